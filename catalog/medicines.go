@@ -0,0 +1,476 @@
+package catalog
+
+import (
+	"apps90-hms/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrMapping = errors.New("column mapping is not valid")
+
+// Rows written per transaction, and per progress update of the job
+const importChunk = 200
+
+// Medicine fields that can be imported, in report order
+var medicineFields = []string{
+	"name", "generic_name", "strength", "dosage_form", "manufacturer", "category",
+	"description", "hsn_code", "tax_rate", "pack_size", "mrp", "selling_price",
+}
+
+// Column headers recognised without a mapping, normalised by normalizeHeader
+var headerAliases = map[string]string{
+	"name": "name", "medicine": "name", "medicine name": "name", "brand": "name", "brand name": "name", "product": "name", "product name": "name",
+	"generic": "generic_name", "generic name": "generic_name", "composition": "generic_name", "salt": "generic_name",
+	"strength": "strength",
+	"form":     "dosage_form", "dosage form": "dosage_form",
+	"manufacturer": "manufacturer", "company": "manufacturer", "mfr": "manufacturer",
+	"category": "category", "group": "category",
+	"description": "description",
+	"hsn":         "hsn_code", "hsn code": "hsn_code", "hsn/sac": "hsn_code",
+	"tax": "tax_rate", "tax rate": "tax_rate", "gst": "tax_rate", "gst %": "tax_rate", "gst rate": "tax_rate",
+	"pack": "pack_size", "pack size": "pack_size", "units per pack": "pack_size",
+	"mrp":   "mrp",
+	"price": "selling_price", "selling price": "selling_price", "sale price": "selling_price", "rate": "selling_price",
+}
+
+var dosageForms = []string{
+	models.DosageFormTablet, models.DosageFormCapsule, models.DosageFormSyrup, models.DosageFormSuspension,
+	models.DosageFormInjection, models.DosageFormInfusion, models.DosageFormOintment, models.DosageFormCream,
+	models.DosageFormDrops, models.DosageFormInhaler, models.DosageFormPowder, models.DosageFormOther,
+}
+
+// MedicineImport is a parsed file ready to import into an entity's catalog
+type MedicineImport struct {
+	columns map[string]int
+	headers map[string]string
+	rows    []Row
+}
+
+// ParseMapping reads a mapping written as field=Header pairs separated by
+// commas, e.g. "name=Brand,mrp=M.R.P."
+func ParseMapping(spec string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, header, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not field=Header", ErrMapping, pair)
+		}
+		mapping[strings.TrimSpace(field)] = strings.TrimSpace(header)
+	}
+	return mapping, nil
+}
+
+// PrepareMedicineImport finds the column of each field in the header row,
+// the first row of the file. mapping names the header of fields whose column
+// is not recognised by name; name and category columns are required.
+func PrepareMedicineImport(rows []Row, mapping map[string]string) (*MedicineImport, error) {
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("%w: the file needs a header row and at least one medicine", ErrMapping)
+	}
+
+	positions := map[string]int{}
+	for i, header := range rows[0].Cells {
+		if key := normalizeHeader(header); key != "" {
+			if _, taken := positions[key]; !taken {
+				positions[key] = i
+			}
+		}
+	}
+
+	prepared := &MedicineImport{columns: map[string]int{}, headers: map[string]string{}, rows: rows[1:]}
+	claimed := map[int]bool{}
+	for field, header := range mapping {
+		if !isMedicineField(field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrMapping, field)
+		}
+		position, ok := positions[normalizeHeader(header)]
+		if !ok {
+			return nil, fmt.Errorf("%w: no column %q for %s", ErrMapping, header, field)
+		}
+		prepared.columns[field] = position
+		prepared.headers[field] = rows[0].Cells[position]
+		claimed[position] = true
+	}
+	for key, position := range positions {
+		field, ok := headerAliases[key]
+		if !ok || claimed[position] {
+			continue
+		}
+		if _, mapped := prepared.columns[field]; !mapped {
+			prepared.columns[field] = position
+			prepared.headers[field] = rows[0].Cells[position]
+		}
+	}
+
+	for _, field := range []string{"name", "category"} {
+		if _, ok := prepared.columns[field]; !ok {
+			return nil, fmt.Errorf("%w: no %s column; map one explicitly", ErrMapping, field)
+		}
+	}
+	return prepared, nil
+}
+
+// Rows is the number of medicine rows, excluding the header
+func (m *MedicineImport) Rows() int {
+	return len(m.rows)
+}
+
+// Mapping returns the columns used as JSON of field to header
+func (m *MedicineImport) Mapping() string {
+	mapping, _ := json.Marshal(m.headers)
+	return string(mapping)
+}
+
+// medicineRow is a validated row. Empty cells are nil or "" and leave an
+// existing medicine's value unchanged.
+type medicineRow struct {
+	number       int
+	values       map[string]string
+	taxRate      *float64
+	packSize     *int
+	mrp          *float64
+	sellingPrice *float64
+}
+
+// Run imports the rows into job.EntityID's catalog. Each row creates a
+// medicine, updates the medicine of the same name in the same category, or
+// is reported as an issue; categories are created as needed. A dry run
+// validates and counts without writing. Rows are written in chunks, so if
+// the database fails part way the job is marked failed with the chunks
+// before it imported.
+func (m *MedicineImport) Run(db *gorm.DB, job *models.ImportJob) error {
+	now := time.Now()
+	job.Status = models.ImportJobRunning
+	job.StartedAt = &now
+	job.TotalRows = len(m.rows)
+	if err := db.Model(job).Updates(map[string]interface{}{
+		"status": job.Status, "started_at": now, "total_rows": job.TotalRows,
+	}).Error; err != nil {
+		return err
+	}
+
+	err := m.run(db, job)
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = models.ImportJobCompleted
+	if err != nil {
+		job.Status = models.ImportJobFailed
+		job.Message = "Import stopped: " + err.Error()
+	}
+	if updateErr := db.Model(job).Updates(map[string]interface{}{
+		"status": job.Status, "finished_at": finished, "message": job.Message,
+	}).Error; err == nil {
+		err = updateErr
+	}
+	return err
+}
+
+func (m *MedicineImport) run(db *gorm.DB, job *models.ImportJob) error {
+	var categories []models.MedicineCategory
+	if err := db.Where("entity_id = ?", job.EntityID).Find(&categories).Error; err != nil {
+		return err
+	}
+	categoryIDs := map[string]uint{}
+	for _, category := range categories {
+		categoryIDs[strings.ToLower(category.Name)] = category.ID
+	}
+	newCategories := map[string]bool{}
+	seen := map[string]int{}
+
+	for start := 0; start < len(m.rows); start += importChunk {
+		chunk := m.rows[start:min(start+importChunk, len(m.rows))]
+
+		var issues []models.ImportJobIssue
+		report := func(number int, name, message string) {
+			issues = append(issues, models.ImportJobIssue{ImportJobID: job.ID, RowNumber: number, Name: name, Message: message})
+		}
+
+		var parsed []medicineRow
+		var names []string
+		for _, row := range chunk {
+			medicine, problems := m.parse(row)
+			name := medicine.values["name"]
+			if len(problems) > 0 {
+				report(row.Number, name, strings.Join(problems, "; "))
+				continue
+			}
+			key := strings.ToLower(name)
+			if first, ok := seen[key]; ok {
+				report(row.Number, name, fmt.Sprintf("Duplicate of row %d", first))
+				continue
+			}
+			seen[key] = row.Number
+			parsed = append(parsed, medicine)
+			names = append(names, key)
+		}
+
+		var existing []models.Medicine
+		if len(names) > 0 {
+			if err := db.Preload("Category").Where("entity_id = ? AND LOWER(name) IN ?", job.EntityID, names).Find(&existing).Error; err != nil {
+				return err
+			}
+		}
+		byName := map[string]models.Medicine{}
+		for _, medicine := range existing {
+			byName[strings.ToLower(medicine.Name)] = medicine
+		}
+
+		var creates []models.Medicine
+		var createRows []medicineRow
+		updates := map[uint]map[string]interface{}{}
+		for _, row := range parsed {
+			name, category := row.values["name"], row.values["category"]
+			current, found := byName[strings.ToLower(name)]
+			switch {
+			case found && !strings.EqualFold(current.Category.Name, category):
+				report(row.number, name, fmt.Sprintf("Already in the catalog under category %q", current.Category.Name))
+			case found:
+				changes, problem := row.changes(current)
+				if problem != "" {
+					report(row.number, name, problem)
+				} else if len(changes) == 0 {
+					job.UnchangedCount++
+				} else {
+					updates[current.ID] = changes
+					job.UpdatedCount++
+				}
+			default:
+				if _, ok := categoryIDs[strings.ToLower(category)]; !ok {
+					newCategories[strings.ToLower(category)] = true
+				}
+				creates = append(creates, row.medicine(job.EntityID))
+				createRows = append(createRows, row)
+				job.CreatedCount++
+			}
+		}
+
+		if !job.DryRun {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				for i := range creates {
+					categoryID, err := ensureCategory(tx, job.EntityID, createRows[i].values["category"], categoryIDs)
+					if err != nil {
+						return err
+					}
+					creates[i].CategoryID = categoryID
+				}
+				if len(creates) > 0 {
+					if err := tx.Create(&creates).Error; err != nil {
+						return err
+					}
+				}
+				for id, changes := range updates {
+					if err := tx.Model(&models.Medicine{}).Where("id = ?", id).Updates(changes).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				job.CreatedCount -= len(creates)
+				job.UpdatedCount -= len(updates)
+				return err
+			}
+		}
+
+		if len(issues) > 0 {
+			if err := db.CreateInBatches(&issues, importChunk).Error; err != nil {
+				return err
+			}
+		}
+		job.ErrorCount += len(issues)
+		job.ProcessedRows += len(chunk)
+		if err := db.Model(job).Updates(map[string]interface{}{
+			"processed_rows":  job.ProcessedRows,
+			"created_count":   job.CreatedCount,
+			"updated_count":   job.UpdatedCount,
+			"unchanged_count": job.UnchangedCount,
+			"error_count":     job.ErrorCount,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(newCategories) > 0 {
+		verb := "Created"
+		if job.DryRun {
+			verb = "Would create"
+		}
+		job.Message = fmt.Sprintf("%s %d new categories", verb, len(newCategories))
+	}
+	return nil
+}
+
+// ensureCategory returns the entity's category of the given name, creating
+// it on first use
+func ensureCategory(tx *gorm.DB, entityID uint, name string, categoryIDs map[string]uint) (uint, error) {
+	if id, ok := categoryIDs[strings.ToLower(name)]; ok {
+		return id, nil
+	}
+	category := models.MedicineCategory{Name: name, EntityID: entityID}
+	if err := tx.Create(&category).Error; err != nil {
+		return 0, err
+	}
+	categoryIDs[strings.ToLower(name)] = category.ID
+	return category.ID, nil
+}
+
+// parse validates a row against the rules of the add-medicine form
+func (m *MedicineImport) parse(row Row) (medicineRow, []string) {
+	medicine := medicineRow{number: row.Number, values: map[string]string{}}
+	for field, column := range m.columns {
+		if column < len(row.Cells) {
+			medicine.values[field] = strings.TrimSpace(row.Cells[column])
+		}
+	}
+
+	var problems []string
+	checkLength := func(field string, limit int) {
+		if len([]rune(medicine.values[field])) > limit {
+			problems = append(problems, fmt.Sprintf("%s is longer than %d characters", field, limit))
+		}
+	}
+	if medicine.values["name"] == "" {
+		problems = append(problems, "name is required")
+	}
+	if medicine.values["category"] == "" {
+		problems = append(problems, "category is required")
+	}
+	checkLength("name", 100)
+	checkLength("category", 100)
+	checkLength("generic_name", 150)
+	checkLength("strength", 50)
+	checkLength("manufacturer", 150)
+
+	if form := medicine.values["dosage_form"]; form != "" {
+		medicine.values["dosage_form"] = ""
+		for _, known := range dosageForms {
+			if strings.EqualFold(form, known) || strings.EqualFold(strings.TrimSuffix(form, "s"), known) {
+				medicine.values["dosage_form"] = known
+			}
+		}
+		if medicine.values["dosage_form"] == "" {
+			problems = append(problems, fmt.Sprintf("dosage_form %q is not one of %s", form, strings.Join(dosageForms, ", ")))
+		}
+	}
+	if hsn := medicine.values["hsn_code"]; hsn != "" {
+		if _, err := strconv.ParseUint(hsn, 10, 64); err != nil || len(hsn) < 4 || len(hsn) > 8 {
+			problems = append(problems, "hsn_code must be 4 to 8 digits")
+		}
+	}
+
+	number := func(field string, max float64) *float64 {
+		text := strings.NewReplacer(",", "", "₹", "", "%", "", " ", "").Replace(medicine.values[field])
+		if text == "" {
+			return nil
+		}
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || value < 0 || value > max {
+			problems = append(problems, fmt.Sprintf("%s %q is not a valid amount", field, medicine.values[field]))
+			return nil
+		}
+		return &value
+	}
+	medicine.taxRate = number("tax_rate", 100)
+	medicine.mrp = number("mrp", math.MaxFloat64)
+	medicine.sellingPrice = number("selling_price", math.MaxFloat64)
+	if packSize := number("pack_size", math.MaxInt32); packSize != nil {
+		if *packSize != math.Trunc(*packSize) {
+			problems = append(problems, "pack_size must be a whole number")
+		} else {
+			size := int(*packSize)
+			medicine.packSize = &size
+		}
+	}
+	if medicine.mrp != nil && medicine.sellingPrice != nil && *medicine.mrp > 0 && *medicine.sellingPrice > *medicine.mrp {
+		problems = append(problems, "selling_price cannot exceed mrp")
+	}
+	return medicine, problems
+}
+
+func (r medicineRow) medicine(entityID uint) models.Medicine {
+	medicine := models.Medicine{
+		Name:         r.values["name"],
+		GenericName:  r.values["generic_name"],
+		Strength:     r.values["strength"],
+		DosageForm:   r.values["dosage_form"],
+		Manufacturer: r.values["manufacturer"],
+		Description:  r.values["description"],
+		HSNCode:      r.values["hsn_code"],
+		EntityID:     entityID,
+	}
+	if r.taxRate != nil {
+		medicine.TaxRate = *r.taxRate
+	}
+	if r.packSize != nil {
+		medicine.PackSize = *r.packSize
+	}
+	if r.mrp != nil {
+		medicine.MRP = *r.mrp
+	}
+	if r.sellingPrice != nil {
+		medicine.SellingPrice = *r.sellingPrice
+	}
+	return medicine
+}
+
+// changes lists the columns a row would change on an existing medicine,
+// ignoring empty cells, or explains why the merged values are not valid
+func (r medicineRow) changes(current models.Medicine) (map[string]interface{}, string) {
+	changes := map[string]interface{}{}
+	text := map[string]string{
+		"generic_name": current.GenericName,
+		"strength":     current.Strength,
+		"dosage_form":  current.DosageForm,
+		"manufacturer": current.Manufacturer,
+		"description":  current.Description,
+		"hsn_code":     current.HSNCode,
+	}
+	for field, value := range text {
+		if r.values[field] != "" && r.values[field] != value {
+			changes[field] = r.values[field]
+		}
+	}
+	if r.taxRate != nil && *r.taxRate != current.TaxRate {
+		changes["tax_rate"] = *r.taxRate
+	}
+	if r.packSize != nil && *r.packSize != current.PackSize {
+		changes["pack_size"] = *r.packSize
+	}
+	mrp, sellingPrice := current.MRP, current.SellingPrice
+	if r.mrp != nil && *r.mrp != current.MRP {
+		changes["mrp"] = *r.mrp
+		mrp = *r.mrp
+	}
+	if r.sellingPrice != nil && *r.sellingPrice != current.SellingPrice {
+		changes["selling_price"] = *r.sellingPrice
+		sellingPrice = *r.sellingPrice
+	}
+	if mrp > 0 && sellingPrice > mrp {
+		return nil, "selling_price would exceed mrp"
+	}
+	return changes, ""
+}
+
+func isMedicineField(field string) bool {
+	for _, known := range medicineFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHeader(header string) string {
+	header = strings.ToLower(strings.ReplaceAll(header, "_", " "))
+	return strings.Join(strings.Fields(header), " ")
+}
@@ -0,0 +1,294 @@
+package catalog
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("file must be CSV or XLSX")
+
+// Limit on the uncompressed size of any part read from an XLSX file
+const maxXLSXPart = 256 << 20
+
+// Number of columns in a worksheet, A to XFD
+const maxColumns = 16384
+
+// Row is one non-empty line of a sheet. Number is the line or row number as
+// the user sees it in their editor, for error reports.
+type Row struct {
+	Number int
+	Cells  []string
+}
+
+// Format returns "csv" or "xlsx" from a file name
+func Format(fileName string) (string, error) {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return "csv", nil
+	case ".xlsx":
+		return "xlsx", nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ReadSheet reads every non-empty row of a CSV file, or of the first
+// worksheet of an XLSX workbook
+func ReadSheet(r io.ReaderAt, size int64, format string) ([]Row, error) {
+	switch format {
+	case "csv":
+		return readCSV(io.NewSectionReader(r, 0, size))
+	case "xlsx":
+		return readXLSX(r, size)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// readCSV accepts comma or semicolon separated files, as spreadsheet programs
+// in many locales write the latter, with or without a byte order mark
+func readCSV(r io.Reader) ([]Row, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if !blank(record) {
+			rows = append(rows, Row{Number: line, Cells: record})
+		}
+	}
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxText is plain text in <t> or rich text split over runs <r><t>
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.T)
+	}
+	return text.String()
+}
+
+type xlsxRow struct {
+	R     int `xml:"r,attr"`
+	Cells []struct {
+		R      string   `xml:"r,attr"`
+		T      string   `xml:"t,attr"`
+		V      string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// readXLSX reads the first worksheet using only the parts of the format a
+// catalog export needs: shared strings, inline strings and plain values
+func readXLSX(r io.ReaderAt, size int64) ([]Row, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a valid XLSX file", ErrUnsupportedFormat)
+	}
+	parts := map[string]*zip.File{}
+	for _, file := range archive.File {
+		parts[strings.TrimPrefix(file.Name, "/")] = file
+	}
+
+	sheetPath, err := firstSheet(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if part, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(part, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	part, ok := parts[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("%w: worksheet %s is missing", ErrUnsupportedFormat, sheetPath)
+	}
+	file, err := part.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rows []Row
+	decoder := xml.NewDecoder(io.LimitReader(file, maxXLSXPart))
+	number := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := decoder.DecodeElement(&row, &start); err != nil {
+			return nil, err
+		}
+		number++
+		if row.R > 0 {
+			number = row.R
+		}
+
+		var cells []string
+		for i, cell := range row.Cells {
+			column := i
+			if cell.R != "" {
+				var ok bool
+				if column, ok = columnIndex(cell.R); !ok {
+					return nil, fmt.Errorf("%w: bad cell reference %q", ErrUnsupportedFormat, cell.R)
+				}
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+			switch cell.T {
+			case "s":
+				index, err := strconv.Atoi(cell.V)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: bad shared string in cell %s", ErrUnsupportedFormat, cell.R)
+				}
+				cells[column] = shared.Items[index].String()
+			case "inlineStr":
+				cells[column] = cell.Inline.String()
+			default:
+				cells[column] = cell.V
+			}
+		}
+		if !blank(cells) {
+			rows = append(rows, Row{Number: number, Cells: cells})
+		}
+	}
+}
+
+// firstSheet resolves the path of the workbook's first worksheet
+func firstSheet(parts map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	workbookPart, ok1 := parts["xl/workbook.xml"]
+	relsPart, ok2 := parts["xl/_rels/workbook.xml.rels"]
+	if !ok1 || !ok2 {
+		return "", fmt.Errorf("%w: workbook is missing", ErrUnsupportedFormat)
+	}
+	if err := decodePart(workbookPart, &workbook); err != nil {
+		return "", err
+	}
+	if err := decodePart(relsPart, &rels); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: workbook has no sheets", ErrUnsupportedFormat)
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("%w: sheet %q has no worksheet", ErrUnsupportedFormat, workbook.Sheets[0].Name)
+}
+
+func decodePart(part *zip.File, v interface{}) error {
+	file, err := part.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := xml.NewDecoder(io.LimitReader(file, maxXLSXPart)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrUnsupportedFormat, part.Name, err)
+	}
+	return nil
+}
+
+// columnIndex turns the letters of a cell reference such as "AB12" into a
+// zero-based column index. It reports false for anything but letters followed
+// by a row number, or a column past XFD, the last one Excel allows.
+func columnIndex(ref string) (int, bool) {
+	letters := 0
+	index := 0
+	for letters < len(ref) && ref[letters] >= 'A' && ref[letters] <= 'Z' {
+		index = index*26 + int(ref[letters]-'A') + 1
+		letters++
+		if index > maxColumns {
+			return 0, false
+		}
+	}
+	digits := ref[letters:]
+	if letters == 0 || digits == "" || digits[0] == '0' || strings.Trim(digits, "0123456789") != "" {
+		return 0, false
+	}
+	return index - 1, true
+}
+
+func blank(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
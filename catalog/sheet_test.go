@@ -0,0 +1,31 @@
+package catalog
+
+import "testing"
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+		ok   bool
+	}{
+		{"A1", 0, true},
+		{"Z9", 25, true},
+		{"AA10", 26, true},
+		{"AB12", 27, true},
+		{"XFD1048576", 16383, true},
+		{"XFE1", 0, false},
+		{"ZZZZZZZZ1", 0, false},
+		{"1", 0, false},
+		{"a1", 0, false},
+		{"A", 0, false},
+		{"A0", 0, false},
+		{"A1B", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		got, ok := columnIndex(test.ref)
+		if got != test.want || ok != test.ok {
+			t.Errorf("columnIndex(%q) = %d, %v, want %d, %v", test.ref, got, ok, test.want, test.ok)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package appointmentControllers
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/inpatient"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdmitPatient opens an IP visit for a patient, optionally allocating a bed
+func AdmitPatient(c *gin.Context) {
+	var input schemas.AdmitInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Admit Patient", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var patient models.Patient
+	if err := initializers.DB.First(&patient, input.PatientID).Error; err != nil {
+		logger.Warn("Patient not found", "patient_id", input.PatientID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Patient not found", "status": "Error"})
+		return
+	}
+
+	var doctor models.Employee
+	if err := initializers.DB.First(&doctor, input.DoctorID).Error; err != nil {
+		logger.Warn("Doctor not found", "doctor_id", input.DoctorID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Doctor not found", "status": "Error"})
+		return
+	}
+
+	if input.AppointmentID != nil {
+		var appointment models.Appointment
+		if err := initializers.DB.First(&appointment, *input.AppointmentID).Error; err != nil {
+			logger.Warn("Appointment not found", "appointment_id", *input.AppointmentID)
+			c.JSON(http.StatusNotFound, gin.H{"message": "Appointment not found", "status": "Error"})
+			return
+		}
+	}
+
+	visit := models.Visit{
+		AppointmentID: input.AppointmentID,
+		Diagnosis:     input.Diagnosis,
+		TreatmentPlan: input.TreatmentPlan,
+		PatientID:     input.PatientID,
+		DoctorID:      input.DoctorID,
+	}
+	if input.AdmissionDate != nil {
+		visit.AdmissionDate = *input.AdmissionDate
+	}
+
+	admit(c, &visit, patient, input.BedID, input.WardID, input.Notes)
+}
+
+// admit runs the admission workflow and writes the response
+func admit(c *gin.Context, visit *models.Visit, patient models.Patient, bedID, wardID *uint, notes string) {
+	logger := loggers.InitializeLogger()
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return inpatient.Admit(tx, visit, patient, bedID, wardID, notes)
+	})
+	if inpatient.IsWorkflowError(err) {
+		logger.Warn("Admission rejected", "patient_id", patient.ID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Admission failed: " + err.Error(), "status": "Error"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to admit patient", "patient_id", patient.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create visit", "status": "Error"})
+		return
+	}
+
+	logger.Info("Patient admitted", "visit_id", visit.ID, "patient_id", patient.ID, "bed_id", visit.BedID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    visit.ID,
+		"message": "Successfully created visit",
+		"status":  "Success",
+	})
+}
+
+// TransferVisit moves an admitted patient to another bed or ward and/or
+// another attending doctor
+func TransferVisit(c *gin.Context) {
+	var input schemas.TransferVisitInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Transfer Visit", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var visit models.Visit
+	if err := initializers.DB.Preload("Patient").First(&visit, input.VisitID).Error; err != nil {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Visit not found", "status": "Error"})
+		return
+	}
+
+	if input.DoctorID != nil {
+		var doctor models.Employee
+		if err := initializers.DB.First(&doctor, *input.DoctorID).Error; err != nil {
+			logger.Warn("Doctor not found", "doctor_id", *input.DoctorID)
+			c.JSON(http.StatusNotFound, gin.H{"message": "Doctor not found", "status": "Error"})
+			return
+		}
+	}
+
+	transferredAt := time.Now()
+	if input.TransferTime != nil {
+		transferredAt = *input.TransferTime
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return inpatient.Transfer(tx, &visit, visit.Patient, input.BedID, input.WardID, input.DoctorID, transferredAt, input.Notes)
+	})
+	if inpatient.IsWorkflowError(err) {
+		logger.Warn("Transfer rejected", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Transfer failed: " + err.Error(), "status": "Error"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to transfer visit", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to transfer visit", "status": "Error"})
+		return
+	}
+
+	logger.Info("Visit transferred", "visit_id", visit.ID, "bed_id", visit.BedID, "doctor_id", visit.DoctorID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    visit.ID,
+		"message": "Successfully transferred visit",
+		"status":  "Success",
+	})
+}
+
+// DischargeVisit records the discharge of an IP visit and releases its bed
+func DischargeVisit(c *gin.Context) {
+	var input schemas.DischargeVisitInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Discharge Visit", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var visit models.Visit
+	if err := initializers.DB.First(&visit, input.VisitID).Error; err != nil {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Visit not found", "status": "Error"})
+		return
+	}
+
+	dischargedAt := time.Now()
+	if input.DischargeDate != nil {
+		dischargedAt = *input.DischargeDate
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return inpatient.Discharge(tx, &visit, dischargedAt, input.Notes)
+	})
+	if inpatient.IsWorkflowError(err) {
+		logger.Warn("Discharge rejected", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Discharge failed: " + err.Error(), "status": "Error"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to discharge visit", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to discharge visit", "status": "Error"})
+		return
+	}
+
+	logger.Info("Visit discharged", "visit_id", visit.ID, "bed_id", visit.BedID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    visit.ID,
+		"message": "Successfully discharged visit",
+		"status":  "Success",
+	})
+}
+
+// GetAdtHistory lists the admission, transfer and discharge events of a visit
+func GetAdtHistory(c *gin.Context) {
+	visitID := c.Query("visit_id")
+	logger := loggers.InitializeLogger()
+
+	if visitID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"data": nil, "message": "Missing visit_id", "status": "Error"})
+		return
+	}
+
+	var events []models.AdtEvent
+	if err := initializers.DB.Preload("FromBed.Room").Preload("ToBed.Room").
+		Where("visit_id = ?", visitID).Order("event_time, id").Find(&events).Error; err != nil {
+		logger.Error("Failed to fetch ADT history", "visit_id", visitID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"data": nil, "message": "Failed to fetch ADT history", "status": "Error"})
+		return
+	}
+
+	history := []map[string]interface{}{}
+	for _, event := range events {
+		entry := map[string]interface{}{
+			"id":             event.ID,
+			"event_type":     event.EventType,
+			"event_time":     event.EventTime,
+			"from_bed_id":    event.FromBedID,
+			"to_bed_id":      event.ToBedID,
+			"from_doctor_id": event.FromDoctorID,
+			"to_doctor_id":   event.ToDoctorID,
+			"notes":          event.Notes,
+		}
+		if event.FromBed != nil {
+			entry["from_bed"] = event.FromBed.Room.RoomNumber + "/" + event.FromBed.BedNumber
+		}
+		if event.ToBed != nil {
+			entry["to_bed"] = event.ToBed.Room.RoomNumber + "/" + event.ToBed.BedNumber
+		}
+		history = append(history, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    history,
+		"message": "Successfully fetched ADT history",
+		"status":  "Success",
+	})
+}
@@ -5,10 +5,17 @@ import (
 	"apps90-hms/initializers"
 	"apps90-hms/loggers"
 	"apps90-hms/models"
+	"apps90-hms/notifications"
+	"apps90-hms/scheduling"
 	"apps90-hms/schemas"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func CreateAppointment(c *gin.Context) {
@@ -59,9 +66,22 @@ func CreateAppointment(c *gin.Context) {
 		PatientID:       appointmentInput.PatientID,
 		EmployeeID:      appointmentInput.DoctorID,
 		EntityID:        appointmentInput.EntityID,
+		DurationMinutes: defaultDurationMinutes,
+		Status:          models.AppointmentStatusScheduled,
 	}
 
-	initializers.DB.Create(&appointment)
+	// Write the appointment and its reminders to the outbox together
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&appointment).Error; err != nil {
+			return err
+		}
+		return notifications.EnqueueAppointmentReminders(tx, appointment.ID)
+	})
+	if err != nil {
+		logger.Error("Failed to create appointment", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create appointment"))
+		return
+	}
 
 	logger.Info("Appointment created successfully", "appointment_id", appointment.ID)
 
@@ -122,6 +142,9 @@ func GetAppointments(c *gin.Context) {
 			"patient_dob":       appointment.Patient.DateOfBirth,
 			"doctor_firstname":  appointment.Employee.FirstName,
 			"doctor_lastname":   appointment.Employee.LastName,
+			"duration_minutes":  appointment.DurationMinutes,
+			"status":            appointment.Status,
+			"series_id":         appointment.SeriesID,
 		})
 	}
 
@@ -133,6 +156,346 @@ func GetAppointments(c *gin.Context) {
 	})
 }
 
+// CreateRecurringAppointments books a series of linked appointments from a
+// recurrence rule. Occurrences that clash with an existing appointment for the
+// doctor or patient are skipped and reported back instead of failing the series.
+func CreateRecurringAppointments(c *gin.Context) {
+	var input schemas.RecurringAppointmentInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Create Recurring Appointments", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	// Build the rule from either the RRULE string or the structured fields
+	var rule recurrenceRule
+	var err error
+	if input.Rule != "" {
+		rule, err = parseRule(input.Rule)
+	} else {
+		rule = recurrenceRule{
+			Frequency: strings.ToUpper(input.Frequency),
+			Interval:  input.Interval,
+			Count:     input.Count,
+			Until:     input.Until,
+		}
+		err = rule.validate()
+	}
+	var occurrences []time.Time
+	if err == nil {
+		occurrences, err = rule.occurrences(input.AppointmentTime)
+	}
+	if err != nil {
+		logger.Warn("Invalid recurrence rule", "rule", input.Rule, "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Invalid recurrence rule: "+err.Error()))
+		return
+	}
+
+	var patient models.Patient
+	initializers.DB.First(&patient, input.PatientID)
+	if patient.ID == 0 {
+		logger.Warn("Patient not found", "patient_id", input.PatientID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Patient not found"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+
+	var entity models.Entity
+	initializers.DB.First(&entity, input.EntityID)
+	if entity.ID == 0 {
+		logger.Warn("Entity not found", "entity_id", input.EntityID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Entity not found"))
+		return
+	}
+
+	duration := input.DurationMinutes
+	if duration <= 0 {
+		duration = defaultDurationMinutes
+	}
+
+	series := models.AppointmentSeries{
+		Rule:       rule.String(),
+		Frequency:  rule.Frequency,
+		Interval:   rule.Interval,
+		Count:      rule.Count,
+		Until:      rule.Until,
+		PatientID:  input.PatientID,
+		EmployeeID: input.DoctorID,
+		EntityID:   input.EntityID,
+	}
+
+	createdIDs := []uint{}
+	skipped := []schemas.SkippedOccurrence{}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+
+		for _, occurrence := range occurrences {
+			conflict, err := scheduling.FindConflict(tx, input.DoctorID, input.PatientID, occurrence, duration)
+			if err != nil {
+				return err
+			}
+			if conflict != nil {
+				skipped = append(skipped, schemas.SkippedOccurrence{
+					AppointmentTime: occurrence,
+					Reason:          fmt.Sprintf("Conflicts with appointment %d", conflict.ID),
+				})
+				continue
+			}
+
+			appointment := models.Appointment{
+				AppointmentTime: occurrence,
+				Reason:          input.Reason,
+				Notes:           input.Notes,
+				PatientID:       input.PatientID,
+				EmployeeID:      input.DoctorID,
+				EntityID:        input.EntityID,
+				DurationMinutes: duration,
+				Status:          models.AppointmentStatusScheduled,
+				SeriesID:        &series.ID,
+			}
+			if err := tx.Create(&appointment).Error; err != nil {
+				return err
+			}
+			if err := notifications.EnqueueAppointmentReminders(tx, appointment.ID); err != nil {
+				return err
+			}
+			createdIDs = append(createdIDs, appointment.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to create recurring appointments", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create recurring appointments"))
+		return
+	}
+
+	logger.Info("Recurring appointments created", "series_id", series.ID, "created", len(createdIDs), "skipped", len(skipped))
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"series_id":       series.ID,
+			"rule":            series.Rule,
+			"appointment_ids": createdIDs,
+			"skipped":         skipped,
+		},
+		"message": "Successfully created recurring appointments",
+		"status":  "Success",
+	})
+}
+
+// UpdateAppointment edits a single occurrence, with scope "following" the
+// selected occurrence and every later one in its series, or with scope
+// "series" every occurrence of the series. A time change on a series edit
+// shifts each occurrence by the same offset.
+func UpdateAppointment(c *gin.Context) {
+	var input schemas.UpdateAppointmentInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Appointment", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var appointment models.Appointment
+	if err := initializers.DB.First(&appointment, input.AppointmentID).Error; err != nil {
+		logger.Warn("Appointment not found", "appointment_id", input.AppointmentID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Appointment not found"))
+		return
+	}
+
+	if appointment.Status == models.AppointmentStatusCancelled {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Cancelled appointments cannot be edited"))
+		return
+	}
+
+	if input.DoctorID != nil {
+		var doctor models.Employee
+		initializers.DB.First(&doctor, *input.DoctorID)
+		if doctor.ID == 0 {
+			logger.Warn("Doctor not found", "employee_id", *input.DoctorID)
+			c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+			return
+		}
+	}
+
+	var shift time.Duration
+	if input.AppointmentTime != nil {
+		shift = input.AppointmentTime.Sub(appointment.AppointmentTime)
+	}
+
+	var updatedIDs []uint
+	var conflicts []schemas.SkippedOccurrence
+
+	// Reminders are regenerated so they follow the new time and doctor
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the appointments affected by this edit so concurrent edits of
+		// the same series are checked one after the other
+		query := tx.Model(&models.Appointment{}).Where("id = ?", appointment.ID)
+		if series := seriesScope(tx, appointment, input.Scope); series != nil {
+			query = series.Where("status <> ?", models.AppointmentStatusCancelled)
+		}
+		var targets []models.Appointment
+		if err := query.Clauses(clause.Locking{Strength: "UPDATE"}).Order("appointment_time").Find(&targets).Error; err != nil {
+			return err
+		}
+
+		updatedIDs = make([]uint, 0, len(targets))
+		for _, target := range targets {
+			updatedIDs = append(updatedIDs, target.ID)
+		}
+
+		// Apply the changes and check every resulting slot for conflicts first,
+		// ignoring the old slots of the appointments being moved
+		for i := range targets {
+			target := &targets[i]
+			target.AppointmentTime = target.AppointmentTime.Add(shift)
+			if input.DurationMinutes != nil && *input.DurationMinutes > 0 {
+				target.DurationMinutes = *input.DurationMinutes
+			}
+			if input.Reason != nil {
+				target.Reason = *input.Reason
+			}
+			if input.Notes != nil {
+				target.Notes = *input.Notes
+			}
+			if input.DoctorID != nil {
+				target.EmployeeID = *input.DoctorID
+			}
+
+			conflict, err := scheduling.FindConflict(tx, target.EmployeeID, target.PatientID, target.AppointmentTime, target.DurationMinutes, updatedIDs...)
+			if err != nil {
+				return err
+			}
+			if conflict != nil {
+				conflicts = append(conflicts, schemas.SkippedOccurrence{
+					AppointmentTime: target.AppointmentTime,
+					Reason:          fmt.Sprintf("Conflicts with appointment %d", conflict.ID),
+				})
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil
+		}
+
+		for i := range targets {
+			if err := tx.Select("appointment_time", "duration_minutes", "reason", "notes", "employee_id").
+				Updates(&targets[i]).Error; err != nil {
+				return err
+			}
+		}
+		if err := notifications.CancelAppointmentReminders(tx, updatedIDs); err != nil {
+			return err
+		}
+		for _, id := range updatedIDs {
+			if err := notifications.EnqueueAppointmentReminders(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to update appointment", "appointment_id", appointment.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update appointment"))
+		return
+	}
+
+	if len(conflicts) > 0 {
+		logger.Warn("Appointment update conflicts", "appointment_id", appointment.ID, "conflicts", len(conflicts))
+		c.JSON(http.StatusConflict, gin.H{"data": conflicts, "message": "Appointment update conflicts with existing appointments", "status": "Error"})
+		return
+	}
+
+	logger.Info("Appointment updated successfully", "appointment_id", appointment.ID, "updated", len(updatedIDs))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    updatedIDs,
+		"message": "Successfully updated appointment",
+		"status":  "Success",
+	})
+}
+
+// CancelAppointment cancels a single occurrence, with scope "following" the
+// selected occurrence and every later one in its series, or with scope
+// "series" every occurrence of the series.
+func CancelAppointment(c *gin.Context) {
+	var input schemas.CancelAppointmentInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Cancel Appointment", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var appointment models.Appointment
+	if err := initializers.DB.First(&appointment, input.AppointmentID).Error; err != nil {
+		logger.Warn("Appointment not found", "appointment_id", input.AppointmentID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Appointment not found"))
+		return
+	}
+
+	query := initializers.DB.Model(&models.Appointment{}).Where("id = ?", appointment.ID)
+	if series := seriesScope(initializers.DB, appointment, input.Scope); series != nil {
+		query = series
+	}
+
+	var cancelled []models.Appointment
+	if err := query.Where("status <> ?", models.AppointmentStatusCancelled).Find(&cancelled).Error; err != nil {
+		logger.Error("Failed to load appointments to cancel", "appointment_id", appointment.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to cancel appointment"))
+		return
+	}
+
+	cancelledIDs := make([]uint, 0, len(cancelled))
+	for _, a := range cancelled {
+		cancelledIDs = append(cancelledIDs, a.ID)
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if len(cancelledIDs) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.Appointment{}).Where("id IN ?", cancelledIDs).
+			Update("status", models.AppointmentStatusCancelled).Error; err != nil {
+			return err
+		}
+		if err := notifications.CancelAppointmentReminders(tx, cancelledIDs); err != nil {
+			return err
+		}
+
+		// Offer each freed slot to the waitlist
+		for _, a := range cancelled {
+			if _, err := scheduling.OfferFreedSlot(tx, a.EmployeeID, a.AppointmentTime, a.DurationMinutes, a.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to cancel appointment", "appointment_id", appointment.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to cancel appointment"))
+		return
+	}
+
+	logger.Info("Appointment cancelled", "appointment_id", appointment.ID, "scope", input.Scope, "cancelled", len(cancelledIDs))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    cancelledIDs,
+		"message": "Successfully cancelled appointment",
+		"status":  "Success",
+	})
+}
+
 func CreateVisit(c *gin.Context) {
 	var input schemas.VisitInput // Use your appropriate input schema
 	logger := loggers.InitializeLogger()
@@ -192,6 +555,23 @@ func CreateVisit(c *gin.Context) {
 		return
 	}
 
+	// Beds are only allocated to inpatients
+	if (input.BedID != nil || input.WardID != nil) && visit.VisitType != "IP" {
+		logger.Warn("Bed requested for outpatient visit", "patient_id", input.PatientID)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Beds can only be allocated to IP visits", "status": "Error"})
+		return
+	}
+
+	// IP visits go through the admission workflow; discharge is a separate step
+	if visit.VisitType == "IP" {
+		if input.DischargeDate != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Discharge date cannot be set on admission; use the discharge operation", "status": "Error"})
+			return
+		}
+		admit(c, &visit, patient, input.BedID, input.WardID, "")
+		return
+	}
+
 	// Save the visit
 	if err := initializers.DB.Create(&visit).Error; err != nil {
 		logger.Error("Failed to create visit", "error", err.Error())
@@ -206,3 +586,21 @@ func CreateVisit(c *gin.Context) {
 		"status":  "Success",
 	})
 }
+
+// seriesScope selects the appointments of a series edit or cancellation:
+// "following" is the given occurrence and every later one, "series" is the
+// whole series. It returns nil for a single occurrence or a one-off
+// appointment.
+func seriesScope(db *gorm.DB, appointment models.Appointment, scope string) *gorm.DB {
+	if appointment.SeriesID == nil {
+		return nil
+	}
+	switch scope {
+	case "following":
+		return db.Model(&models.Appointment{}).
+			Where("series_id = ? AND appointment_time >= ?", *appointment.SeriesID, appointment.AppointmentTime)
+	case "series":
+		return db.Model(&models.Appointment{}).Where("series_id = ?", *appointment.SeriesID)
+	}
+	return nil
+}
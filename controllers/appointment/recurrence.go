@@ -0,0 +1,139 @@
+package appointmentControllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences caps the number of appointments a single series may generate
+const maxOccurrences = 200
+
+const defaultDurationMinutes = 15
+
+// recurrenceRule is the parsed form of an RRULE-style recurrence
+type recurrenceRule struct {
+	Frequency string
+	Interval  int
+	Count     int
+	Until     *time.Time
+}
+
+// parseRule parses an RRULE-style string such as "FREQ=DAILY;INTERVAL=2;COUNT=10"
+// or "FREQ=WEEKLY;UNTIL=20250630". Only DAILY and WEEKLY frequencies are supported.
+func parseRule(rule string) (recurrenceRule, error) {
+	var r recurrenceRule
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return r, fmt.Errorf("invalid rule part %q", part)
+		}
+		key, value := strings.ToUpper(kv[0]), kv[1]
+
+		switch key {
+		case "FREQ":
+			r.Frequency = strings.ToUpper(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return r, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return r, fmt.Errorf("invalid COUNT %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return r, err
+			}
+			r.Until = &until
+		default:
+			return r, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	return r, r.validate()
+}
+
+// parseUntil accepts the date and date-time forms used by RFC 5545
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A bare date includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func (r *recurrenceRule) validate() error {
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	if r.Frequency != "DAILY" && r.Frequency != "WEEKLY" {
+		return fmt.Errorf("FREQ must be DAILY or WEEKLY")
+	}
+	if r.Interval < 0 {
+		return fmt.Errorf("INTERVAL must be positive")
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("COUNT must be positive")
+	}
+	if r.Count == 0 && r.Until == nil {
+		return fmt.Errorf("either COUNT or UNTIL is required")
+	}
+	if r.Count > maxOccurrences {
+		return fmt.Errorf("COUNT cannot exceed %d", maxOccurrences)
+	}
+	return nil
+}
+
+// String renders the rule back into RRULE form for storage
+func (r recurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Frequency, "INTERVAL=" + strconv.Itoa(r.Interval)}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// occurrences expands the rule into concrete start times beginning at start.
+// A rule that expands to nothing or to more than maxOccurrences is an error.
+func (r recurrenceRule) occurrences(start time.Time) ([]time.Time, error) {
+	step := r.Interval
+	if r.Frequency == "WEEKLY" {
+		step *= 7
+	}
+
+	var times []time.Time
+	for i := 0; r.Count == 0 || len(times) < r.Count; i++ {
+		t := start.AddDate(0, 0, i*step)
+		if r.Until != nil && t.After(*r.Until) {
+			break
+		}
+		if len(times) == maxOccurrences {
+			return nil, fmt.Errorf("rule cannot generate more than %d occurrences", maxOccurrences)
+		}
+		times = append(times, t)
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("UNTIL is before the first appointment")
+	}
+	return times, nil
+}
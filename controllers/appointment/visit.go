@@ -0,0 +1,230 @@
+package appointmentControllers
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetVisit returns a single visit with its doctor, bed and prescription IDs
+func GetVisit(c *gin.Context) {
+	visitID := c.Query("visit_id")
+	logger := loggers.InitializeLogger()
+
+	if visitID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"data": nil, "message": "Missing visit_id", "status": "Error"})
+		return
+	}
+
+	var visit models.Visit
+	if err := initializers.DB.Preload("Doctor").First(&visit, visitID).Error; err != nil {
+		logger.Warn("Visit not found", "visit_id", visitID)
+		c.JSON(http.StatusNotFound, gin.H{"data": nil, "message": "Visit not found", "status": "Error"})
+		return
+	}
+
+	var prescriptions []models.Prescription
+	if err := initializers.DB.Select("id").Where("visit_id = ?", visit.ID).Find(&prescriptions).Error; err != nil {
+		logger.Error("Error fetching prescriptions for visit", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"data": nil, "message": "Failed to fetch visit", "status": "Error"})
+		return
+	}
+
+	prescriptionList := []schemas.PrescriptionResponse{}
+	for _, prescription := range prescriptions {
+		prescriptionList = append(prescriptionList, schemas.PrescriptionResponse{ID: prescription.ID})
+	}
+
+	response := schemas.VisitResponse{
+		ID:            visit.ID,
+		PatientID:     visit.PatientID,
+		DoctorID:      visit.DoctorID,
+		AppointmentID: visit.AppointmentID,
+		BedID:         visit.BedID,
+		ClosedAt:      visit.ClosedAt,
+		VisitDate:     visit.VisitDate,
+		RoomNumber:    visit.RoomNumber,
+		Diagnosis:     visit.Diagnosis,
+		TreatmentPlan: visit.TreatmentPlan,
+		Notes:         visit.Notes,
+		VisitType:     visit.VisitType,
+		DischargeDate: visit.DischargeDate,
+		DoctorName:    visit.Doctor.FirstName + " " + visit.Doctor.LastName,
+		Prescriptions: prescriptionList,
+	}
+	if visit.VisitType == "IP" {
+		response.AdmissionDate = &visit.AdmissionDate
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    response,
+		"message": "Successfully fetched visit",
+		"status":  "Success",
+	})
+}
+
+// UpdateVisit edits the clinical fields of an open visit. On IP visits the
+// admission date, and the discharge date once discharged, may be corrected as
+// long as they stay in order with the visit's ADT events.
+func UpdateVisit(c *gin.Context) {
+	var input schemas.UpdateVisitInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Visit", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var visit models.Visit
+	if err := initializers.DB.First(&visit, input.VisitID).Error; err != nil {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Visit not found", "status": "Error"})
+		return
+	}
+
+	if visit.ClosedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Closed visits cannot be edited", "status": "Error"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	for column, value := range map[string]*string{
+		"diagnosis":      input.Diagnosis,
+		"treatment_plan": input.TreatmentPlan,
+		"notes":          input.Notes,
+	} {
+		if value != nil {
+			updates[column] = *value
+		}
+	}
+
+	if input.RoomNumber != nil {
+		// An allocated bed determines the room of an IP visit
+		if visit.BedID != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Room of an IP visit follows its bed; use the transfer operation", "status": "Error"})
+			return
+		}
+		updates["room_number"] = *input.RoomNumber
+	}
+
+	if visit.VisitType != "IP" && (input.AdmissionDate != nil || input.DischargeDate != nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Admission and discharge dates only apply to IP visits", "status": "Error"})
+		return
+	}
+	if input.DischargeDate != nil && visit.DischargeDate == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Visit has not been discharged; use the discharge operation", "status": "Error"})
+		return
+	}
+
+	admission := visit.AdmissionDate
+	if input.AdmissionDate != nil {
+		admission = *input.AdmissionDate
+	}
+	discharge := visit.DischargeDate
+	if input.DischargeDate != nil {
+		discharge = input.DischargeDate
+	}
+	if discharge != nil && discharge.Before(admission) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Discharge date must be after the admission date", "status": "Error"})
+		return
+	}
+
+	// Transfers must stay between admission and discharge
+	if input.AdmissionDate != nil || input.DischargeDate != nil {
+		var transfers []models.AdtEvent
+		initializers.DB.Where("visit_id = ? AND event_type = ?", visit.ID, models.AdtEventTransfer).Find(&transfers)
+		for _, transfer := range transfers {
+			if transfer.EventTime.Before(admission) || (discharge != nil && transfer.EventTime.After(*discharge)) {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Admission and discharge dates must enclose the visit's transfers", "status": "Error"})
+				return
+			}
+		}
+	}
+	if input.AdmissionDate != nil {
+		updates["admission_date"] = admission
+	}
+	if input.DischargeDate != nil {
+		updates["discharge_date"] = *discharge
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": visit.ID, "message": "Visit updated successfully", "status": "Success"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&visit).Updates(updates).Error; err != nil {
+			return err
+		}
+		// Keep the ADT history in line with corrected dates
+		if input.AdmissionDate != nil {
+			if err := tx.Model(&models.AdtEvent{}).
+				Where("visit_id = ? AND event_type = ?", visit.ID, models.AdtEventAdmit).
+				Update("event_time", admission).Error; err != nil {
+				return err
+			}
+		}
+		if input.DischargeDate != nil {
+			if err := tx.Model(&models.AdtEvent{}).
+				Where("visit_id = ? AND event_type = ?", visit.ID, models.AdtEventDischarge).
+				Update("event_time", *discharge).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to update visit", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update visit", "status": "Error"})
+		return
+	}
+
+	logger.Info("Visit updated", "visit_id", visit.ID)
+	c.JSON(http.StatusOK, gin.H{"data": visit.ID, "message": "Visit updated successfully", "status": "Success"})
+}
+
+// CloseVisit closes an OP visit so no further prescriptions can be written
+// against it. IP visits are closed by discharge.
+func CloseVisit(c *gin.Context) {
+	var input schemas.CloseVisitInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Close Visit", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var visit models.Visit
+	if err := initializers.DB.First(&visit, input.VisitID).Error; err != nil {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Visit not found", "status": "Error"})
+		return
+	}
+
+	if visit.VisitType != "OP" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Only OP visits can be closed; IP visits are closed by discharge", "status": "Error"})
+		return
+	}
+
+	result := initializers.DB.Model(&visit).Where("closed_at IS NULL").Update("closed_at", time.Now())
+	if result.Error != nil {
+		logger.Error("Failed to close visit", "visit_id", visit.ID, "error", result.Error.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to close visit", "status": "Error"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Visit is already closed", "status": "Error"})
+		return
+	}
+
+	logger.Info("Visit closed", "visit_id", visit.ID)
+	c.JSON(http.StatusOK, gin.H{"data": visit.ID, "message": "Successfully closed visit", "status": "Success"})
+}
@@ -0,0 +1,341 @@
+package appointmentControllers
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/notifications"
+	"apps90-hms/scheduling"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func AddToWaitlist(c *gin.Context) {
+	var input schemas.WaitlistInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add To Waitlist", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	preferredFrom, err := time.Parse("2006-01-02", input.PreferredFrom)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Invalid preferred_from format"))
+		return
+	}
+	preferredTo, err := time.Parse("2006-01-02", input.PreferredTo)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Invalid preferred_to format"))
+		return
+	}
+	if preferredTo.Before(preferredFrom) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "preferred_to must not be before preferred_from"))
+		return
+	}
+
+	var patient models.Patient
+	initializers.DB.First(&patient, input.PatientID)
+	if patient.ID == 0 {
+		logger.Warn("Patient not found", "patient_id", input.PatientID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Patient not found"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+
+	var entity models.Entity
+	initializers.DB.First(&entity, input.EntityID)
+	if entity.ID == 0 {
+		logger.Warn("Entity not found", "entity_id", input.EntityID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Entity not found"))
+		return
+	}
+	if doctor.EntityID != entity.ID {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Doctor does not work at this entity"))
+		return
+	}
+
+	var existing models.WaitlistEntry
+	initializers.DB.Where("patient_id = ? AND doctor_id = ? AND status IN ?", input.PatientID, input.DoctorID,
+		[]string{models.WaitlistStatusWaiting, models.WaitlistStatusOffered}).Find(&existing)
+	if existing.ID != 0 {
+		logger.Warn("Patient already on waitlist", "patient_id", input.PatientID, "doctor_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrObjectExists, "Patient is already on this doctor's waitlist"))
+		return
+	}
+
+	priority := input.Priority
+	if priority == 0 {
+		priority = 3
+	}
+
+	entry := models.WaitlistEntry{
+		PatientID:     input.PatientID,
+		DoctorID:      input.DoctorID,
+		EntityID:      input.EntityID,
+		PreferredFrom: preferredFrom,
+		PreferredTo:   preferredTo,
+		Priority:      priority,
+		Reason:        input.Reason,
+		Status:        models.WaitlistStatusWaiting,
+	}
+
+	if err := initializers.DB.Create(&entry).Error; err != nil {
+		logger.Error("Failed to add waitlist entry", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to add to waitlist"))
+		return
+	}
+
+	logger.Info("Patient added to waitlist", "waitlist_entry_id", entry.ID, "doctor_id", entry.DoctorID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    entry.ID,
+		"message": "Successfully added to waitlist",
+		"status":  "Success",
+	})
+}
+
+func GetWaitlist(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("Patient").Preload("Offers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC")
+	})
+
+	if doctorID := c.Query("doctor_id"); doctorID != "" {
+		query = query.Where("doctor_id = ?", doctorID)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{models.WaitlistStatusWaiting, models.WaitlistStatusOffered})
+	}
+
+	var entries []models.WaitlistEntry
+	if err := query.Order("priority, created_at").Find(&entries).Error; err != nil {
+		logger.Error("Failed to fetch waitlist", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch waitlist"))
+		return
+	}
+
+	var response []map[string]interface{}
+	for _, entry := range entries {
+		response = append(response, map[string]interface{}{
+			"id":                entry.ID,
+			"patient_id":        entry.PatientID,
+			"patient_firstname": entry.Patient.FirstName,
+			"patient_lastname":  entry.Patient.LastName,
+			"doctor_id":         entry.DoctorID,
+			"preferred_from":    entry.PreferredFrom.Format("2006-01-02"),
+			"preferred_to":      entry.PreferredTo.Format("2006-01-02"),
+			"priority":          entry.Priority,
+			"reason":            entry.Reason,
+			"status":            entry.Status,
+			"offers":            entry.Offers,
+		})
+	}
+	if len(response) == 0 {
+		response = []map[string]interface{}{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    response,
+		"message": "Successfully fetched waitlist",
+		"status":  "Success",
+	})
+}
+
+func RemoveFromWaitlist(c *gin.Context) {
+	var input schemas.WaitlistEntryActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Remove From Waitlist", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var entry models.WaitlistEntry
+		if err := tx.First(&entry, input.WaitlistEntryID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entry).Update("status", models.WaitlistStatusRemoved).Error; err != nil {
+			return err
+		}
+
+		// A slot held for this patient goes to the next in line
+		var offers []models.WaitlistOffer
+		tx.Where("waitlist_entry_id = ? AND status = ?", entry.ID, models.OfferStatusPending).Find(&offers)
+		for _, offer := range offers {
+			if err := scheduling.ReleaseOffer(tx, offer, models.OfferStatusDeclined); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Waitlist entry not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to remove waitlist entry", "waitlist_entry_id", input.WaitlistEntryID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to remove from waitlist"))
+		return
+	}
+
+	logger.Info("Patient removed from waitlist", "waitlist_entry_id", input.WaitlistEntryID)
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully removed from waitlist", "status": "Success"})
+}
+
+// AcceptWaitlistOffer books the offered slot for the waitlisted patient,
+// provided the offer has not expired and the slot is still free.
+func AcceptWaitlistOffer(c *gin.Context) {
+	var input schemas.WaitlistOfferActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Accept Waitlist Offer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var appointment models.Appointment
+	var failure *models.APIError
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var offer models.WaitlistOffer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&offer, input.OfferID).Error; err != nil {
+			return err
+		}
+
+		if offer.Status != models.OfferStatusPending {
+			apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Offer is no longer available")
+			failure = &apiErr
+			return nil
+		}
+		if time.Now().After(offer.ExpiresAt) {
+			apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Offer has expired")
+			failure = &apiErr
+			return scheduling.ReleaseOffer(tx, offer, models.OfferStatusExpired)
+		}
+
+		var entry models.WaitlistEntry
+		if err := tx.First(&entry, offer.WaitlistEntryID).Error; err != nil {
+			return err
+		}
+
+		conflict, err := scheduling.FindConflict(tx, entry.DoctorID, entry.PatientID, offer.SlotTime, offer.DurationMinutes)
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			apiErr := models.WrapError(http.StatusConflict, errors.ErrObjectExists, "Slot has already been taken")
+			failure = &apiErr
+			return scheduling.ReleaseOffer(tx, offer, models.OfferStatusExpired)
+		}
+
+		appointment = models.Appointment{
+			AppointmentTime: offer.SlotTime,
+			Reason:          entry.Reason,
+			PatientID:       entry.PatientID,
+			EmployeeID:      entry.DoctorID,
+			EntityID:        entry.EntityID,
+			DurationMinutes: offer.DurationMinutes,
+			Status:          models.AppointmentStatusScheduled,
+		}
+		if err := tx.Create(&appointment).Error; err != nil {
+			return err
+		}
+		if err := notifications.EnqueueAppointmentReminders(tx, appointment.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&offer).Updates(map[string]interface{}{
+			"status":         models.OfferStatusAccepted,
+			"appointment_id": appointment.ID,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entry).Update("status", models.WaitlistStatusBooked).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Offer not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to accept waitlist offer", "offer_id", input.OfferID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to accept offer"))
+		return
+	}
+	if failure != nil {
+		logger.Warn("Waitlist offer could not be accepted", "offer_id", input.OfferID, "reason", failure.Message)
+		c.Error(*failure)
+		return
+	}
+
+	logger.Info("Waitlist offer accepted", "offer_id", input.OfferID, "appointment_id", appointment.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    appointment.ID,
+		"message": "Successfully booked waitlist offer",
+		"status":  "Success",
+	})
+}
+
+// DeclineWaitlistOffer returns the patient to the waitlist and passes the
+// slot on to the next patient in line.
+func DeclineWaitlistOffer(c *gin.Context) {
+	var input schemas.WaitlistOfferActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Decline Waitlist Offer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	notPending := false
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var offer models.WaitlistOffer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&offer, input.OfferID).Error; err != nil {
+			return err
+		}
+		if offer.Status != models.OfferStatusPending {
+			notPending = true
+			return nil
+		}
+		return scheduling.ReleaseOffer(tx, offer, models.OfferStatusDeclined)
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Offer not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to decline waitlist offer", "offer_id", input.OfferID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to decline offer"))
+		return
+	}
+	if notPending {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Offer is no longer available"))
+		return
+	}
+
+	logger.Info("Waitlist offer declined", "offer_id", input.OfferID)
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully declined waitlist offer", "status": "Success"})
+}
@@ -0,0 +1,220 @@
+package calendarController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// How far back and ahead a doctor's feed reaches
+const (
+	feedPastDays   = 30
+	feedFutureDays = 180
+)
+
+// IssueCalendarToken creates a new feed token for the calling doctor, revoking
+// any previous one so a leaked URL can be rotated out.
+func IssueCalendarToken(c *gin.Context) {
+	var input schemas.CalendarTokenInput
+	logger := loggers.InitializeLogger()
+
+	user, ok := currentUser(c)
+	if !ok {
+		c.Error(models.WrapError(http.StatusUnauthorized, errors.ErrUserNotFound, "User not found"))
+		return
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Issue Calendar Token", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+	if !strings.EqualFold(doctor.Email, user.Email) {
+		logger.Warn("Calendar token requested for another doctor", "user_id", user.ID, "employee_id", doctor.ID)
+		c.Error(models.WrapError(http.StatusForbidden, errors.ErrForbidden, "Calendar tokens can only be issued for yourself"))
+		return
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		logger.Error("Failed to generate calendar token", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrGeneratingToken, "Failed to generate calendar token"))
+		return
+	}
+
+	token := models.CalendarFeedToken{
+		Token:      hex.EncodeToString(raw),
+		EmployeeID: doctor.ID,
+	}
+
+	initializers.DB.Model(&models.CalendarFeedToken{}).
+		Where("employee_id = ? AND is_active = ?", doctor.ID, true).
+		Update("is_active", false)
+
+	if err := initializers.DB.Create(&token).Error; err != nil {
+		logger.Error("Failed to save calendar token", "employee_id", doctor.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to save calendar token"))
+		return
+	}
+
+	logger.Info("Calendar token issued", "employee_id", doctor.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"token":    token.Token,
+			"feed_url": "/calendar/doctor/" + token.Token + ".ics",
+		},
+		"message": "Successfully issued calendar token",
+		"status":  "Success",
+	})
+}
+
+// GetDoctorFeed serves a doctor's appointments as an iCalendar feed. Patients
+// appear by initials only and the location is the entity name.
+func GetDoctorFeed(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	tokenValue := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	var token models.CalendarFeedToken
+	initializers.DB.Preload("Employee").
+		Where("token = ? AND is_active = ?", tokenValue, true).Find(&token)
+	if token.ID == 0 {
+		logger.Warn("Invalid calendar feed token")
+		c.String(http.StatusUnauthorized, "Invalid calendar token")
+		return
+	}
+
+	now := time.Now()
+	var appointments []models.Appointment
+	if err := initializers.DB.Preload("Patient").Preload("Entity").
+		Where("employee_id = ? AND appointment_time BETWEEN ? AND ?", token.EmployeeID,
+			now.AddDate(0, 0, -feedPastDays), now.AddDate(0, 0, feedFutureDays)).
+		Order("appointment_time").Find(&appointments).Error; err != nil {
+		logger.Error("Failed to fetch appointments for calendar feed", "employee_id", token.EmployeeID, "error", err.Error())
+		c.String(http.StatusInternalServerError, "Failed to build calendar")
+		return
+	}
+
+	doctorName := "Dr. " + token.Employee.FirstName + " " + token.Employee.LastName
+
+	w := &icsWriter{}
+	beginCalendar(w, doctorName+" appointments")
+	for _, appointment := range appointments {
+		writeEvent(w, appointment,
+			"Appointment: "+initials(appointment.Patient.FirstName, appointment.Patient.LastName), "")
+	}
+	w.line("END", "VCALENDAR")
+
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(w.String()))
+}
+
+// DownloadAppointment returns a single appointment as an .ics file for the
+// patient. The caller must belong to the appointment's entity.
+func DownloadAppointment(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	appointmentID := c.Query("appointment_id")
+
+	user, ok := currentUser(c)
+	if !ok {
+		c.Error(models.WrapError(http.StatusUnauthorized, errors.ErrUserNotFound, "User not found"))
+		return
+	}
+
+	if appointmentID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "appointment_id is required"))
+		return
+	}
+
+	var appointment models.Appointment
+	if err := initializers.DB.Preload("Employee").Preload("Entity").
+		First(&appointment, appointmentID).Error; err != nil {
+		logger.Warn("Appointment not found", "appointment_id", appointmentID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Appointment not found"))
+		return
+	}
+
+	var linked int64
+	initializers.DB.Table("user_entity").
+		Where("user_id = ? AND entity_id = ?", user.ID, appointment.EntityID).Count(&linked)
+	if linked == 0 {
+		logger.Warn("Appointment download outside user's entities", "user_id", user.ID, "appointment_id", appointment.ID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Appointment not found"))
+		return
+	}
+
+	w := &icsWriter{}
+	beginCalendar(w, "Appointment")
+	writeEvent(w, appointment,
+		"Appointment with Dr. "+appointment.Employee.FirstName+" "+appointment.Employee.LastName,
+		appointment.Reason)
+	w.line("END", "VCALENDAR")
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=appointment-%d.ics", appointment.ID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(w.String()))
+}
+
+// currentUser returns the user set by the auth middleware
+func currentUser(c *gin.Context) (models.User, bool) {
+	value, ok := c.Get("currentUser")
+	if !ok {
+		return models.User{}, false
+	}
+	user, ok := value.(models.User)
+	return user, ok
+}
+
+func beginCalendar(w *icsWriter, name string) {
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//apps90//HMS//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", name)
+}
+
+func writeEvent(w *icsWriter, appointment models.Appointment, summary, description string) {
+	duration := appointment.DurationMinutes
+	if duration <= 0 {
+		duration = 15
+	}
+	start := appointment.AppointmentTime
+	stamp := appointment.UpdatedAt
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", fmt.Sprintf("appointment-%d@apps90-hms", appointment.ID))
+	w.timestamp("DTSTAMP", stamp)
+	w.timestamp("DTSTART", start)
+	w.timestamp("DTEND", start.Add(time.Duration(duration)*time.Minute))
+	w.text("SUMMARY", summary)
+	w.text("LOCATION", appointment.Entity.Name)
+	if description != "" {
+		w.text("DESCRIPTION", description)
+	}
+	if appointment.Status == models.AppointmentStatusCancelled {
+		w.line("STATUS", "CANCELLED")
+	} else {
+		w.line("STATUS", "CONFIRMED")
+	}
+	w.line("END", "VEVENT")
+}
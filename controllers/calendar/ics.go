@@ -0,0 +1,64 @@
+package calendarController
+
+import (
+	"strings"
+	"time"
+)
+
+// icsWriter builds an RFC 5545 iCalendar document with CRLF line endings and
+// lines folded at 75 octets.
+type icsWriter struct {
+	b strings.Builder
+}
+
+func (w *icsWriter) line(name, value string) {
+	content := name + ":" + value
+	limit := 75
+	for len(content) > limit {
+		// Avoid splitting a multi-byte UTF-8 sequence
+		cut := limit
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.b.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = 74
+	}
+	w.b.WriteString(content + "\r\n")
+}
+
+func (w *icsWriter) text(name, value string) {
+	w.line(name, escapeText(value))
+}
+
+func (w *icsWriter) timestamp(name string, t time.Time) {
+	w.line(name, t.UTC().Format("20060102T150405Z"))
+}
+
+func (w *icsWriter) String() string {
+	return w.b.String()
+}
+
+// escapeText escapes TEXT property values as required by RFC 5545 section 3.3.11
+func escapeText(value string) string {
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	)
+	return replacer.Replace(value)
+}
+
+// initials reduces a name to initials, e.g. "John Doe" to "J.D."
+func initials(names ...string) string {
+	var out strings.Builder
+	for _, name := range names {
+		for _, part := range strings.Fields(name) {
+			out.WriteString(strings.ToUpper(string([]rune(part)[:1])) + ".")
+		}
+	}
+	return out.String()
+}
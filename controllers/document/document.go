@@ -0,0 +1,292 @@
+package documentController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"apps90-hms/storage"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+// Content types accepted for patient documents, checked against the sniffed
+// content rather than the client's claim
+var allowedTypes = []string{
+	"application/pdf",
+	"image/jpeg",
+	"image/png",
+	"image/tiff",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// maxDocumentBytes is DOCUMENT_MAX_MB megabytes, 20 by default
+func maxDocumentBytes() int64 {
+	if mb, err := strconv.Atoi(os.Getenv("DOCUMENT_MAX_MB")); err == nil && mb > 0 {
+		return int64(mb) << 20
+	}
+	return 20 << 20
+}
+
+// UploadDocument stores a file on a patient's record, optionally tied to one
+// of their visits. The file goes in the multipart field "file" next to the
+// fields of DocumentUploadInput.
+func UploadDocument(c *gin.Context) {
+	var input schemas.DocumentUploadInput
+	logger := loggers.InitializeLogger()
+
+	// Refuse oversized bodies while parsing rather than after buffering them;
+	// the extra megabyte covers the form fields and part headers
+	limit := maxDocumentBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+
+	if err := c.ShouldBind(&input); err != nil {
+		if errors.IsBodyTooLarge(err) {
+			c.Error(models.WrapError(http.StatusRequestEntityTooLarge, errors.ErrBadRequest, fmt.Sprintf("File exceeds the %d MB limit", limit>>20)))
+			return
+		}
+		logger.Error("Error binding form for Upload Document", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "file is required"))
+		return
+	}
+	if header.Size == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "File is empty"))
+		return
+	}
+	if header.Size > limit {
+		c.Error(models.WrapError(http.StatusRequestEntityTooLarge, errors.ErrBadRequest, fmt.Sprintf("File exceeds the %d MB limit", limit>>20)))
+		return
+	}
+
+	var patient models.Patient
+	initializers.DB.First(&patient, input.PatientID)
+	if patient.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Patient not found"))
+		return
+	}
+	if input.VisitID != nil {
+		var visit models.Visit
+		initializers.DB.First(&visit, *input.VisitID)
+		if visit.ID == 0 || visit.PatientID != patient.ID {
+			c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Visit not found for this patient"))
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.Error("Failed to open uploaded file", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Could not read uploaded file"))
+		return
+	}
+	defer file.Close()
+
+	detected, err := mimetype.DetectReader(file)
+	if err != nil {
+		logger.Error("Failed to sniff uploaded file", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Could not read uploaded file"))
+		return
+	}
+	contentType := ""
+	for _, allowed := range allowedTypes {
+		if detected.Is(allowed) {
+			contentType = allowed
+			break
+		}
+	}
+	if contentType == "" {
+		c.Error(models.WrapError(http.StatusUnsupportedMediaType, errors.ErrBadRequest, "Unsupported file type "+detected.String()+"; upload PDF, JPEG, PNG, TIFF or DOCX"))
+		return
+	}
+
+	hash := sha256.New()
+	if _, err := file.Seek(0, io.SeekStart); err == nil {
+		_, err = io.Copy(hash, file)
+	}
+	if err != nil {
+		logger.Error("Failed to checksum uploaded file", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.InternalServerError, "Could not read uploaded file"))
+		return
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	var existing models.Document
+	initializers.DB.Where("patient_id = ? AND checksum = ? AND is_active = ?", patient.ID, checksum, true).Find(&existing)
+	if existing.ID != 0 {
+		c.Error(models.WrapError(http.StatusConflict, errors.ErrObjectExists, fmt.Sprintf("This file is already on the patient's record as document %d", existing.ID)))
+		return
+	}
+
+	backend, err := storage.FromEnv()
+	if err != nil {
+		logger.Error("Document storage is misconfigured", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.InternalServerError, "Document storage is not available"))
+		return
+	}
+
+	suffix := make([]byte, 16)
+	rand.Read(suffix)
+	key := storage.Key("documents", strconv.FormatUint(uint64(patient.ID), 10), hex.EncodeToString(suffix))
+
+	if _, err := file.Seek(0, io.SeekStart); err == nil {
+		err = backend.Put(c.Request.Context(), key, file, header.Size, contentType)
+	}
+	if err != nil {
+		logger.Error("Failed to store document", "patient_id", patient.ID, "backend", backend.Name(), "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.InternalServerError, "Failed to store file"))
+		return
+	}
+
+	title := input.Title
+	if title == "" {
+		title = header.Filename
+	}
+	document := models.Document{
+		PatientID:      patient.ID,
+		VisitID:        input.VisitID,
+		Category:       input.Category,
+		Title:          title,
+		FileName:       filepath.Base(header.Filename),
+		ContentType:    contentType,
+		SizeBytes:      header.Size,
+		Checksum:       checksum,
+		StorageBackend: backend.Name(),
+		StorageKey:     key,
+		UploadedByID:   input.UploadedByID,
+	}
+	if err := initializers.DB.Create(&document).Error; err != nil {
+		backend.Delete(c.Request.Context(), key)
+		logger.Error("Failed to save document", "patient_id", patient.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to save document"))
+		return
+	}
+
+	logger.Info("Document uploaded", "document_id", document.ID, "patient_id", patient.ID, "category", document.Category, "size_bytes", document.SizeBytes)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    document,
+		"message": "Successfully uploaded document",
+		"status":  "Success",
+	})
+}
+
+// GetDocuments lists the documents of a patient (patient_id) or a visit
+// (visit_id), newest first, optionally narrowed to a category
+func GetDocuments(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("UploadedBy").Where("is_active = ?", true)
+	switch {
+	case c.Query("visit_id") != "":
+		query = query.Where("visit_id = ?", c.Query("visit_id"))
+	case c.Query("patient_id") != "":
+		query = query.Where("patient_id = ?", c.Query("patient_id"))
+	default:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "patient_id or visit_id is required"))
+		return
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+
+	var documents []models.Document
+	if err := query.Order("created_at DESC").Find(&documents).Error; err != nil {
+		logger.Error("Failed to fetch documents", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch documents"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    documents,
+		"message": "Successfully fetched documents",
+		"status":  "Success",
+	})
+}
+
+// DownloadDocument streams a document back. Pass inline=true to let the
+// browser display it instead of saving it. The ETag carries the SHA-256
+// checksum so clients can verify what they received.
+func DownloadDocument(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	documentID := c.Query("document_id")
+
+	if documentID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "document_id is required"))
+		return
+	}
+
+	var document models.Document
+	initializers.DB.Where("is_active = ?", true).First(&document, documentID)
+	if document.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Document not found"))
+		return
+	}
+
+	content, err := storage.Open(c.Request.Context(), document.StorageBackend, document.StorageKey)
+	if err == storage.ErrNotFound {
+		logger.Error("Document file is missing from storage", "document_id", document.ID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Document file not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to open document file", "document_id", document.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.InternalServerError, "Failed to read document"))
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if c.Query("inline") == "true" {
+		disposition = "inline"
+	}
+
+	logger.Info("Document downloaded", "document_id", document.ID, "patient_id", document.PatientID)
+	c.DataFromReader(http.StatusOK, document.SizeBytes, document.ContentType, content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("%s; filename=%q", disposition, document.FileName),
+		"ETag":                `"` + document.Checksum + `"`,
+	})
+}
+
+// DeleteDocument removes a document from the patient's record. The stored file
+// is kept so the record can be audited.
+func DeleteDocument(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	documentID := c.Query("document_id")
+
+	if documentID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "document_id is required"))
+		return
+	}
+
+	result := initializers.DB.Model(&models.Document{}).
+		Where("id = ? AND is_active = ?", documentID, true).
+		Update("is_active", false)
+	if result.Error != nil {
+		logger.Error("Failed to delete document", "document_id", documentID, "error", result.Error.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to delete document"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Document not found"))
+		return
+	}
+
+	logger.Info("Document deleted", "document_id", documentID)
+	c.JSON(http.StatusOK, gin.H{"data": documentID, "message": "Successfully deleted document", "status": "Success"})
+}
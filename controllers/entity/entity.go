@@ -8,6 +8,7 @@ import (
 	"apps90-hms/schemas"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -236,43 +237,90 @@ func GetPatientList(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": patientList})
 }
 
-// GetMedicines retrieves medicines for a specific entity and category
+// GetMedicines lists the active medicines of an entity (entity_id) grouped by
+// category, with usable stock and prices. Filters: category_id, q (brand or
+// generic name) and in_stock=true. Medicines are paged in category and name
+// order with page (from 1) and page_size (50 by default, at most 200).
 func GetMedicines(c *gin.Context) {
 	logger := loggers.InitializeLogger()
 
-	// Fetch all medicine categories
-	var categories []models.MedicineCategory
-	if err := initializers.DB.Preload("Medicines").Find(&categories).Error; err != nil {
-		logger.Error("Failed to fetch medicine categories", "error", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"data": nil, "message": "Failed to fetch medicine categories", "status": "Error"})
+	entityID, err := strconv.ParseUint(c.Query("entity_id"), 10, 64)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
 		return
 	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "50"))
+	if err != nil || pageSize < 1 || pageSize > 200 {
+		pageSize = 50
+	}
 
-	// Prepare response structure
-	var categoryList []schemas.MedicineCategoryResponse
+	// Expired batches stay in stock until written off but cannot be dispensed
+	expired := initializers.DB.Model(&models.MedicineBatch{}).
+		Select("medicine_id, SUM(quantity) AS quantity").
+		Where("entity_id = ? AND quantity > 0 AND expiry_date < ?", entityID, time.Now().Format("2006-01-02")).
+		Group("medicine_id")
+	usable := "COALESCE(stock_level.quantity, 0) - COALESCE(expired.quantity, 0)"
+
+	query := initializers.DB.Table("medicine").
+		Joins("JOIN medicine_category ON medicine_category.id = medicine.category_id").
+		Joins("LEFT JOIN stock_level ON stock_level.medicine_id = medicine.id AND stock_level.entity_id = ?", entityID).
+		Joins("LEFT JOIN (?) AS expired ON expired.medicine_id = medicine.id", expired).
+		Where("medicine.entity_id = ? AND medicine.is_active = ? AND medicine_category.is_active = ?", entityID, true, true)
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("medicine.category_id = ?", categoryID)
+	}
+	if term := strings.TrimSpace(c.Query("q")); term != "" {
+		pattern := "%" + escapeLike(term) + "%"
+		query = query.Where("medicine.name ILIKE ? OR medicine.generic_name ILIKE ?", pattern, pattern)
+	}
+	if c.Query("in_stock") == "true" {
+		query = query.Where(usable + " > 0")
+	}
 
-	for _, category := range categories {
-		var medicinesList []schemas.MedicineResponse
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Error("Failed to count medicines", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch medicines"))
+		return
+	}
 
-		// Populate medicines under each category
-		for _, medicine := range category.Medicines {
-			medicinesList = append(medicinesList, schemas.MedicineResponse{
-				ID:   medicine.ID,
-				Name: medicine.Name,
-			})
-		}
+	var rows []struct {
+		models.Medicine
+		CategoryName string
+		Stock        int
+	}
+	if err := query.Select("medicine.*, medicine_category.name AS category_name, " + usable + " AS stock").
+		Order("medicine_category.name, medicine.name").
+		Offset((page - 1) * pageSize).Limit(pageSize).Scan(&rows).Error; err != nil {
+		logger.Error("Failed to fetch medicines", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch medicines"))
+		return
+	}
 
-		// Add category with its medicines
-		categoryList = append(categoryList, schemas.MedicineCategoryResponse{
-			ID:        category.ID,
-			Name:      category.Name,
-			Medicines: medicinesList,
-		})
+	// Group the page by category, keeping the order
+	categoryList := []schemas.MedicineCategoryResponse{}
+	for _, row := range rows {
+		if len(categoryList) == 0 || categoryList[len(categoryList)-1].ID != row.CategoryID {
+			categoryList = append(categoryList, schemas.MedicineCategoryResponse{ID: row.CategoryID, Name: row.CategoryName})
+		}
+		medicine := medicineResponse(row.Medicine)
+		stock := row.Stock
+		medicine.Stock = &stock
+		last := &categoryList[len(categoryList)-1]
+		last.Medicines = append(last.Medicines, medicine)
 	}
 
-	// Send response
 	c.JSON(http.StatusOK, gin.H{
-		"data":    categoryList,
+		"data": categoryList,
+		"pagination": gin.H{
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
+		},
 		"message": "Successfully fetched medicine categories and medicines",
 		"status":  "Success",
 	})
@@ -329,9 +377,9 @@ func AddMedicine(c *gin.Context) {
 		return
 	}
 
-	// Check if the medicine already exists for the given entity and category
+	// Check if the medicine already exists for the given entity
 	var existingMedicine models.Medicine
-	if err := initializers.DB.Where("name = ? AND entity_id = ? AND category_id = ?", input.Name, input.EntityID, input.CategoryID).
+	if err := initializers.DB.Where("name = ? AND entity_id = ?", input.Name, input.EntityID).
 		First(&existingMedicine).Error; err == nil {
 
 		logger.Info("Medicine already exists, returning existing ID", "medicine_id", existingMedicine.ID)
@@ -343,11 +391,35 @@ func AddMedicine(c *gin.Context) {
 		return
 	}
 
+	// The category must belong to the same entity as the medicine
+	var category models.MedicineCategory
+	initializers.DB.Where("entity_id = ?", input.EntityID).First(&category, input.CategoryID)
+	if category.ID == 0 {
+		logger.Warn("Medicine category not found", "category_id", input.CategoryID, "entity_id", input.EntityID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Medicine category not found", "status": "Error"})
+		return
+	}
+
+	if input.MRP > 0 && input.SellingPrice > input.MRP {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Selling price cannot exceed MRP", "status": "Error"})
+		return
+	}
+
 	// Create new medicine
 	medicine := models.Medicine{
-		Name:       input.Name,
-		EntityID:   input.EntityID,
-		CategoryID: input.CategoryID,
+		Name:         input.Name,
+		GenericName:  input.GenericName,
+		Strength:     input.Strength,
+		DosageForm:   input.DosageForm,
+		Manufacturer: input.Manufacturer,
+		EntityID:     input.EntityID,
+		CategoryID:   input.CategoryID,
+		Description:  input.Description,
+		HSNCode:      input.HSNCode,
+		TaxRate:      input.TaxRate,
+		PackSize:     input.PackSize,
+		MRP:          input.MRP,
+		SellingPrice: input.SellingPrice,
 	}
 
 	// Save to DB
@@ -0,0 +1,197 @@
+package entityController
+
+import (
+	"apps90-hms/catalog"
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Files with up to this many rows are imported before responding; larger
+// ones run in the background and are followed through the job
+const inlineImportRows = 500
+
+// maxImportBytes is IMPORT_MAX_MB megabytes, 20 by default
+func maxImportBytes() int64 {
+	if mb, err := strconv.Atoi(os.Getenv("IMPORT_MAX_MB")); err == nil && mb > 0 {
+		return int64(mb) << 20
+	}
+	return 20 << 20
+}
+
+// ImportMedicines loads a CSV or XLSX catalog (multipart field "file") into
+// an entity's medicines. With dry_run=true it only reports what would be
+// created, updated or rejected.
+func ImportMedicines(c *gin.Context) {
+	var input schemas.MedicineImportInput
+	logger := loggers.InitializeLogger()
+
+	// Stop reading past the file limit plus a megabyte for the other fields
+	limit := maxImportBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+
+	if err := c.ShouldBind(&input); err != nil {
+		if errors.IsBodyTooLarge(err) {
+			c.Error(models.WrapError(http.StatusRequestEntityTooLarge, errors.ErrBadRequest, fmt.Sprintf("File exceeds the %d MB limit", limit>>20)))
+			return
+		}
+		logger.Error("Error binding form for Import Medicines", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	mapping := map[string]string{}
+	if input.Mapping != "" {
+		if err := json.Unmarshal([]byte(input.Mapping), &mapping); err != nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "mapping must be a JSON object of field to column header"))
+			return
+		}
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "file is required"))
+		return
+	}
+	if header.Size > limit {
+		c.Error(models.WrapError(http.StatusRequestEntityTooLarge, errors.ErrBadRequest, fmt.Sprintf("File exceeds the %d MB limit", limit>>20)))
+		return
+	}
+	format, err := catalog.Format(header.Filename)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusUnsupportedMediaType, errors.ErrBadRequest, err.Error()))
+		return
+	}
+
+	var entity models.Entity
+	initializers.DB.First(&entity, input.EntityID)
+	if entity.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Entity not found"))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.Error("Failed to open uploaded file", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Could not read uploaded file"))
+		return
+	}
+	defer file.Close()
+
+	rows, err := catalog.ReadSheet(file, header.Size, format)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Could not read file: "+err.Error()))
+		return
+	}
+	prepared, err := catalog.PrepareMedicineImport(rows, mapping)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, err.Error()))
+		return
+	}
+
+	job := models.ImportJob{
+		EntityID:    entity.ID,
+		Kind:        models.ImportKindMedicine,
+		FileName:    filepath.Base(header.Filename),
+		Format:      format,
+		DryRun:      input.DryRun,
+		Mapping:     prepared.Mapping(),
+		Status:      models.ImportJobQueued,
+		TotalRows:   prepared.Rows(),
+		StartedByID: input.StartedByID,
+	}
+	if err := initializers.DB.Create(&job).Error; err != nil {
+		logger.Error("Failed to create import job", "entity_id", entity.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to start import"))
+		return
+	}
+	logger.Info("Medicine import started", "import_job_id", job.ID, "entity_id", entity.ID, "rows", job.TotalRows, "dry_run", job.DryRun)
+
+	if prepared.Rows() > inlineImportRows {
+		go func(job models.ImportJob) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("Medicine import panicked", "import_job_id", job.ID, "panic", fmt.Sprint(r))
+					finished := time.Now()
+					initializers.DB.Model(&job).Updates(map[string]interface{}{
+						"status": models.ImportJobFailed, "finished_at": finished, "message": "Import stopped by an internal error",
+					})
+				}
+			}()
+			if err := prepared.Run(initializers.DB, &job); err != nil {
+				logger.Error("Medicine import failed", "import_job_id", job.ID, "error", err.Error())
+				return
+			}
+			logger.Info("Medicine import finished", "import_job_id", job.ID, "created", job.CreatedCount, "updated", job.UpdatedCount, "errors", job.ErrorCount)
+		}(job)
+		c.JSON(http.StatusAccepted, gin.H{
+			"data":    job,
+			"message": "Import queued; follow its progress through the import job",
+			"status":  "Success",
+		})
+		return
+	}
+
+	if err := prepared.Run(initializers.DB, &job); err != nil {
+		logger.Error("Medicine import failed", "import_job_id", job.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, fmt.Sprintf("Import job %d failed", job.ID)))
+		return
+	}
+	initializers.DB.Where("import_job_id = ?", job.ID).Order("row_number").Find(&job.Issues)
+
+	logger.Info("Medicine import finished", "import_job_id", job.ID, "created", job.CreatedCount, "updated", job.UpdatedCount, "errors", job.ErrorCount)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    job,
+		"message": "Successfully imported medicines",
+		"status":  "Success",
+	})
+}
+
+// GetImportJobs returns one import job (job_id) with the issues it found, up
+// to 1000, or lists the jobs of an entity (entity_id) without them
+func GetImportJobs(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	if jobID := c.Query("job_id"); jobID != "" {
+		var job models.ImportJob
+		err := initializers.DB.Preload("Issues", func(db *gorm.DB) *gorm.DB {
+			return db.Order("row_number").Limit(1000)
+		}).First(&job, jobID).Error
+		if err == gorm.ErrRecordNotFound {
+			c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Import job not found"))
+			return
+		}
+		if err != nil {
+			logger.Error("Failed to fetch import job", "import_job_id", jobID, "error", err.Error())
+			c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch import job"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": job, "message": "Successfully fetched import job", "status": "Success"})
+		return
+	}
+
+	entityID := c.Query("entity_id")
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "job_id or entity_id is required"))
+		return
+	}
+	var jobs []models.ImportJob
+	if err := initializers.DB.Where("entity_id = ?", entityID).Order("created_at DESC").Limit(100).Find(&jobs).Error; err != nil {
+		logger.Error("Failed to fetch import jobs", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch import jobs"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": jobs, "message": "Successfully fetched import jobs", "status": "Success"})
+}
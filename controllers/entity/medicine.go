@@ -0,0 +1,226 @@
+package entityController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateMedicine edits a catalog medicine or deactivates it
+func UpdateMedicine(c *gin.Context) {
+	var input schemas.UpdateMedicineInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Medicine", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var medicine models.Medicine
+	initializers.DB.First(&medicine, input.MedicineID)
+	if medicine.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine not found"))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.Name != nil {
+		var existing models.Medicine
+		initializers.DB.Where("entity_id = ? AND name = ? AND id <> ?", medicine.EntityID, *input.Name, medicine.ID).Find(&existing)
+		if existing.ID != 0 {
+			c.Error(models.WrapError(http.StatusConflict, errors.ErrObjectExists, "Another medicine has this name"))
+			return
+		}
+		updates["name"] = *input.Name
+	}
+	if input.CategoryID != nil {
+		var category models.MedicineCategory
+		initializers.DB.Where("entity_id = ?", medicine.EntityID).First(&category, *input.CategoryID)
+		if category.ID == 0 {
+			c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine category not found"))
+			return
+		}
+		updates["category_id"] = *input.CategoryID
+	}
+	if input.GenericName != nil {
+		updates["generic_name"] = *input.GenericName
+	}
+	if input.Strength != nil {
+		updates["strength"] = *input.Strength
+	}
+	if input.DosageForm != nil {
+		updates["dosage_form"] = *input.DosageForm
+	}
+	if input.Manufacturer != nil {
+		updates["manufacturer"] = *input.Manufacturer
+	}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+	if input.HSNCode != nil {
+		updates["hsn_code"] = *input.HSNCode
+	}
+	if input.TaxRate != nil {
+		updates["tax_rate"] = *input.TaxRate
+	}
+	if input.PackSize != nil {
+		updates["pack_size"] = *input.PackSize
+	}
+	if input.MRP != nil {
+		updates["mrp"] = *input.MRP
+		medicine.MRP = *input.MRP
+	}
+	if input.SellingPrice != nil {
+		updates["selling_price"] = *input.SellingPrice
+		medicine.SellingPrice = *input.SellingPrice
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+	}
+	if len(updates) == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Nothing to update"))
+		return
+	}
+	if medicine.MRP > 0 && medicine.SellingPrice > medicine.MRP {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Selling price cannot exceed MRP"))
+		return
+	}
+
+	if err := initializers.DB.Model(&medicine).Updates(updates).Error; err != nil {
+		logger.Error("Failed to update medicine", "medicine_id", medicine.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update medicine"))
+		return
+	}
+
+	logger.Info("Medicine updated", "medicine_id", medicine.ID)
+	c.JSON(http.StatusOK, gin.H{"data": medicine.ID, "message": "Medicine updated successfully", "status": "Success"})
+}
+
+// UpdateMedicineCategory renames, describes or deactivates a medicine
+// category
+func UpdateMedicineCategory(c *gin.Context) {
+	var input schemas.UpdateMedicineCategoryInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Medicine Category", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var category models.MedicineCategory
+	initializers.DB.First(&category, input.CategoryID)
+	if category.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine category not found"))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.Name != nil {
+		var existing models.MedicineCategory
+		initializers.DB.Where("entity_id = ? AND name = ? AND id <> ?", category.EntityID, *input.Name, category.ID).Find(&existing)
+		if existing.ID != 0 {
+			c.Error(models.WrapError(http.StatusConflict, errors.ErrObjectExists, "Another category has this name"))
+			return
+		}
+		updates["name"] = *input.Name
+	}
+	if input.Description != nil {
+		updates["description"] = *input.Description
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+	}
+	if len(updates) == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Nothing to update"))
+		return
+	}
+
+	if err := initializers.DB.Model(&category).Updates(updates).Error; err != nil {
+		logger.Error("Failed to update medicine category", "category_id", category.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update medicine category"))
+		return
+	}
+
+	logger.Info("Medicine category updated", "category_id", category.ID)
+	c.JSON(http.StatusOK, gin.H{"data": category.ID, "message": "Medicine category updated successfully", "status": "Success"})
+}
+
+// SearchMedicines autocompletes active medicines by brand or generic name
+// (q, at least two characters) for prescribing. Names starting with q rank
+// first, then generics starting with q, then other matches. Narrowed by
+// entity_id and dosage_form; limit defaults to 20.
+func SearchMedicines(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	term := strings.TrimSpace(c.Query("q"))
+	if len([]rune(term)) < 2 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "q must be at least two characters"))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	escaped := escapeLike(term)
+	prefix, anywhere := escaped+"%", "%"+escaped+"%"
+
+	query := initializers.DB.Where("is_active = ?", true).
+		Where("name ILIKE ? OR generic_name ILIKE ?", anywhere, anywhere)
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if form := c.Query("dosage_form"); form != "" {
+		query = query.Where("dosage_form = ?", form)
+	}
+
+	var medicines []models.Medicine
+	if err := query.
+		Order(clause.Expr{SQL: "CASE WHEN name ILIKE ? THEN 0 WHEN generic_name ILIKE ? THEN 1 ELSE 2 END", Vars: []interface{}{prefix, prefix}}).
+		Order("name").Limit(limit).Find(&medicines).Error; err != nil {
+		logger.Error("Failed to search medicines", "q", term, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to search medicines"))
+		return
+	}
+
+	results := []schemas.MedicineResponse{}
+	for _, medicine := range medicines {
+		results = append(results, medicineResponse(medicine))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    results,
+		"message": "Successfully searched medicines",
+		"status":  "Success",
+	})
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so user input matches
+// literally
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+func medicineResponse(medicine models.Medicine) schemas.MedicineResponse {
+	return schemas.MedicineResponse{
+		ID:           medicine.ID,
+		Name:         medicine.Name,
+		GenericName:  medicine.GenericName,
+		Strength:     medicine.Strength,
+		DosageForm:   medicine.DosageForm,
+		Manufacturer: medicine.Manufacturer,
+		PackSize:     medicine.PackSize,
+		MRP:          medicine.MRP,
+		SellingPrice: medicine.SellingPrice,
+	}
+}
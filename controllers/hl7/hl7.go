@@ -0,0 +1,110 @@
+package hl7Controller
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/hl7"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetHl7Messages lists the HL7 message log, newest first. Filters: direction,
+// status, lab_order_id and limit (default 100).
+func GetHl7Messages(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	query := initializers.DB.Model(&models.Hl7Message{})
+	for _, filter := range []string{"direction", "status", "lab_order_id"} {
+		if value := c.Query(filter); value != "" {
+			query = query.Where(filter+" = ?", value)
+		}
+	}
+
+	var messages []models.Hl7Message
+	if err := query.Order("id DESC").Limit(limit).Find(&messages).Error; err != nil {
+		logger.Error("Failed to fetch HL7 messages", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch HL7 messages"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    messages,
+		"message": "Successfully fetched HL7 messages",
+		"status":  "Success",
+	})
+}
+
+// ReplayHl7Message re-processes a logged inbound message or resends a logged
+// outbound one
+func ReplayHl7Message(c *gin.Context) {
+	var input schemas.Hl7ReplayInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Replay HL7 Message", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	entry, err := hl7.Replay(input.MessageID, hl7.NewProcessorFromEnv())
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "HL7 message not found"))
+		return
+	}
+	if err != nil {
+		logger.Warn("HL7 replay failed", "hl7_message_id", input.MessageID, "error", err.Error())
+		c.JSON(http.StatusBadGateway, gin.H{"data": entry, "message": "Replay failed: " + err.Error(), "status": "Error"})
+		return
+	}
+
+	logger.Info("HL7 message replayed", "hl7_message_id", input.MessageID, "replay_id", entry.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    entry,
+		"message": "Successfully replayed HL7 message",
+		"status":  "Success",
+	})
+}
+
+// SendLabOrder sends a lab order to the LIS as ORM^O01
+func SendLabOrder(c *gin.Context) {
+	var input schemas.Hl7SendOrderInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Send Lab Order", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	entry, err := hl7.SendLabOrder(input.OrderID)
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Lab order not found"))
+		return
+	}
+	if err == hl7.ErrNoLIS {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "No LIS is configured"))
+		return
+	}
+	if err != nil {
+		logger.Warn("Failed to send lab order", "lab_order_id", input.OrderID, "error", err.Error())
+		c.JSON(http.StatusBadGateway, gin.H{"data": entry, "message": "Failed to send lab order: " + err.Error(), "status": "Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    entry,
+		"message": "Successfully sent lab order",
+		"status":  "Success",
+	})
+}
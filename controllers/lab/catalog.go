@@ -0,0 +1,179 @@
+package labController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddLabTest adds a test or panel to an entity's lab catalog
+func AddLabTest(c *gin.Context) {
+	var input schemas.LabTestInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add Lab Test", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var entity models.Entity
+	initializers.DB.First(&entity, input.EntityID)
+	if entity.ID == 0 {
+		logger.Warn("Entity not found", "entity_id", input.EntityID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Entity not found"))
+		return
+	}
+
+	var existing models.LabTest
+	initializers.DB.Where("entity_id = ? AND code = ?", entity.ID, input.Code).Find(&existing)
+	if existing.ID != 0 {
+		c.Error(models.WrapError(http.StatusConflict, errors.ErrObjectExists, "A lab test with this code already exists"))
+		return
+	}
+
+	// Panels are built from single tests of the same catalog
+	var components []models.LabTest
+	if len(input.ComponentIDs) > 0 {
+		initializers.DB.Where("id IN ? AND entity_id = ? AND is_panel = ?", input.ComponentIDs, entity.ID, false).Find(&components)
+		if len(components) != len(uniqueIDs(input.ComponentIDs)) {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Panel components must be single tests from the same catalog"))
+			return
+		}
+	}
+
+	test := models.LabTest{
+		EntityID:        entity.ID,
+		Code:            input.Code,
+		Name:            input.Name,
+		SpecimenType:    input.SpecimenType,
+		Price:           input.Price,
+		TurnaroundHours: input.TurnaroundHours,
+		IsPanel:         len(components) > 0,
+		Components:      components,
+	}
+
+	if err := initializers.DB.Create(&test).Error; err != nil {
+		logger.Error("Failed to create lab test", "entity_id", entity.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create lab test"))
+		return
+	}
+
+	logger.Info("Lab test created", "lab_test_id", test.ID, "entity_id", entity.ID, "is_panel", test.IsPanel)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    test.ID,
+		"message": "Successfully created lab test",
+		"status":  "Success",
+	})
+}
+
+// GetLabTests lists an entity's lab catalog with panel components
+func GetLabTests(c *gin.Context) {
+	entityID := c.Query("entity_id")
+	logger := loggers.InitializeLogger()
+
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	var tests []models.LabTest
+	if err := initializers.DB.Preload("Components").
+		Where("entity_id = ? AND is_active = ?", entityID, true).
+		Order("code").Find(&tests).Error; err != nil {
+		logger.Error("Failed to fetch lab tests", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch lab tests"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    tests,
+		"message": "Successfully fetched lab tests",
+		"status":  "Success",
+	})
+}
+
+// AddLabAnalyte defines an analyte of a single test with its reference ranges
+func AddLabAnalyte(c *gin.Context) {
+	var input schemas.LabAnalyteInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add Lab Analyte", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var test models.LabTest
+	initializers.DB.First(&test, input.LabTestID)
+	if test.ID == 0 {
+		logger.Warn("Lab test not found", "lab_test_id", input.LabTestID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Lab test not found"))
+		return
+	}
+	if test.IsPanel {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Analytes belong to the panel's component tests, not the panel"))
+		return
+	}
+
+	analyte := models.LabAnalyte{
+		LabTestID: test.ID,
+		Code:      input.Code,
+		Name:      input.Name,
+		Unit:      input.Unit,
+		SortOrder: input.SortOrder,
+	}
+	for _, r := range input.Ranges {
+		if r.AgeMaxDays != nil && *r.AgeMaxDays <= r.AgeMinDays {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "age_max_days must be greater than age_min_days"))
+			return
+		}
+		if r.Low != nil && r.High != nil && *r.Low > *r.High {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Reference range low is above high"))
+			return
+		}
+		sex := r.Sex
+		if sex == "" {
+			sex = "Any"
+		}
+		analyte.Ranges = append(analyte.Ranges, models.LabReferenceRange{
+			Sex:          sex,
+			AgeMinDays:   r.AgeMinDays,
+			AgeMaxDays:   r.AgeMaxDays,
+			Low:          r.Low,
+			High:         r.High,
+			CriticalLow:  r.CriticalLow,
+			CriticalHigh: r.CriticalHigh,
+		})
+	}
+
+	if err := initializers.DB.Create(&analyte).Error; err != nil {
+		logger.Error("Failed to create lab analyte", "lab_test_id", test.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create lab analyte"))
+		return
+	}
+
+	logger.Info("Lab analyte created", "lab_analyte_id", analyte.ID, "lab_test_id", test.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    analyte.ID,
+		"message": "Successfully created lab analyte",
+		"status":  "Success",
+	})
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := map[uint]bool{}
+	var unique []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
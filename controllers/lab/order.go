@@ -0,0 +1,316 @@
+package labController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/hl7"
+	"apps90-hms/initializers"
+	"apps90-hms/laboratory"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Order statuses each status may move to. Collected is reached only
+// through sample collection and Resulted through result verification.
+var orderTransitions = map[string][]string{
+	models.LabOrderStatusOrdered:    {models.LabOrderStatusCollected, models.LabOrderStatusCancelled},
+	models.LabOrderStatusCollected:  {models.LabOrderStatusInProgress, models.LabOrderStatusCancelled},
+	models.LabOrderStatusInProgress: {models.LabOrderStatusResulted},
+}
+
+func canMove(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateLabOrder places an order for catalog tests against a visit. Tests come
+// from the catalog of the ordering doctor's entity.
+func CreateLabOrder(c *gin.Context) {
+	var input schemas.LabOrderInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Create Lab Order", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var visit models.Visit
+	initializers.DB.First(&visit, input.VisitID)
+	if visit.ID == 0 {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Visit not found"))
+		return
+	}
+	if visit.ClosedAt != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Visit is closed; no further orders can be placed"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+
+	testIDs := uniqueIDs(input.TestIDs)
+	var tests []models.LabTest
+	initializers.DB.Where("id IN ? AND entity_id = ? AND is_active = ?", testIDs, doctor.EntityID, true).Find(&tests)
+	if len(tests) != len(testIDs) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Some tests are not in the entity's lab catalog"))
+		return
+	}
+
+	priority := input.Priority
+	if priority == "" {
+		priority = models.LabPriorityRoutine
+	}
+
+	now := time.Now()
+	order := models.LabOrder{
+		VisitID:       visit.ID,
+		PatientID:     visit.PatientID,
+		DoctorID:      doctor.ID,
+		EntityID:      doctor.EntityID,
+		Priority:      priority,
+		ClinicalNotes: input.ClinicalNotes,
+		Status:        models.LabOrderStatusOrdered,
+		OrderedAt:     now,
+		DueAt:         now,
+	}
+	for _, test := range tests {
+		order.Items = append(order.Items, models.LabOrderItem{LabTestID: test.ID, Price: test.Price})
+		if due := now.Add(time.Duration(test.TurnaroundHours) * time.Hour); due.After(order.DueAt) {
+			order.DueAt = due
+		}
+	}
+
+	if err := initializers.DB.Create(&order).Error; err != nil {
+		logger.Error("Failed to create lab order", "visit_id", visit.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create lab order"))
+		return
+	}
+
+	// Pass the order on to the LIS when one is configured
+	hl7.EmitLabOrder(order.ID)
+
+	logger.Info("Lab order created", "lab_order_id", order.ID, "visit_id", visit.ID, "tests", len(tests))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    order.ID,
+		"message": "Successfully created lab order",
+		"status":  "Success",
+	})
+}
+
+// CollectSamples records sample collection for an order: one barcoded sample
+// per specimen type, linked to the tests run on it
+func CollectSamples(c *gin.Context) {
+	var input schemas.CollectSamplesInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Collect Samples", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var collector models.Employee
+	initializers.DB.First(&collector, input.CollectedByID)
+	if collector.ID == 0 {
+		logger.Warn("Collector not found", "employee_id", input.CollectedByID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Employee not found"))
+		return
+	}
+
+	var samples []models.LabSample
+	var failure *models.APIError
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.LabOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items.LabTest").First(&order, input.OrderID).Error; err != nil {
+			return err
+		}
+
+		if !canMove(order.Status, models.LabOrderStatusCollected) {
+			apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Samples have already been collected for this order")
+			failure = &apiErr
+			return nil
+		}
+
+		now := time.Now()
+		bySpecimen := map[string]*models.LabSample{}
+		for _, item := range order.Items {
+			specimen := item.LabTest.SpecimenType
+			sample, ok := bySpecimen[specimen]
+			if !ok {
+				sample = &models.LabSample{
+					LabOrderID:    order.ID,
+					Barcode:       fmt.Sprintf("LAB%08d%02d", order.ID, len(bySpecimen)+1),
+					SpecimenType:  specimen,
+					CollectedAt:   now,
+					CollectedByID: collector.ID,
+				}
+				if err := tx.Create(sample).Error; err != nil {
+					return err
+				}
+				bySpecimen[specimen] = sample
+				samples = append(samples, *sample)
+			}
+			if err := tx.Model(&item).Update("lab_sample_id", sample.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&order).Update("status", models.LabOrderStatusCollected).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Lab order not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to collect samples", "lab_order_id", input.OrderID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to record sample collection"))
+		return
+	}
+	if failure != nil {
+		c.Error(*failure)
+		return
+	}
+
+	logger.Info("Lab samples collected", "lab_order_id", input.OrderID, "samples", len(samples))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    samples,
+		"message": "Successfully recorded sample collection",
+		"status":  "Success",
+	})
+}
+
+// UpdateLabOrderStatus moves an order to its next status. Moving to
+// InProgress marks the order's samples as received by the lab.
+func UpdateLabOrderStatus(c *gin.Context) {
+	var input schemas.LabOrderStatusInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Lab Order Status", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var order models.LabOrder
+	initializers.DB.First(&order, input.OrderID)
+	if order.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Lab order not found"))
+		return
+	}
+
+	if !canMove(order.Status, input.Status) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest,
+			fmt.Sprintf("Lab order cannot move from %s to %s", order.Status, input.Status)))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if input.Status == models.LabOrderStatusInProgress {
+			return laboratory.MarkReceived(tx, &order)
+		}
+		// Guard on the current status so concurrent updates cannot skip a step
+		result := tx.Model(&order).Where("status = ?", order.Status).Update("status", input.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusConflict, errors.ErrBadRequest, "Lab order status changed; reload and retry"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to update lab order status", "lab_order_id", order.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update lab order"))
+		return
+	}
+
+	logger.Info("Lab order status updated", "lab_order_id", order.ID, "from", order.Status, "to", input.Status)
+	c.JSON(http.StatusOK, gin.H{"data": order.ID, "message": "Lab order updated successfully", "status": "Success"})
+}
+
+// GetLabOrders lists lab orders by order_id, visit_id or patient_id, newest first
+func GetLabOrders(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("Doctor").Preload("Items.LabTest").Preload("Samples")
+	switch {
+	case c.Query("order_id") != "":
+		query = query.Where("id = ?", c.Query("order_id"))
+	case c.Query("visit_id") != "":
+		query = query.Where("visit_id = ?", c.Query("visit_id"))
+	case c.Query("patient_id") != "":
+		query = query.Where("patient_id = ?", c.Query("patient_id"))
+	default:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "order_id, visit_id or patient_id is required"))
+		return
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var orders []models.LabOrder
+	if err := query.Order("ordered_at DESC").Find(&orders).Error; err != nil {
+		logger.Error("Failed to fetch lab orders", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch lab orders"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    orders,
+		"message": "Successfully fetched lab orders",
+		"status":  "Success",
+	})
+}
+
+// GetSampleByBarcode looks up a scanned sample with its order and tests
+func GetSampleByBarcode(c *gin.Context) {
+	barcode := c.Query("barcode")
+
+	if barcode == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "barcode is required"))
+		return
+	}
+
+	var sample models.LabSample
+	initializers.DB.Where("barcode = ?", barcode).Find(&sample)
+	if sample.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Sample not found"))
+		return
+	}
+
+	var items []models.LabOrderItem
+	initializers.DB.Preload("LabTest").Where("lab_sample_id = ?", sample.ID).Find(&items)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"sample": sample,
+			"tests":  items,
+		},
+		"message": "Successfully fetched sample",
+		"status":  "Success",
+	})
+}
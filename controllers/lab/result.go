@@ -0,0 +1,306 @@
+package labController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/laboratory"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EnterLabResults records analyte values for an order that is in progress,
+// flagging each numeric value against the patient's reference range
+func EnterLabResults(c *gin.Context) {
+	var input schemas.LabResultEntryInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Enter Lab Results", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var employee models.Employee
+	initializers.DB.First(&employee, input.EnteredByID)
+	if employee.ID == 0 {
+		logger.Warn("Employee not found", "employee_id", input.EnteredByID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Employee not found"))
+		return
+	}
+
+	var results []models.LabResult
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.LabOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Patient").
+			First(&order, input.OrderID).Error; err != nil {
+			return err
+		}
+
+		values := make([]laboratory.ResultValue, 0, len(input.Results))
+		for _, value := range input.Results {
+			values = append(values, laboratory.ResultValue{AnalyteID: value.AnalyteID, Value: value.Value})
+		}
+
+		var err error
+		results, err = laboratory.RecordResults(tx, &order, values, employee.ID)
+		return err
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Lab order not found"))
+		return
+	}
+	if laboratory.IsWorkflowError(err) {
+		logger.Warn("Lab results rejected", "lab_order_id", input.OrderID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Lab results rejected: "+err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to enter lab results", "lab_order_id", input.OrderID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to save lab results"))
+		return
+	}
+
+	logger.Info("Lab results entered", "lab_order_id", input.OrderID, "results", len(results), "employee_id", employee.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    results,
+		"message": "Successfully entered lab results",
+		"status":  "Success",
+	})
+}
+
+// VerifyLabResults releases entered results. The verifier is the employee of
+// the logged-in user and must be a different user from whoever entered them.
+// Once every analyte of the order is verified the order becomes Resulted.
+func VerifyLabResults(c *gin.Context) {
+	var input schemas.VerifyLabResultsInput
+	logger := loggers.InitializeLogger()
+
+	value, ok := c.Get("currentUser")
+	user, isUser := value.(models.User)
+	if !ok || !isUser {
+		c.Error(models.WrapError(http.StatusUnauthorized, errors.ErrUserNotFound, "User not found"))
+		return
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Verify Lab Results", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	// Users and employees share an email address
+	var verifier models.Employee
+	initializers.DB.Where("LOWER(email) = LOWER(?)", user.Email).First(&verifier)
+	if verifier.ID == 0 {
+		logger.Warn("No employee for user", "user_id", user.ID)
+		c.Error(models.WrapError(http.StatusForbidden, errors.ErrForbidden, "Only lab staff can verify results"))
+		return
+	}
+
+	var verified int64
+	orderStatus := ""
+	var failure *models.APIError
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.LabOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, input.OrderID).Error; err != nil {
+			return err
+		}
+		orderStatus = order.Status
+		if order.Status != models.LabOrderStatusInProgress {
+			apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Only results of orders in progress can be verified")
+			failure = &apiErr
+			return nil
+		}
+
+		query := tx.Where("lab_order_id = ? AND status = ?", order.ID, models.LabResultStatusEntered)
+		if len(input.ResultIDs) > 0 {
+			query = query.Where("id IN ?", input.ResultIDs)
+		}
+		var pending []models.LabResult
+		if err := query.Find(&pending).Error; err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "No entered results to verify")
+			failure = &apiErr
+			return nil
+		}
+
+		var ids []uint
+		for _, result := range pending {
+			if result.EnteredByID == verifier.ID {
+				apiErr := models.WrapError(http.StatusForbidden, errors.ErrBadRequest, "Results must be verified by someone other than who entered them")
+				failure = &apiErr
+				return nil
+			}
+			ids = append(ids, result.ID)
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.LabResult{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":         models.LabResultStatusVerified,
+			"verified_by_id": verifier.ID,
+			"verified_at":    now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		verified = result.RowsAffected
+
+		// Release the order once every expected analyte is verified
+		expected, err := laboratory.ExpectedAnalytes(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		var done int64
+		if err := tx.Model(&models.LabResult{}).
+			Where("lab_order_id = ? AND status = ?", order.ID, models.LabResultStatusVerified).
+			Count(&done).Error; err != nil {
+			return err
+		}
+		if int(done) >= len(expected) {
+			orderStatus = models.LabOrderStatusResulted
+			return tx.Model(&order).Update("status", models.LabOrderStatusResulted).Error
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Lab order not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to verify lab results", "lab_order_id", input.OrderID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to verify lab results"))
+		return
+	}
+	if failure != nil {
+		logger.Warn("Lab results not verified", "lab_order_id", input.OrderID, "reason", failure.Message)
+		c.Error(*failure)
+		return
+	}
+
+	logger.Info("Lab results verified", "lab_order_id", input.OrderID, "verified", verified, "order_status", orderStatus)
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"verified":     verified,
+			"order_status": orderStatus,
+		},
+		"message": "Successfully verified lab results",
+		"status":  "Success",
+	})
+}
+
+// GetLabResults lists every result of an order, verified or not, for lab staff
+func GetLabResults(c *gin.Context) {
+	orderID := c.Query("order_id")
+	logger := loggers.InitializeLogger()
+
+	if orderID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "order_id is required"))
+		return
+	}
+
+	var results []models.LabResult
+	if err := initializers.DB.Preload("LabAnalyte").Select("lab_result.*").
+		Joins("JOIN lab_analyte ON lab_analyte.id = lab_result.lab_analyte_id").
+		Where("lab_result.lab_order_id = ?", orderID).
+		Order("lab_analyte.lab_test_id, lab_analyte.sort_order").Find(&results).Error; err != nil {
+		logger.Error("Failed to fetch lab results", "lab_order_id", orderID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch lab results"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    results,
+		"message": "Successfully fetched lab results",
+		"status":  "Success",
+	})
+}
+
+// GetCumulativeResults returns a patient's verified results across all
+// visits, grouped by analyte and oldest first. analyte_code narrows it down.
+func GetCumulativeResults(c *gin.Context) {
+	patientID := c.Query("patient_id")
+	logger := loggers.InitializeLogger()
+
+	if patientID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "patient_id is required"))
+		return
+	}
+
+	type row struct {
+		models.LabResult
+		VisitID uint
+	}
+
+	query := initializers.DB.Model(&models.LabResult{}).
+		Select("lab_result.*, lab_order.visit_id").
+		Joins("JOIN lab_order ON lab_order.id = lab_result.lab_order_id").
+		Where("lab_result.patient_id = ? AND lab_result.status = ?", patientID, models.LabResultStatusVerified)
+	if code := c.Query("analyte_code"); code != "" {
+		query = query.Joins("JOIN lab_analyte ON lab_analyte.id = lab_result.lab_analyte_id").
+			Where("lab_analyte.code = ?", code)
+	}
+
+	var rows []row
+	if err := query.Order("lab_result.entered_at").Scan(&rows).Error; err != nil {
+		logger.Error("Failed to fetch cumulative lab results", "patient_id", patientID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch lab results"))
+		return
+	}
+
+	var analyteIDs []uint
+	for _, r := range rows {
+		analyteIDs = append(analyteIDs, r.LabAnalyteID)
+	}
+	var analytes []models.LabAnalyte
+	if len(analyteIDs) > 0 {
+		initializers.DB.Where("id IN ?", uniqueIDs(analyteIDs)).Order("lab_test_id, sort_order").Find(&analytes)
+	}
+
+	byAnalyte := map[uint]*schemas.CumulativeResultResponse{}
+	response := []schemas.CumulativeResultResponse{}
+	for _, analyte := range analytes {
+		response = append(response, schemas.CumulativeResultResponse{
+			AnalyteID: analyte.ID,
+			Code:      analyte.Code,
+			Name:      analyte.Name,
+			Unit:      analyte.Unit,
+			Results:   []schemas.CumulativeResultPoint{},
+		})
+	}
+	for i := range response {
+		byAnalyte[response[i].AnalyteID] = &response[i]
+	}
+	for _, r := range rows {
+		entry := byAnalyte[r.LabAnalyteID]
+		if entry == nil {
+			continue
+		}
+		entry.Results = append(entry.Results, schemas.CumulativeResultPoint{
+			ResultID:   r.ID,
+			LabOrderID: r.LabOrderID,
+			VisitID:    r.VisitID,
+			ResultedAt: r.EnteredAt,
+			Value:      r.Value,
+			Flag:       r.Flag,
+			RangeLow:   r.RangeLow,
+			RangeHigh:  r.RangeHigh,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    response,
+		"message": "Successfully fetched cumulative lab results",
+		"status":  "Success",
+	})
+}
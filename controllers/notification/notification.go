@@ -0,0 +1,112 @@
+package notificationController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetNotifications lists outbox messages with their delivery attempts,
+// filtered by appointment_id, entity_id and status.
+func GetNotifications(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("Deliveries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempt")
+	})
+
+	if appointmentID := c.Query("appointment_id"); appointmentID != "" {
+		query = query.Where("appointment_id = ?", appointmentID)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var messages []models.NotificationOutbox
+	if err := query.Order("send_at DESC").Limit(500).Find(&messages).Error; err != nil {
+		logger.Error("Failed to fetch notifications", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    messages,
+		"message": "Successfully fetched notifications",
+		"status":  "Success",
+	})
+}
+
+// GetNotificationFeed lists in-app alerts of an entity, newest first,
+// filtered by category and unread=true
+func GetNotificationFeed(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	entityID := c.Query("entity_id")
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	query := initializers.DB.Where("entity_id = ? AND is_active = ?", entityID, true)
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var alerts []models.InAppNotification
+	if err := query.Order("updated_at DESC").Limit(200).Find(&alerts).Error; err != nil {
+		logger.Error("Failed to fetch notification feed", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch notification feed"))
+		return
+	}
+
+	var unread int64
+	initializers.DB.Model(&models.InAppNotification{}).
+		Where("entity_id = ? AND is_active = ? AND read_at IS NULL", entityID, true).Count(&unread)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    gin.H{"notifications": alerts, "unread": unread},
+		"message": "Successfully fetched notification feed",
+		"status":  "Success",
+	})
+}
+
+// MarkNotificationsRead marks in-app alerts as read. Alerts already read keep
+// who read them first.
+func MarkNotificationsRead(c *gin.Context) {
+	var input schemas.MarkNotificationsReadInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Mark Notifications Read", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	result := initializers.DB.Model(&models.InAppNotification{}).
+		Where("id IN ? AND read_at IS NULL", input.NotificationIDs).
+		Updates(map[string]interface{}{"read_at": time.Now(), "read_by_id": input.EmployeeID})
+	if result.Error != nil {
+		logger.Error("Failed to mark notifications read", "error", result.Error.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to mark notifications read"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    result.RowsAffected,
+		"message": "Successfully marked notifications read",
+		"status":  "Success",
+	})
+}
@@ -0,0 +1,314 @@
+package patientController
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func CreateClinicalNote(c *gin.Context) {
+	var input schemas.ClinicalNoteInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Create Clinical Note", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var visit models.Visit
+	if err := initializers.DB.First(&visit, input.VisitID).Error; err != nil {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Visit not found", "status": "Error"})
+		return
+	}
+
+	var author models.Employee
+	if err := initializers.DB.First(&author, input.AuthorID).Error; err != nil {
+		logger.Warn("Author not found", "author_id", input.AuthorID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Author not found", "status": "Error"})
+		return
+	}
+
+	noteTime := time.Now()
+	if input.NoteTime != nil {
+		noteTime = *input.NoteTime
+	}
+
+	note := models.ClinicalNote{
+		VisitID:    visit.ID,
+		PatientID:  visit.PatientID,
+		AuthorID:   author.ID,
+		NoteType:   input.NoteType,
+		NoteTime:   noteTime,
+		Subjective: input.Subjective,
+		Objective:  input.Objective,
+		Assessment: input.Assessment,
+		Plan:       input.Plan,
+		Body:       input.Body,
+		Status:     models.NoteStatusDraft,
+	}
+	if message := missingNoteContent(note); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": message, "status": "Error"})
+		return
+	}
+
+	if err := initializers.DB.Create(&note).Error; err != nil {
+		logger.Error("Failed to create clinical note", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create clinical note", "status": "Error"})
+		return
+	}
+
+	logger.Info("Clinical note created", "note_id", note.ID, "visit_id", visit.ID, "note_type", note.NoteType)
+	c.JSON(http.StatusOK, gin.H{"data": note.ID, "message": "Successfully created clinical note", "status": "Success"})
+}
+
+// UpdateClinicalNote edits a draft note. Only its author may edit it, and
+// signed notes can only be amended through an addendum.
+func UpdateClinicalNote(c *gin.Context) {
+	var input schemas.UpdateClinicalNoteInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Clinical Note", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var note models.ClinicalNote
+	if err := initializers.DB.First(&note, input.NoteID).Error; err != nil {
+		logger.Warn("Clinical note not found", "note_id", input.NoteID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Clinical note not found", "status": "Error"})
+		return
+	}
+
+	if note.AuthorID != input.AuthorID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Only the author can edit this note", "status": "Error"})
+		return
+	}
+	if note.Status == models.NoteStatusSigned {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Signed notes cannot be edited; add an addendum instead", "status": "Error"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	for column, field := range map[string]struct {
+		value  *string
+		target *string
+	}{
+		"subjective": {input.Subjective, &note.Subjective},
+		"objective":  {input.Objective, &note.Objective},
+		"assessment": {input.Assessment, &note.Assessment},
+		"plan":       {input.Plan, &note.Plan},
+		"body":       {input.Body, &note.Body},
+	} {
+		if field.value != nil {
+			updates[column] = *field.value
+			*field.target = *field.value
+		}
+	}
+
+	// The edited note must still have the content a new note needs
+	if message := missingNoteContent(note); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": message, "status": "Error"})
+		return
+	}
+
+	if len(updates) > 0 {
+		result := initializers.DB.Model(&note).Where("status = ?", models.NoteStatusDraft).Updates(updates)
+		if result.Error != nil {
+			logger.Error("Failed to update clinical note", "note_id", note.ID, "error", result.Error.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update clinical note", "status": "Error"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Signed notes cannot be edited; add an addendum instead", "status": "Error"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": note.ID, "message": "Clinical note updated successfully", "status": "Success"})
+}
+
+// missingNoteContent explains why a note has no content to record: SOAP notes
+// need at least one section and other notes a body. It is empty otherwise.
+func missingNoteContent(note models.ClinicalNote) string {
+	if note.NoteType == models.NoteTypeSOAP {
+		if note.Subjective == "" && note.Objective == "" && note.Assessment == "" && note.Plan == "" {
+			return "SOAP notes need at least one SOAP section"
+		}
+	} else if note.Body == "" {
+		return "Note body is required"
+	}
+	return ""
+}
+
+// SignClinicalNote locks a draft note. Only its author may sign it.
+func SignClinicalNote(c *gin.Context) {
+	var input schemas.SignClinicalNoteInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Sign Clinical Note", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var note models.ClinicalNote
+	if err := initializers.DB.First(&note, input.NoteID).Error; err != nil {
+		logger.Warn("Clinical note not found", "note_id", input.NoteID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Clinical note not found", "status": "Error"})
+		return
+	}
+
+	if note.AuthorID != input.AuthorID {
+		c.JSON(http.StatusForbidden, gin.H{"message": "Only the author can sign this note", "status": "Error"})
+		return
+	}
+
+	result := initializers.DB.Model(&note).Where("status = ?", models.NoteStatusDraft).Updates(map[string]interface{}{
+		"status":    models.NoteStatusSigned,
+		"signed_at": time.Now(),
+	})
+	if result.Error != nil {
+		logger.Error("Failed to sign clinical note", "note_id", note.ID, "error", result.Error.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to sign clinical note", "status": "Error"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Clinical note is already signed", "status": "Error"})
+		return
+	}
+
+	logger.Info("Clinical note signed", "note_id", note.ID, "author_id", note.AuthorID)
+	c.JSON(http.StatusOK, gin.H{"data": note.ID, "message": "Clinical note signed successfully", "status": "Success"})
+}
+
+// AddAddendum attaches a draft addendum to a signed note
+func AddAddendum(c *gin.Context) {
+	var input schemas.AddendumInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add Addendum", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var parent models.ClinicalNote
+	if err := initializers.DB.First(&parent, input.NoteID).Error; err != nil {
+		logger.Warn("Clinical note not found", "note_id", input.NoteID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Clinical note not found", "status": "Error"})
+		return
+	}
+
+	if parent.Status != models.NoteStatusSigned {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Draft notes can be edited directly; addenda are for signed notes", "status": "Error"})
+		return
+	}
+	if parent.ParentNoteID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Addenda are attached to the original note, not to another addendum", "status": "Error"})
+		return
+	}
+
+	var author models.Employee
+	if err := initializers.DB.First(&author, input.AuthorID).Error; err != nil {
+		logger.Warn("Author not found", "author_id", input.AuthorID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Author not found", "status": "Error"})
+		return
+	}
+
+	addendum := models.ClinicalNote{
+		VisitID:      parent.VisitID,
+		PatientID:    parent.PatientID,
+		AuthorID:     author.ID,
+		NoteType:     models.NoteTypeAddendum,
+		NoteTime:     time.Now(),
+		Body:         input.Body,
+		Status:       models.NoteStatusDraft,
+		ParentNoteID: &parent.ID,
+	}
+
+	if err := initializers.DB.Create(&addendum).Error; err != nil {
+		logger.Error("Failed to create addendum", "note_id", parent.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create addendum", "status": "Error"})
+		return
+	}
+
+	logger.Info("Addendum created", "note_id", addendum.ID, "parent_note_id", parent.ID)
+	c.JSON(http.StatusOK, gin.H{"data": addendum.ID, "message": "Successfully created addendum", "status": "Success"})
+}
+
+// GetClinicalNotes lists the notes of a visit (visit_id) or of every visit of
+// a patient (patient_id), oldest first, with addenda under their notes
+func GetClinicalNotes(c *gin.Context) {
+	visitID := c.Query("visit_id")
+	patientID := c.Query("patient_id")
+	logger := loggers.InitializeLogger()
+
+	if visitID == "" && patientID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"data": nil, "message": "Missing visit_id or patient_id", "status": "Error"})
+		return
+	}
+
+	query := initializers.DB.Preload("Author").
+		Preload("Addenda", func(db *gorm.DB) *gorm.DB { return db.Order("note_time") }).
+		Preload("Addenda.Author").
+		Where("parent_note_id IS NULL")
+	if visitID != "" {
+		query = query.Where("visit_id = ?", visitID)
+	}
+	if patientID != "" {
+		query = query.Where("patient_id = ?", patientID)
+	}
+	if noteType := c.Query("note_type"); noteType != "" {
+		query = query.Where("note_type = ?", noteType)
+	}
+
+	var notes []models.ClinicalNote
+	if err := query.Order("note_time").Find(&notes).Error; err != nil {
+		logger.Error("Failed to fetch clinical notes", "visit_id", visitID, "patient_id", patientID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"data": nil, "message": "Failed to fetch clinical notes", "status": "Error"})
+		return
+	}
+
+	response := []schemas.ClinicalNoteResponse{}
+	for _, note := range notes {
+		noteResponse := toClinicalNoteResponse(note)
+		for _, addendum := range note.Addenda {
+			noteResponse.Addenda = append(noteResponse.Addenda, toClinicalNoteResponse(addendum))
+		}
+		response = append(response, noteResponse)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    response,
+		"message": "Successfully fetched clinical notes",
+		"status":  "Success",
+	})
+}
+
+func toClinicalNoteResponse(note models.ClinicalNote) schemas.ClinicalNoteResponse {
+	return schemas.ClinicalNoteResponse{
+		ID:         note.ID,
+		VisitID:    note.VisitID,
+		NoteType:   note.NoteType,
+		NoteTime:   note.NoteTime,
+		AuthorID:   note.AuthorID,
+		AuthorName: note.Author.FirstName + " " + note.Author.LastName,
+		Subjective: note.Subjective,
+		Objective:  note.Objective,
+		Assessment: note.Assessment,
+		Plan:       note.Plan,
+		Body:       note.Body,
+		Status:     note.Status,
+		SignedAt:   note.SignedAt,
+		Addenda:    []schemas.ClinicalNoteResponse{},
+	}
+}
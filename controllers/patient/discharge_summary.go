@@ -0,0 +1,358 @@
+package patientController
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/pdfgen"
+	"apps90-hms/schemas"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GenerateDischargeSummary drafts a discharge summary from an IP visit, its
+// prescriptions, ADT history, clinical notes, lab and radiology orders. An
+// existing summary is returned as is.
+func GenerateDischargeSummary(c *gin.Context) {
+	var input schemas.GenerateDischargeSummaryInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Generate Discharge Summary", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var visit models.Visit
+	if err := initializers.DB.First(&visit, input.VisitID).Error; err != nil {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Visit not found", "status": "Error"})
+		return
+	}
+
+	if visit.VisitType != "IP" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Discharge summaries are only available for IP visits", "status": "Error"})
+		return
+	}
+
+	var existing models.DischargeSummary
+	initializers.DB.Where("visit_id = ?", visit.ID).Find(&existing)
+	if existing.ID != 0 {
+		c.JSON(http.StatusOK, gin.H{"data": existing, "message": "Discharge summary already exists", "status": "Success"})
+		return
+	}
+
+	// Medications from the visit's prescriptions
+	var prescriptions []models.Prescription
+	initializers.DB.Preload("Doctor").Preload("PrescriptionItems").
+		Where("visit_id = ?", visit.ID).Order("date_issued").Find(&prescriptions)
+
+	var medications []string
+	for _, prescription := range prescriptions {
+		var items []string
+		for _, item := range prescription.PrescriptionItems {
+			items = append(items, item.PrescriptionDetails)
+		}
+		medications = append(medications, fmt.Sprintf("%s (Dr. %s %s): %s",
+			prescription.DateIssued.Format("02 Jan 2006"), prescription.Doctor.FirstName, prescription.Doctor.LastName,
+			strings.Join(items, "; ")))
+	}
+
+	// Hospital course from the visit notes and ADT history
+	course := []string{}
+	if visit.Notes != "" {
+		course = append(course, visit.Notes)
+	}
+	var events []models.AdtEvent
+	initializers.DB.Preload("ToBed.Room").Where("visit_id = ?", visit.ID).Order("event_time, id").Find(&events)
+	for _, event := range events {
+		line := event.EventTime.Format("02 Jan 2006 15:04") + " " + event.EventType
+		if event.ToBed != nil && event.EventType != models.AdtEventDischarge {
+			line += " to bed " + event.ToBed.Room.RoomNumber + "/" + event.ToBed.BedNumber
+		}
+		if event.Notes != "" {
+			line += ": " + event.Notes
+		}
+		course = append(course, line)
+	}
+
+	summary := models.DischargeSummary{
+		VisitID:        visit.ID,
+		PatientID:      visit.PatientID,
+		DoctorID:       visit.DoctorID,
+		AdmissionDate:  visit.AdmissionDate,
+		DischargeDate:  visit.DischargeDate,
+		Diagnosis:      visit.Diagnosis,
+		HospitalCourse: strings.Join(course, "\n"),
+		TreatmentPlan:  visit.TreatmentPlan,
+		Procedures:     dischargeProcedures(visit.ID),
+		Vitals:         dischargeVitals(visit.ID),
+		Medications:    strings.Join(medications, "\n"),
+		Status:         models.SummaryStatusDraft,
+	}
+
+	if err := initializers.DB.Create(&summary).Error; err != nil {
+		logger.Error("Failed to create discharge summary", "visit_id", visit.ID, "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create discharge summary", "status": "Error"})
+		return
+	}
+
+	logger.Info("Discharge summary generated", "summary_id", summary.ID, "visit_id", visit.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    summary,
+		"message": "Successfully generated discharge summary",
+		"status":  "Success",
+	})
+}
+
+// UpdateDischargeSummary edits a draft summary. Signed summaries are locked.
+func UpdateDischargeSummary(c *gin.Context) {
+	var input schemas.UpdateDischargeSummaryInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Discharge Summary", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var summary models.DischargeSummary
+	if err := initializers.DB.First(&summary, input.SummaryID).Error; err != nil {
+		logger.Warn("Discharge summary not found", "summary_id", input.SummaryID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Discharge summary not found", "status": "Error"})
+		return
+	}
+
+	if summary.Status == models.SummaryStatusSigned {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Signed discharge summaries cannot be edited", "status": "Error"})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	for column, value := range map[string]*string{
+		"diagnosis":              input.Diagnosis,
+		"hospital_course":        input.HospitalCourse,
+		"treatment_plan":         input.TreatmentPlan,
+		"procedures":             input.Procedures,
+		"vitals":                 input.Vitals,
+		"medications":            input.Medications,
+		"follow_up_instructions": input.FollowUpInstructions,
+	} {
+		if value != nil {
+			updates[column] = *value
+		}
+	}
+
+	if len(updates) > 0 {
+		// Guard on status so a concurrent sign-off cannot be overwritten
+		result := initializers.DB.Model(&summary).Where("status = ?", models.SummaryStatusDraft).Updates(updates)
+		if result.Error != nil {
+			logger.Error("Failed to update discharge summary", "summary_id", summary.ID, "error", result.Error.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update discharge summary", "status": "Error"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Signed discharge summaries cannot be edited", "status": "Error"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary.ID, "message": "Discharge summary updated successfully", "status": "Success"})
+}
+
+// SignDischargeSummary finalises a draft summary. Only the visit's attending
+// doctor, as the logged-in user, may sign, the visit must have been
+// discharged, and nothing can be edited afterwards.
+func SignDischargeSummary(c *gin.Context) {
+	var input schemas.SignDischargeSummaryInput
+	logger := loggers.InitializeLogger()
+
+	value, ok := c.Get("currentUser")
+	user, isUser := value.(models.User)
+	if !ok || !isUser {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "User not found", "status": "Error"})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Sign Discharge Summary", "error", err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request format", "status": "Error"})
+		return
+	}
+
+	var summary models.DischargeSummary
+	if err := initializers.DB.Preload("Visit").First(&summary, input.SummaryID).Error; err != nil {
+		logger.Warn("Discharge summary not found", "summary_id", input.SummaryID)
+		c.JSON(http.StatusNotFound, gin.H{"message": "Discharge summary not found", "status": "Error"})
+		return
+	}
+
+	// Users and employees share an email address
+	var doctor models.Employee
+	initializers.DB.Where("LOWER(email) = LOWER(?)", user.Email).First(&doctor)
+
+	if summary.Status == models.SummaryStatusSigned {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Discharge summary is already signed", "status": "Error"})
+		return
+	}
+	if doctor.ID == 0 || doctor.ID != summary.Visit.DoctorID {
+		logger.Warn("Discharge summary sign-off by a doctor other than the attending", "summary_id", summary.ID, "user_id", user.ID)
+		c.JSON(http.StatusForbidden, gin.H{"message": "Only the attending doctor can sign the discharge summary", "status": "Error"})
+		return
+	}
+	if summary.Visit.DischargeDate == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Visit must be discharged before the summary is signed", "status": "Error"})
+		return
+	}
+
+	now := time.Now()
+	result := initializers.DB.Model(&summary).Where("status = ?", models.SummaryStatusDraft).Updates(map[string]interface{}{
+		"status":         models.SummaryStatusSigned,
+		"signed_by_id":   doctor.ID,
+		"signed_at":      now,
+		"discharge_date": summary.Visit.DischargeDate,
+	})
+	if result.Error != nil {
+		logger.Error("Failed to sign discharge summary", "summary_id", summary.ID, "error", result.Error.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to sign discharge summary", "status": "Error"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Discharge summary is already signed", "status": "Error"})
+		return
+	}
+
+	logger.Info("Discharge summary signed", "summary_id", summary.ID, "doctor_id", doctor.ID)
+	c.JSON(http.StatusOK, gin.H{"data": summary.ID, "message": "Discharge summary signed successfully", "status": "Success"})
+}
+
+// GetDischargeSummary returns a visit's discharge summary as JSON, or as a
+// PDF download with format=pdf
+func GetDischargeSummary(c *gin.Context) {
+	visitID := c.Query("visit_id")
+	logger := loggers.InitializeLogger()
+
+	if visitID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"data": nil, "message": "Missing visit_id", "status": "Error"})
+		return
+	}
+
+	var summary models.DischargeSummary
+	if err := initializers.DB.Preload("Patient").Preload("Doctor").Preload("SignedBy").
+		Where("visit_id = ?", visitID).First(&summary).Error; err != nil {
+		logger.Warn("Discharge summary not found", "visit_id", visitID)
+		c.JSON(http.StatusNotFound, gin.H{"data": nil, "message": "Discharge summary not found", "status": "Error"})
+		return
+	}
+
+	if c.Query("format") != "pdf" {
+		c.JSON(http.StatusOK, gin.H{
+			"data":    summary,
+			"message": "Successfully fetched discharge summary",
+			"status":  "Success",
+		})
+		return
+	}
+
+	discharged := "-"
+	if summary.DischargeDate != nil {
+		discharged = summary.DischargeDate.Format("02 Jan 2006 15:04")
+	}
+	signature := "DRAFT - not signed"
+	if summary.SignedBy != nil && summary.SignedAt != nil {
+		signature = "Signed by Dr. " + summary.SignedBy.FirstName + " " + summary.SignedBy.LastName +
+			" on " + summary.SignedAt.Format("02 Jan 2006 15:04")
+	}
+
+	document := pdfgen.Document{
+		Title: "Discharge Summary",
+		Sections: []pdfgen.Section{
+			{Heading: "Patient", Body: summary.Patient.FirstName + " " + summary.Patient.LastName +
+				"\nGender: " + summary.Patient.Gender +
+				"\nDate of birth: " + summary.Patient.DateOfBirth.Format("02 Jan 2006")},
+			{Heading: "Attending doctor", Body: "Dr. " + summary.Doctor.FirstName + " " + summary.Doctor.LastName},
+			{Heading: "Admission", Body: "Admitted: " + summary.AdmissionDate.Format("02 Jan 2006 15:04") + "\nDischarged: " + discharged},
+			{Heading: "Diagnosis", Body: summary.Diagnosis},
+			{Heading: "Hospital course", Body: summary.HospitalCourse},
+			{Heading: "Procedures", Body: summary.Procedures},
+			{Heading: "Vitals", Body: summary.Vitals},
+			{Heading: "Treatment plan", Body: summary.TreatmentPlan},
+			{Heading: "Medications", Body: summary.Medications},
+			{Heading: "Follow-up instructions", Body: summary.FollowUpInstructions},
+			{Heading: "Sign-off", Body: signature},
+		},
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=discharge-summary-%d.pdf", summary.VisitID))
+	c.Data(http.StatusOK, "application/pdf", document.Render())
+}
+
+// dischargeProcedures lists the radiology studies performed and lab tests run
+// during a visit, with abnormal verified lab values
+func dischargeProcedures(visitID uint) string {
+	var lines []string
+
+	var studies []models.RadiologyOrder
+	initializers.DB.Preload("Report").
+		Where("visit_id = ? AND status IN ?", visitID, []string{models.RadiologyStatusPerformed, models.RadiologyStatusReported}).
+		Order("performed_at").Find(&studies)
+	for _, study := range studies {
+		line := study.Modality + " " + study.BodyPart
+		if study.Laterality != "" {
+			line += " (" + study.Laterality + ")"
+		}
+		if study.PerformedAt != nil {
+			line = study.PerformedAt.Format("02 Jan 2006") + " " + line
+		}
+		if study.Report != nil && study.Report.Status == models.RadiologyReportFinal && study.Report.Impression != "" {
+			line += ": " + study.Report.Impression
+		}
+		lines = append(lines, line)
+	}
+
+	var orders []models.LabOrder
+	initializers.DB.Preload("Items.LabTest").
+		Where("visit_id = ? AND status <> ?", visitID, models.LabOrderStatusCancelled).
+		Order("ordered_at").Find(&orders)
+	for _, order := range orders {
+		var tests []string
+		for _, item := range order.Items {
+			tests = append(tests, item.LabTest.Name)
+		}
+		line := order.OrderedAt.Format("02 Jan 2006") + " Lab: " + strings.Join(tests, ", ")
+
+		var results []models.LabResult
+		initializers.DB.Preload("LabAnalyte").
+			Where("lab_order_id = ? AND status = ? AND flag IN ?", order.ID, models.LabResultStatusVerified,
+				[]string{models.LabFlagLow, models.LabFlagHigh, models.LabFlagCriticalLow, models.LabFlagCriticalHigh}).
+			Find(&results)
+		var abnormal []string
+		for _, result := range results {
+			abnormal = append(abnormal, strings.TrimSpace(result.LabAnalyte.Name+" "+result.Value+" "+result.Unit)+" ("+result.Flag+")")
+		}
+		if len(abnormal) > 0 {
+			line += " - " + strings.Join(abnormal, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dischargeVitals collects the objective findings recorded in a visit's
+// signed SOAP notes, where vitals are charted
+func dischargeVitals(visitID uint) string {
+	var notes []models.ClinicalNote
+	initializers.DB.Where("visit_id = ? AND note_type = ? AND status = ? AND objective <> ''",
+		visitID, models.NoteTypeSOAP, models.NoteStatusSigned).
+		Order("note_time").Find(&notes)
+
+	var lines []string
+	for _, note := range notes {
+		lines = append(lines, note.NoteTime.Format("02 Jan 2006 15:04")+": "+note.Objective)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -174,6 +174,16 @@ func CreatePrescription(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Visit not found", "status": "Error"})
 		return
 	}
+	if visit.ClosedAt != nil {
+		logger.Warn("Prescription on closed visit", "visit_id", visit.ID)
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Visit is closed; no further prescriptions can be added", "status": "Error"})
+		return
+	}
+
+	if !medicinesExist(input.Items) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Medicine not found", "status": "Error"})
+		return
+	}
 
 	// Create prescription
 	prescription := models.Prescription{
@@ -192,13 +202,7 @@ func CreatePrescription(c *gin.Context) {
 	}
 
 	// Create prescription items
-	var prescriptionItems []models.PrescriptionItem
-	for _, item := range input.PrescriptionDetails {
-		prescriptionItems = append(prescriptionItems, models.PrescriptionItem{
-			PrescriptionID:      prescription.ID,
-			PrescriptionDetails: item,
-		})
-	}
+	prescriptionItems := buildPrescriptionItems(prescription.ID, input.PrescriptionDetails, input.Items)
 
 	if len(prescriptionItems) > 0 {
 		if err := initializers.DB.Create(&prescriptionItems).Error; err != nil {
@@ -235,6 +239,25 @@ func EditPrescription(c *gin.Context) {
 		return
 	}
 
+	// Prescriptions of a closed visit are final
+	var visit models.Visit
+	initializers.DB.Select("id", "closed_at").First(&visit, prescription.VisitID)
+	if visit.ClosedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Visit is closed; its prescriptions can no longer be changed", "status": "Error"})
+		return
+	}
+
+	// Replacing the items would lose what the pharmacy already handed over
+	if prescription.DispenseStatus != "" && prescription.DispenseStatus != models.PrescriptionNotDispensed {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Prescription has been dispensed and can no longer be changed", "status": "Error"})
+		return
+	}
+
+	if !medicinesExist(request.Items) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Medicine not found", "status": "Error"})
+		return
+	}
+
 	// Delete existing prescription items
 	if err := initializers.DB.Where("prescription_id = ?", request.PrescriptionID).Delete(&models.PrescriptionItem{}).Error; err != nil {
 		logger.Error("Failed to delete existing prescription items", "prescription_id", request.PrescriptionID, "error", err.Error())
@@ -243,12 +266,7 @@ func EditPrescription(c *gin.Context) {
 	}
 
 	// Insert new prescription items
-	for _, details := range request.PrescriptionItems {
-		newItem := models.PrescriptionItem{
-			PrescriptionID:      request.PrescriptionID,
-			PrescriptionDetails: details, // Store prescription details as text
-		}
-
+	for _, newItem := range buildPrescriptionItems(request.PrescriptionID, request.PrescriptionItems, request.Items) {
 		if err := initializers.DB.Create(&newItem).Error; err != nil {
 			logger.Error("Failed to insert new prescription item", "prescription_id", request.PrescriptionID, "error", err.Error())
 			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update prescription", "status": "Error"})
@@ -273,7 +291,7 @@ func GetPrescriptionDetails(c *gin.Context) {
 
 	// Fetch prescription
 	var prescription models.Prescription
-	if err := initializers.DB.Preload("Doctor").Preload("PrescriptionItems").First(&prescription, prescriptionID).Error; err != nil {
+	if err := initializers.DB.Preload("Doctor").Preload("PrescriptionItems.Medicine").First(&prescription, prescriptionID).Error; err != nil {
 		logger.Error("Prescription not found", "prescription_id", prescriptionID, "error", err.Error())
 		c.JSON(http.StatusNotFound, gin.H{"data": nil, "message": "Prescription not found", "status": "Error"})
 		return
@@ -281,8 +299,21 @@ func GetPrescriptionDetails(c *gin.Context) {
 
 	// Extract prescription details
 	var prescriptionDetails []string
+	items := []schemas.PrescriptionItemResponse{}
 	for _, item := range prescription.PrescriptionItems {
 		prescriptionDetails = append(prescriptionDetails, item.PrescriptionDetails)
+
+		itemResponse := schemas.PrescriptionItemResponse{
+			ID:                item.ID,
+			Details:           item.PrescriptionDetails,
+			MedicineID:        item.MedicineID,
+			Quantity:          item.Quantity,
+			DispensedQuantity: item.DispensedQuantity,
+		}
+		if item.Medicine != nil {
+			itemResponse.MedicineName = item.Medicine.Name
+		}
+		items = append(items, itemResponse)
 	}
 
 	// Response format
@@ -292,6 +323,8 @@ func GetPrescriptionDetails(c *gin.Context) {
 		DateIssued:        prescription.DateIssued,
 		Notes:             prescription.Notes,
 		PrescriptionItems: prescriptionDetails,
+		DispenseStatus:    prescription.DispenseStatus,
+		Items:             items,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -300,3 +333,43 @@ func GetPrescriptionDetails(c *gin.Context) {
 		"status":  "Success",
 	})
 }
+
+// buildPrescriptionItems turns free-text lines and catalog medicine lines into
+// prescription items
+func buildPrescriptionItems(prescriptionID uint, details []string, items []schemas.PrescriptionItemInput) []models.PrescriptionItem {
+	var prescriptionItems []models.PrescriptionItem
+	for _, text := range details {
+		prescriptionItems = append(prescriptionItems, models.PrescriptionItem{
+			PrescriptionID:      prescriptionID,
+			PrescriptionDetails: text, // Store prescription details as text
+		})
+	}
+	for _, item := range items {
+		medicineID := item.MedicineID
+		prescriptionItems = append(prescriptionItems, models.PrescriptionItem{
+			PrescriptionID:      prescriptionID,
+			PrescriptionDetails: item.Details,
+			MedicineID:          &medicineID,
+			Quantity:            item.Quantity,
+		})
+	}
+	return prescriptionItems
+}
+
+// medicinesExist checks that every catalog medicine named by items exists
+func medicinesExist(items []schemas.PrescriptionItemInput) bool {
+	ids := map[uint]bool{}
+	for _, item := range items {
+		ids[item.MedicineID] = true
+	}
+	if len(ids) == 0 {
+		return true
+	}
+	var medicineIDs []uint
+	for id := range ids {
+		medicineIDs = append(medicineIDs, id)
+	}
+	var count int64
+	initializers.DB.Model(&models.Medicine{}).Where("id IN ?", medicineIDs).Count(&count)
+	return int(count) == len(ids)
+}
@@ -0,0 +1,192 @@
+package pharmacyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/pharmacy"
+	"apps90-hms/schemas"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// nearExpiryDays is the default horizon of the near-expiry report,
+// PHARMACY_NEAR_EXPIRY_DAYS or 90
+func nearExpiryDays() int {
+	if days, err := strconv.Atoi(os.Getenv("PHARMACY_NEAR_EXPIRY_DAYS")); err == nil && days > 0 {
+		return days
+	}
+	return 90
+}
+
+// GetBatches lists the batches in stock at an entity (entity_id), optionally
+// for one medicine (medicine_id), first-expiring first. Pass
+// include_empty=true to include used-up batches.
+func GetBatches(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	entityID := c.Query("entity_id")
+
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	query := initializers.DB.Preload("Medicine").Where("entity_id = ?", entityID)
+	if medicineID := c.Query("medicine_id"); medicineID != "" {
+		query = query.Where("medicine_id = ?", medicineID)
+	}
+	if c.Query("include_empty") != "true" {
+		query = query.Where("quantity > 0")
+	}
+
+	var batches []models.MedicineBatch
+	if err := query.Order("expiry_date, id").Find(&batches).Error; err != nil {
+		logger.Error("Failed to fetch medicine batches", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch batches"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    batches,
+		"message": "Successfully fetched batches",
+		"status":  "Success",
+	})
+}
+
+// GetNearExpiryReport lists batches with stock at an entity (entity_id) that
+// expire within days (default PHARMACY_NEAR_EXPIRY_DAYS), including those
+// already expired
+func GetNearExpiryReport(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	entityID := c.Query("entity_id")
+
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+	days := nearExpiryDays()
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "days must be a positive number"))
+			return
+		}
+		days = parsed
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizon := today.AddDate(0, 0, days)
+
+	var batches []models.MedicineBatch
+	if err := initializers.DB.Preload("Medicine").
+		Where("entity_id = ? AND quantity > 0 AND expiry_date <= ?", entityID, horizon.Format("2006-01-02")).
+		Order("expiry_date, medicine_id").Find(&batches).Error; err != nil {
+		logger.Error("Failed to fetch near-expiry batches", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch near-expiry report"))
+		return
+	}
+
+	rows := []schemas.NearExpiryRow{}
+	for _, batch := range batches {
+		expiry := time.Date(batch.ExpiryDate.Year(), batch.ExpiryDate.Month(), batch.ExpiryDate.Day(), 0, 0, 0, 0, time.UTC)
+		row := schemas.NearExpiryRow{
+			BatchID:       batch.ID,
+			MedicineID:    batch.MedicineID,
+			BatchNumber:   batch.BatchNumber,
+			ExpiryDate:    batch.ExpiryDate,
+			DaysLeft:      int(math.Round(expiry.Sub(today).Hours() / 24)),
+			Expired:       batch.Expired(now),
+			Quantity:      batch.Quantity,
+			PurchaseValue: math.Round(float64(batch.Quantity)*batch.PurchasePrice*100) / 100,
+		}
+		if batch.Medicine != nil {
+			row.MedicineName = batch.Medicine.Name
+		}
+		rows = append(rows, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    rows,
+		"message": "Successfully fetched near-expiry report",
+		"status":  "Success",
+	})
+}
+
+// WriteOffExpiredBatches pulls every expired batch at an entity off the
+// shelf with an expiry write-off
+func WriteOffExpiredBatches(c *gin.Context) {
+	var input schemas.WriteOffExpiredInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Write Off Expired Batches", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var movements []models.StockMovement
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		movements, err = pharmacy.WriteOffExpired(tx, input.EntityID, time.Now(), input.PerformedByID)
+		return err
+	})
+	if pharmacy.IsStockError(err) {
+		c.Error(models.WrapError(http.StatusConflict, errors.ErrBadRequest, "Write-off rejected, stock changed meanwhile: "+err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to write off expired batches", "entity_id", input.EntityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to write off expired batches"))
+		return
+	}
+
+	logger.Info("Expired batches written off", "entity_id", input.EntityID, "batches", len(movements))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    movements,
+		"message": "Successfully wrote off expired batches",
+		"status":  "Success",
+	})
+}
+
+// PreviewFEFO shows which batches a dispense of quantity units of a medicine
+// at an entity would draw from, without moving any stock
+func PreviewFEFO(c *gin.Context) {
+	entityID, err1 := strconv.ParseUint(c.Query("entity_id"), 10, 64)
+	medicineID, err2 := strconv.ParseUint(c.Query("medicine_id"), 10, 64)
+	quantity, err3 := strconv.Atoi(c.Query("quantity"))
+
+	if err1 != nil || err2 != nil || err3 != nil || quantity <= 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id, medicine_id and a positive quantity are required"))
+		return
+	}
+
+	var allocations []pharmacy.Allocation
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		allocations, err = pharmacy.PickFEFO(tx, uint(entityID), uint(medicineID), quantity, time.Now())
+		return err
+	})
+	if pharmacy.IsStockError(err) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, err.Error()))
+		return
+	}
+	if err != nil {
+		loggers.InitializeLogger().Error("Failed to pick batches", "medicine_id", medicineID, "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to pick batches"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    allocations,
+		"message": "Successfully picked batches",
+		"status":  "Success",
+	})
+}
@@ -0,0 +1,158 @@
+package pharmacyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/pharmacy"
+	"apps90-hms/schemas"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetDispensablePrescription pulls a prescription's items for the pharmacist
+// with what remains to be handed over and the usable stock at the pharmacy
+// (prescription_id, entity_id)
+func GetDispensablePrescription(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	prescriptionID := c.Query("prescription_id")
+	entityID, err := strconv.ParseUint(c.Query("entity_id"), 10, 64)
+
+	if prescriptionID == "" || err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "prescription_id and entity_id are required"))
+		return
+	}
+
+	var prescription models.Prescription
+	initializers.DB.Preload("Patient").Preload("Doctor").Preload("PrescriptionItems.Medicine").Find(&prescription, prescriptionID)
+	if prescription.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Prescription not found"))
+		return
+	}
+
+	response := schemas.DispensablePrescription{
+		PrescriptionID: prescription.ID,
+		PatientID:      prescription.PatientID,
+		PatientName:    prescription.Patient.FirstName + " " + prescription.Patient.LastName,
+		DoctorName:     prescription.Doctor.FirstName + " " + prescription.Doctor.LastName,
+		DateIssued:     prescription.DateIssued,
+		DispenseStatus: prescription.DispenseStatus,
+		Items:          []schemas.DispensableItem{},
+	}
+	for _, item := range prescription.PrescriptionItems {
+		row := schemas.DispensableItem{
+			PrescriptionItemID: item.ID,
+			Details:            item.PrescriptionDetails,
+			MedicineID:         item.MedicineID,
+			Quantity:           item.Quantity,
+			DispensedQuantity:  item.DispensedQuantity,
+			Remaining:          max(item.Quantity-item.DispensedQuantity, 0),
+		}
+		if item.Medicine != nil {
+			row.MedicineName = item.Medicine.Name
+			usable, err := pharmacy.Usable(initializers.DB, uint(entityID), item.Medicine.ID, time.Now())
+			if err != nil {
+				logger.Error("Failed to fetch stock", "medicine_id", item.Medicine.ID, "entity_id", entityID, "error", err.Error())
+				c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch stock"))
+				return
+			}
+			row.Available = usable
+		}
+		response.Items = append(response.Items, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    response,
+		"message": "Successfully fetched prescription for dispensing",
+		"status":  "Success",
+	})
+}
+
+// DispensePrescription hands over medicines against a prescription, in full
+// or in part, taking stock FEFO from the pharmacy's batches
+func DispensePrescription(c *gin.Context) {
+	var input schemas.DispenseInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Dispense Prescription", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var pharmacist models.Employee
+	initializers.DB.First(&pharmacist, input.PharmacistID)
+	if pharmacist.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Pharmacist not found"))
+		return
+	}
+
+	var lines []pharmacy.DispenseLine
+	for _, item := range input.Items {
+		lines = append(lines, pharmacy.DispenseLine{
+			PrescriptionItemID: item.PrescriptionItemID,
+			MedicineID:         item.MedicineID,
+			Quantity:           item.Quantity,
+			SubstitutionReason: item.SubstitutionReason,
+		})
+	}
+
+	var dispense models.Dispense
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		dispense, err = pharmacy.DispensePrescription(tx, input.PrescriptionID, input.EntityID, pharmacist.ID, lines, input.Notes)
+		return err
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Prescription not found"))
+		return
+	}
+	if pharmacy.IsDispenseError(err) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Dispense rejected: "+err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to dispense prescription", "prescription_id", input.PrescriptionID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to dispense prescription"))
+		return
+	}
+
+	logger.Info("Prescription dispensed", "dispense_id", dispense.ID, "prescription_id", input.PrescriptionID,
+		"entity_id", input.EntityID, "items", len(dispense.Items))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    dispense,
+		"message": "Successfully dispensed prescription",
+		"status":  "Success",
+	})
+}
+
+// GetDispenses lists what was handed over against a prescription
+// (prescription_id)
+func GetDispenses(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	prescriptionID := c.Query("prescription_id")
+
+	if prescriptionID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "prescription_id is required"))
+		return
+	}
+
+	var dispenses []models.Dispense
+	if err := initializers.DB.Preload("Items.Medicine").Where("prescription_id = ?", prescriptionID).
+		Order("dispensed_at").Find(&dispenses).Error; err != nil {
+		logger.Error("Failed to fetch dispenses", "prescription_id", prescriptionID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch dispenses"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    dispenses,
+		"message": "Successfully fetched dispenses",
+		"status":  "Success",
+	})
+}
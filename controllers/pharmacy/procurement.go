@@ -0,0 +1,398 @@
+package pharmacyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/pharmacy"
+	"apps90-hms/schemas"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreatePurchaseOrder raises a draft purchase order with a supplier
+func CreatePurchaseOrder(c *gin.Context) {
+	var input schemas.PurchaseOrderInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Create Purchase Order", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var supplier models.Supplier
+	initializers.DB.Where("is_active = ?", true).First(&supplier, input.SupplierID)
+	if supplier.ID == 0 || supplier.EntityID != input.EntityID {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Supplier not found for this entity"))
+		return
+	}
+
+	order := models.PurchaseOrder{
+		EntityID:    input.EntityID,
+		SupplierID:  supplier.ID,
+		OrderedByID: input.OrderedByID,
+		Notes:       input.Notes,
+	}
+	if input.ExpectedAt != "" {
+		expected, err := time.Parse("2006-01-02", input.ExpectedAt)
+		if err != nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "expected_at must be YYYY-MM-DD"))
+			return
+		}
+		order.ExpectedAt = &expected
+	}
+
+	var lines []pharmacy.OrderLine
+	var medicineIDs []uint
+	for _, item := range input.Items {
+		lines = append(lines, pharmacy.OrderLine{MedicineID: item.MedicineID, Quantity: item.Quantity, UnitPrice: item.UnitPrice})
+		medicineIDs = append(medicineIDs, item.MedicineID)
+	}
+	var known int64
+	initializers.DB.Model(&models.Medicine{}).Where("id IN ?", medicineIDs).Count(&known)
+	if int(known) != len(uniqueIDs(medicineIDs)) {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine not found"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return pharmacy.CreatePurchaseOrder(tx, &order, lines)
+	})
+	if pharmacy.IsProcurementError(err) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Purchase order rejected: "+err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to create purchase order", "supplier_id", supplier.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create purchase order"))
+		return
+	}
+
+	logger.Info("Purchase order created", "purchase_order_id", order.ID, "supplier_id", supplier.ID, "total", order.TotalAmount)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    order.ID,
+		"message": "Successfully created purchase order",
+		"status":  "Success",
+	})
+}
+
+// ApprovePurchaseOrder approves a draft order so goods can be received
+// against it
+func ApprovePurchaseOrder(c *gin.Context) {
+	var input schemas.ApprovePurchaseOrderInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Approve Purchase Order", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := pharmacy.ApprovePurchaseOrder(tx, input.PurchaseOrderID, input.ApprovedByID)
+		return err
+	})
+	if !respondProcurementError(c, err, "Failed to approve purchase order") {
+		return
+	}
+
+	logger.Info("Purchase order approved", "purchase_order_id", input.PurchaseOrderID, "approved_by_id", input.ApprovedByID)
+	c.JSON(http.StatusOK, gin.H{"data": input.PurchaseOrderID, "message": "Purchase order approved successfully", "status": "Success"})
+}
+
+// CancelPurchaseOrder cancels a draft or approved order with no receipts
+func CancelPurchaseOrder(c *gin.Context) {
+	var input schemas.PurchaseOrderActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Cancel Purchase Order", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return pharmacy.CancelPurchaseOrder(tx, input.PurchaseOrderID)
+	})
+	if !respondProcurementError(c, err, "Failed to cancel purchase order") {
+		return
+	}
+
+	logger.Info("Purchase order cancelled", "purchase_order_id", input.PurchaseOrderID)
+	c.JSON(http.StatusOK, gin.H{"data": input.PurchaseOrderID, "message": "Purchase order cancelled successfully", "status": "Success"})
+}
+
+// GetPurchaseOrders lists one order (purchase_order_id) or the orders of an
+// entity (entity_id), optionally by supplier_id and status
+func GetPurchaseOrders(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("Supplier").Preload("Items.Medicine")
+	switch {
+	case c.Query("purchase_order_id") != "":
+		query = query.Where("id = ?", c.Query("purchase_order_id"))
+	case c.Query("entity_id") != "":
+		query = query.Where("entity_id = ?", c.Query("entity_id"))
+	default:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "purchase_order_id or entity_id is required"))
+		return
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var orders []models.PurchaseOrder
+	if err := query.Order("created_at DESC").Find(&orders).Error; err != nil {
+		logger.Error("Failed to fetch purchase orders", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch purchase orders"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    orders,
+		"message": "Successfully fetched purchase orders",
+		"status":  "Success",
+	})
+}
+
+// ReceiveGoods records a goods-received note against an approved purchase
+// order and puts the accepted batches into stock
+func ReceiveGoods(c *gin.Context) {
+	var input schemas.GoodsReceiptInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Receive Goods", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var lines []pharmacy.ReceiptLine
+	for _, item := range input.Items {
+		expiry, err := time.Parse("2006-01-02", item.ExpiryDate)
+		if err != nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "expiry_date must be YYYY-MM-DD"))
+			return
+		}
+		lines = append(lines, pharmacy.ReceiptLine{
+			PurchaseOrderItemID: item.PurchaseOrderItemID,
+			Batch: pharmacy.BatchDetails{
+				BatchNumber:   item.BatchNumber,
+				ExpiryDate:    expiry,
+				MRP:           item.MRP,
+				PurchasePrice: item.UnitPrice,
+			},
+			Quantity:         item.Quantity,
+			RejectedQuantity: item.RejectedQuantity,
+			RejectionReason:  item.RejectionReason,
+		})
+	}
+
+	receipt := models.GoodsReceipt{
+		PurchaseOrderID:   input.PurchaseOrderID,
+		SupplierInvoiceNo: input.SupplierInvoiceNo,
+		ReceivedByID:      input.ReceivedByID,
+		Notes:             input.Notes,
+	}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return pharmacy.ReceiveGoods(tx, &receipt, lines)
+	})
+	if !respondProcurementError(c, err, "Failed to record goods receipt") {
+		return
+	}
+
+	logger.Info("Goods received", "goods_receipt_id", receipt.ID, "purchase_order_id", receipt.PurchaseOrderID, "items", len(receipt.Items))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    receipt,
+		"message": "Successfully recorded goods receipt",
+		"status":  "Success",
+	})
+}
+
+// GetGoodsReceipts lists the receipts of a purchase order (purchase_order_id)
+func GetGoodsReceipts(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	orderID := c.Query("purchase_order_id")
+
+	if orderID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "purchase_order_id is required"))
+		return
+	}
+
+	var receipts []models.GoodsReceipt
+	if err := initializers.DB.Preload("Items").Where("purchase_order_id = ?", orderID).
+		Order("received_at").Find(&receipts).Error; err != nil {
+		logger.Error("Failed to fetch goods receipts", "purchase_order_id", orderID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch goods receipts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    receipts,
+		"message": "Successfully fetched goods receipts",
+		"status":  "Success",
+	})
+}
+
+// GetPurchaseOrderDiscrepancies compares ordered and received quantities and
+// prices for one order (purchase_order_id), or lists every order of an entity
+// (entity_id, optionally supplier_id) that has goods received and differs
+// from what was ordered
+func GetPurchaseOrderDiscrepancies(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("Supplier").Preload("Items.Medicine")
+	single := c.Query("purchase_order_id") != ""
+	switch {
+	case single:
+		query = query.Where("id = ?", c.Query("purchase_order_id"))
+	case c.Query("entity_id") != "":
+		query = query.Where("entity_id = ? AND status IN ?", c.Query("entity_id"),
+			[]string{models.PurchaseOrderPartiallyReceived, models.PurchaseOrderReceived})
+		if supplierID := c.Query("supplier_id"); supplierID != "" {
+			query = query.Where("supplier_id = ?", supplierID)
+		}
+	default:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "purchase_order_id or entity_id is required"))
+		return
+	}
+
+	var orders []models.PurchaseOrder
+	if err := query.Order("created_at").Find(&orders).Error; err != nil {
+		logger.Error("Failed to fetch purchase orders", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch purchase orders"))
+		return
+	}
+	if single && len(orders) == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Purchase order not found"))
+		return
+	}
+
+	var orderIDs []uint
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	var received []models.GoodsReceiptItem
+	if len(orderIDs) > 0 {
+		if err := initializers.DB.Joins("JOIN goods_receipt ON goods_receipt.id = goods_receipt_item.goods_receipt_id").
+			Where("goods_receipt.purchase_order_id IN ?", orderIDs).
+			Select("goods_receipt_item.*").Find(&received).Error; err != nil {
+			logger.Error("Failed to fetch goods receipt items", "error", err.Error())
+			c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch goods receipts"))
+			return
+		}
+	}
+	byItem := map[uint][]models.GoodsReceiptItem{}
+	for _, item := range received {
+		byItem[item.PurchaseOrderItemID] = append(byItem[item.PurchaseOrderItemID], item)
+	}
+
+	report := []schemas.PurchaseOrderDiscrepancy{}
+	for _, order := range orders {
+		discrepancy := schemas.PurchaseOrderDiscrepancy{
+			PurchaseOrderID: order.ID,
+			SupplierID:      order.SupplierID,
+			SupplierName:    order.Supplier.Name,
+			Status:          order.Status,
+			OrderedAmount:   order.TotalAmount,
+			Items:           []schemas.DiscrepancyItemRow{},
+		}
+		differs := false
+		for _, item := range order.Items {
+			row := compareItem(order, item, byItem[item.ID])
+			discrepancy.ReceivedAmount += float64(row.ReceivedQuantity) * row.InvoicedUnitPrice
+			if len(row.Issues) > 0 {
+				differs = true
+			}
+			discrepancy.Items = append(discrepancy.Items, row)
+		}
+		discrepancy.ReceivedAmount = math.Round(discrepancy.ReceivedAmount*100) / 100
+		if single || differs {
+			report = append(report, discrepancy)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    report,
+		"message": "Successfully fetched purchase order discrepancies",
+		"status":  "Success",
+	})
+}
+
+// compareItem sets an ordered item against what was received for it
+func compareItem(order models.PurchaseOrder, item models.PurchaseOrderItem, received []models.GoodsReceiptItem) schemas.DiscrepancyItemRow {
+	row := schemas.DiscrepancyItemRow{
+		PurchaseOrderItemID: item.ID,
+		MedicineID:          item.MedicineID,
+		MedicineName:        item.Medicine.Name,
+		OrderedQuantity:     item.Quantity,
+		OrderedUnitPrice:    item.UnitPrice,
+		Issues:              []string{},
+	}
+
+	invoiced := 0.0
+	for _, receipt := range received {
+		row.ReceivedQuantity += receipt.Quantity
+		row.RejectedQuantity += receipt.RejectedQuantity
+		invoiced += float64(receipt.Quantity) * receipt.UnitPrice
+	}
+	if row.ReceivedQuantity > 0 {
+		row.InvoicedUnitPrice = math.Round(invoiced/float64(row.ReceivedQuantity)*100) / 100
+		row.PriceVariance = math.Round((invoiced-float64(row.ReceivedQuantity)*item.UnitPrice)*100) / 100
+	}
+	row.ShortQuantity = max(item.Quantity-row.ReceivedQuantity, 0)
+	row.ExcessQuantity = max(row.ReceivedQuantity-item.Quantity, 0)
+
+	// Nothing is short on an order no goods have arrived for yet
+	if row.ShortQuantity > 0 && (order.Status == models.PurchaseOrderPartiallyReceived || order.Status == models.PurchaseOrderReceived) {
+		row.Issues = append(row.Issues, "Short")
+	}
+	if row.ExcessQuantity > 0 {
+		row.Issues = append(row.Issues, "Excess")
+	}
+	if row.RejectedQuantity > 0 {
+		row.Issues = append(row.Issues, "Rejected")
+	}
+	if row.PriceVariance != 0 {
+		row.Issues = append(row.Issues, "PriceVariance")
+	}
+	return row
+}
+
+// respondProcurementError reports err on c and returns whether the request
+// may go on
+func respondProcurementError(c *gin.Context, err error, failure string) bool {
+	switch {
+	case err == nil:
+		return true
+	case err == gorm.ErrRecordNotFound:
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Purchase order not found"))
+	case pharmacy.IsProcurementError(err):
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, err.Error()))
+	default:
+		loggers.InitializeLogger().Error(failure, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, failure))
+	}
+	return false
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := map[uint]bool{}
+	var unique []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
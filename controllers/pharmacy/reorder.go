@@ -0,0 +1,120 @@
+package pharmacyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/pharmacy"
+	"apps90-hms/schemas"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SetReorderRule sets the reorder level, quantity and preferred supplier of a
+// medicine at an entity
+func SetReorderRule(c *gin.Context) {
+	var input schemas.ReorderRuleInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Set Reorder Rule", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var medicine models.Medicine
+	initializers.DB.First(&medicine, input.MedicineID)
+	if medicine.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine not found"))
+		return
+	}
+	if input.PreferredSupplierID != nil {
+		var supplier models.Supplier
+		initializers.DB.Where("id = ? AND entity_id = ? AND is_active = ?", *input.PreferredSupplierID, input.EntityID, true).Find(&supplier)
+		if supplier.ID == 0 {
+			c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Supplier not found at this entity"))
+			return
+		}
+	}
+
+	var level models.StockLevel
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		level, err = pharmacy.SetReorderRule(tx, input.EntityID, input.MedicineID, input.ReorderLevel, input.ReorderQuantity, input.PreferredSupplierID)
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to set reorder rule", "entity_id", input.EntityID, "medicine_id", input.MedicineID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to set reorder rule"))
+		return
+	}
+
+	logger.Info("Reorder rule set", "entity_id", input.EntityID, "medicine_id", input.MedicineID, "reorder_level", input.ReorderLevel)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    level,
+		"message": "Successfully set reorder rule",
+		"status":  "Success",
+	})
+}
+
+// GetReorderSuggestions lists the medicines at an entity that have fallen to
+// their reorder threshold, without raising alerts or orders
+func GetReorderSuggestions(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	entityID, err := strconv.ParseUint(c.Query("entity_id"), 10, 64)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	suggestions, err := pharmacy.Suggestions(initializers.DB, uint(entityID), pharmacy.ReorderSettingsFromEnv(), time.Now())
+	if err != nil {
+		logger.Error("Failed to compute reorder suggestions", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to compute reorder suggestions"))
+		return
+	}
+	if suggestions == nil {
+		suggestions = []pharmacy.ReorderSuggestion{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    suggestions,
+		"message": "Successfully fetched reorder suggestions",
+		"status":  "Success",
+	})
+}
+
+// RunReorderCheck runs the scheduled reorder check for one entity now
+func RunReorderCheck(c *gin.Context) {
+	var input schemas.ReorderRunInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Run Reorder Check", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	result, err := pharmacy.CheckReorders(initializers.DB, input.EntityID, pharmacy.ReorderSettingsFromEnv(), time.Now())
+	if err != nil {
+		logger.Error("Reorder check failed", "entity_id", input.EntityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Reorder check failed"))
+		return
+	}
+	if result.Suggestions == nil {
+		result.Suggestions = []pharmacy.ReorderSuggestion{}
+	}
+
+	logger.Info("Reorder check run", "entity_id", input.EntityID, "alerts", result.Alerts, "drafted_orders", len(result.DraftedOrders))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    result,
+		"message": "Successfully ran reorder check",
+		"status":  "Success",
+	})
+}
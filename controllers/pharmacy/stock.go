@@ -0,0 +1,241 @@
+package pharmacyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/pharmacy"
+	"apps90-hms/schemas"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RecordStockMovement posts a manual receipt, return, adjustment or expiry
+// write-off to the stock ledger. Dispensing and transfers are posted by their
+// own workflows.
+func RecordStockMovement(c *gin.Context) {
+	var input schemas.StockMovementInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Record Stock Movement", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var medicine models.Medicine
+	initializers.DB.First(&medicine, input.MedicineID)
+	if medicine.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine not found"))
+		return
+	}
+
+	var entity models.Entity
+	initializers.DB.First(&entity, input.EntityID)
+	if entity.ID == 0 {
+		logger.Warn("Entity not found", "entity_id", input.EntityID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Entity not found"))
+		return
+	}
+	if medicine.EntityID != entity.ID {
+		logger.Warn("Stock movement for another entity's medicine", "medicine_id", medicine.ID, "entity_id", entity.ID)
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Medicine is not in this entity's catalog"))
+		return
+	}
+
+	quantity := input.Quantity
+	if direction, _ := pharmacy.Direction(input.Type); direction != 0 {
+		if quantity < 0 {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Quantity must be positive; only adjustments take a sign"))
+			return
+		}
+		quantity *= direction
+	}
+
+	var batchDetails *pharmacy.BatchDetails
+	if input.Type == models.StockMovementReceipt && input.BatchID == nil {
+		if input.Batch == nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Receipts must describe the batch received"))
+			return
+		}
+		expiry, err := time.Parse("2006-01-02", input.Batch.ExpiryDate)
+		if err != nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "expiry_date must be YYYY-MM-DD"))
+			return
+		}
+		if (models.MedicineBatch{ExpiryDate: expiry}).Expired(time.Now()) {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Expired batches cannot be received"))
+			return
+		}
+		batchDetails = &pharmacy.BatchDetails{
+			BatchNumber:   input.Batch.BatchNumber,
+			ExpiryDate:    expiry,
+			MRP:           input.Batch.MRP,
+			PurchasePrice: input.Batch.PurchasePrice,
+		}
+	}
+
+	movement := models.StockMovement{
+		EntityID:      input.EntityID,
+		MedicineID:    medicine.ID,
+		BatchID:       input.BatchID,
+		Type:          input.Type,
+		Quantity:      quantity,
+		ReasonCode:    input.ReasonCode,
+		Notes:         input.Notes,
+		PerformedByID: input.PerformedByID,
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if batchDetails != nil {
+			batch, err := pharmacy.FindOrCreateBatch(tx, movement.EntityID, movement.MedicineID, *batchDetails)
+			if err != nil {
+				return err
+			}
+			movement.BatchID = &batch.ID
+		}
+		return pharmacy.Post(tx, &movement)
+	})
+	if pharmacy.IsStockError(err) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Stock movement rejected: "+err.Error()))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to record stock movement", "medicine_id", medicine.ID, "entity_id", input.EntityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to record stock movement"))
+		return
+	}
+
+	logger.Info("Stock movement recorded", "stock_movement_id", movement.ID, "medicine_id", medicine.ID,
+		"entity_id", movement.EntityID, "type", movement.Type, "quantity", movement.Quantity, "balance", movement.BalanceAfter)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    movement,
+		"message": "Successfully recorded stock movement",
+		"status":  "Success",
+	})
+}
+
+// GetStockLevels lists the current stock at an entity (entity_id), optionally
+// for one medicine (medicine_id)
+func GetStockLevels(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	entityID := c.Query("entity_id")
+
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	query := initializers.DB.Preload("Medicine").Where("entity_id = ?", entityID)
+	if medicineID := c.Query("medicine_id"); medicineID != "" {
+		query = query.Where("medicine_id = ?", medicineID)
+	}
+
+	var levels []models.StockLevel
+	if err := query.Order("medicine_id").Find(&levels).Error; err != nil {
+		logger.Error("Failed to fetch stock levels", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch stock levels"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    levels,
+		"message": "Successfully fetched stock levels",
+		"status":  "Success",
+	})
+}
+
+// GetStockCard returns the ledger of a medicine at an entity (entity_id,
+// medicine_id) with running balances, optionally between from and to
+// (YYYY-MM-DD, inclusive)
+func GetStockCard(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	entityID, err := strconv.ParseUint(c.Query("entity_id"), 10, 64)
+	medicineID := c.Query("medicine_id")
+
+	if err != nil || medicineID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id and medicine_id are required"))
+		return
+	}
+
+	var medicine models.Medicine
+	initializers.DB.First(&medicine, medicineID)
+	if medicine.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine not found"))
+		return
+	}
+
+	card := schemas.StockCardResponse{EntityID: uint(entityID), MedicineID: medicine.ID, MedicineName: medicine.Name, Movements: []schemas.StockCardMovementRow{}}
+	query := initializers.DB.Where("entity_id = ? AND medicine_id = ?", entityID, medicine.ID)
+
+	if from := c.Query("from"); from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "from must be YYYY-MM-DD"))
+			return
+		}
+		card.From = &day
+		query = query.Where("occurred_at >= ?", day)
+
+		// The balance before the period is the balance after the last earlier movement
+		var previous models.StockMovement
+		initializers.DB.Where("entity_id = ? AND medicine_id = ? AND occurred_at < ?", entityID, medicine.ID, day).
+			Order("occurred_at DESC, id DESC").Limit(1).Find(&previous)
+		card.OpeningBalance = previous.BalanceAfter
+	}
+	if to := c.Query("to"); to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "to must be YYYY-MM-DD"))
+			return
+		}
+		card.To = &day
+		query = query.Where("occurred_at < ?", day.AddDate(0, 0, 1))
+	}
+
+	var movements []models.StockMovement
+	if err := query.Preload("Batch").Order("occurred_at, id").Find(&movements).Error; err != nil {
+		logger.Error("Failed to fetch stock card", "entity_id", entityID, "medicine_id", medicine.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch stock card"))
+		return
+	}
+
+	card.ClosingBalance = card.OpeningBalance
+	for _, movement := range movements {
+		row := schemas.StockCardMovementRow{
+			ID:            movement.ID,
+			OccurredAt:    movement.OccurredAt,
+			Type:          movement.Type,
+			ReasonCode:    movement.ReasonCode,
+			ReferenceType: movement.ReferenceType,
+			ReferenceID:   movement.ReferenceID,
+			BatchID:       movement.BatchID,
+			Balance:       movement.BalanceAfter,
+			Notes:         movement.Notes,
+			PerformedByID: movement.PerformedByID,
+		}
+		if movement.Batch != nil {
+			row.BatchNumber = movement.Batch.BatchNumber
+		}
+		if movement.Quantity > 0 {
+			row.In = movement.Quantity
+			card.TotalIn += movement.Quantity
+		} else {
+			row.Out = -movement.Quantity
+			card.TotalOut -= movement.Quantity
+		}
+		card.ClosingBalance = movement.BalanceAfter
+		card.Movements = append(card.Movements, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    card,
+		"message": "Successfully fetched stock card",
+		"status":  "Success",
+	})
+}
@@ -0,0 +1,152 @@
+package pharmacyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddSupplier registers a supplier for an entity
+func AddSupplier(c *gin.Context) {
+	var input schemas.SupplierInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add Supplier", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var existing models.Supplier
+	initializers.DB.Where("entity_id = ? AND name = ?", input.EntityID, input.Name).Find(&existing)
+	if existing.ID != 0 {
+		c.Error(models.WrapError(http.StatusConflict, errors.ErrObjectExists, "Supplier already exists"))
+		return
+	}
+
+	supplier := models.Supplier{
+		EntityID:         input.EntityID,
+		Name:             input.Name,
+		ContactPerson:    input.ContactPerson,
+		Phone:            input.Phone,
+		Email:            input.Email,
+		Address:          input.Address,
+		TaxNumber:        input.TaxNumber,
+		DrugLicense:      input.DrugLicense,
+		PaymentTermsDays: input.PaymentTermsDays,
+	}
+	if err := initializers.DB.Create(&supplier).Error; err != nil {
+		logger.Error("Failed to create supplier", "entity_id", input.EntityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create supplier"))
+		return
+	}
+
+	logger.Info("Supplier created", "supplier_id", supplier.ID, "entity_id", supplier.EntityID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    supplier.ID,
+		"message": "Successfully added supplier",
+		"status":  "Success",
+	})
+}
+
+// UpdateSupplier edits a supplier's details or deactivates it
+func UpdateSupplier(c *gin.Context) {
+	var input schemas.UpdateSupplierInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Supplier", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var supplier models.Supplier
+	initializers.DB.First(&supplier, input.SupplierID)
+	if supplier.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Supplier not found"))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.Name != nil {
+		var existing models.Supplier
+		initializers.DB.Where("entity_id = ? AND name = ? AND id <> ?", supplier.EntityID, *input.Name, supplier.ID).Find(&existing)
+		if existing.ID != 0 {
+			c.Error(models.WrapError(http.StatusConflict, errors.ErrObjectExists, "Another supplier has this name"))
+			return
+		}
+		updates["name"] = *input.Name
+	}
+	if input.ContactPerson != nil {
+		updates["contact_person"] = *input.ContactPerson
+	}
+	if input.Phone != nil {
+		updates["phone"] = *input.Phone
+	}
+	if input.Email != nil {
+		updates["email"] = *input.Email
+	}
+	if input.Address != nil {
+		updates["address"] = *input.Address
+	}
+	if input.TaxNumber != nil {
+		updates["tax_number"] = *input.TaxNumber
+	}
+	if input.DrugLicense != nil {
+		updates["drug_license"] = *input.DrugLicense
+	}
+	if input.PaymentTermsDays != nil {
+		updates["payment_terms_days"] = *input.PaymentTermsDays
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+	}
+	if len(updates) == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Nothing to update"))
+		return
+	}
+
+	if err := initializers.DB.Model(&supplier).Updates(updates).Error; err != nil {
+		logger.Error("Failed to update supplier", "supplier_id", supplier.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update supplier"))
+		return
+	}
+
+	logger.Info("Supplier updated", "supplier_id", supplier.ID)
+	c.JSON(http.StatusOK, gin.H{"data": supplier.ID, "message": "Supplier updated successfully", "status": "Success"})
+}
+
+// GetSuppliers lists the suppliers of an entity (entity_id). Inactive ones are
+// included with include_inactive=true.
+func GetSuppliers(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	entityID := c.Query("entity_id")
+
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	query := initializers.DB.Where("entity_id = ?", entityID)
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var suppliers []models.Supplier
+	if err := query.Order("name").Find(&suppliers).Error; err != nil {
+		logger.Error("Failed to fetch suppliers", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch suppliers"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    suppliers,
+		"message": "Successfully fetched suppliers",
+		"status":  "Success",
+	})
+}
@@ -0,0 +1,317 @@
+package pharmacyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/pharmacy"
+	"apps90-hms/schemas"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RequestStockTransfer asks another entity for medicines
+func RequestStockTransfer(c *gin.Context) {
+	var input schemas.StockTransferInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Request Stock Transfer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var entities int64
+	initializers.DB.Model(&models.Entity{}).Where("id IN ?", []uint{input.FromEntityID, input.ToEntityID}).Count(&entities)
+	if entities != 2 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Entity not found"))
+		return
+	}
+
+	var lines []pharmacy.TransferLine
+	var medicineIDs []uint
+	for _, item := range input.Items {
+		lines = append(lines, pharmacy.TransferLine{MedicineID: item.MedicineID, Quantity: item.Quantity})
+		medicineIDs = append(medicineIDs, item.MedicineID)
+	}
+	var known int64
+	initializers.DB.Model(&models.Medicine{}).Where("id IN ? AND entity_id = ?", medicineIDs, input.FromEntityID).Count(&known)
+	if int(known) != len(uniqueIDs(medicineIDs)) {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Medicine not found in the sending entity's catalog"))
+		return
+	}
+
+	transfer := models.StockTransfer{
+		FromEntityID:  input.FromEntityID,
+		ToEntityID:    input.ToEntityID,
+		RequestedByID: input.RequestedByID,
+		Notes:         input.Notes,
+	}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return pharmacy.RequestTransfer(tx, &transfer, lines)
+	})
+	if !respondTransferError(c, err, "Failed to request stock transfer") {
+		return
+	}
+
+	logger.Info("Stock transfer requested", "stock_transfer_id", transfer.ID, "from_entity_id", transfer.FromEntityID, "to_entity_id", transfer.ToEntityID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    transfer.ID,
+		"message": "Successfully requested stock transfer",
+		"status":  "Success",
+	})
+}
+
+// ApproveStockTransfer approves a request on behalf of the sending entity
+func ApproveStockTransfer(c *gin.Context) {
+	var input schemas.ApproveStockTransferInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Approve Stock Transfer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		_, err := pharmacy.ApproveTransfer(tx, input.StockTransferID, input.ApprovedByID)
+		return err
+	})
+	if !respondTransferError(c, err, "Failed to approve stock transfer") {
+		return
+	}
+
+	logger.Info("Stock transfer approved", "stock_transfer_id", input.StockTransferID, "approved_by_id", input.ApprovedByID)
+	c.JSON(http.StatusOK, gin.H{"data": input.StockTransferID, "message": "Stock transfer approved successfully", "status": "Success"})
+}
+
+// RejectStockTransfer turns down a request that has not been dispatched
+func RejectStockTransfer(c *gin.Context) {
+	var input schemas.RejectStockTransferInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Reject Stock Transfer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return pharmacy.RejectTransfer(tx, input.StockTransferID, input.Reason)
+	})
+	if !respondTransferError(c, err, "Failed to reject stock transfer") {
+		return
+	}
+
+	logger.Info("Stock transfer rejected", "stock_transfer_id", input.StockTransferID)
+	c.JSON(http.StatusOK, gin.H{"data": input.StockTransferID, "message": "Stock transfer rejected successfully", "status": "Success"})
+}
+
+// CancelStockTransfer withdraws a request that has not been dispatched
+func CancelStockTransfer(c *gin.Context) {
+	var input schemas.StockTransferActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Cancel Stock Transfer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return pharmacy.CancelTransfer(tx, input.StockTransferID)
+	})
+	if !respondTransferError(c, err, "Failed to cancel stock transfer") {
+		return
+	}
+
+	logger.Info("Stock transfer cancelled", "stock_transfer_id", input.StockTransferID)
+	c.JSON(http.StatusOK, gin.H{"data": input.StockTransferID, "message": "Stock transfer cancelled successfully", "status": "Success"})
+}
+
+// DispatchStockTransfer takes an approved transfer out of the sending
+// entity's stock; it stays in transit until received
+func DispatchStockTransfer(c *gin.Context) {
+	var input schemas.DispatchStockTransferInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Dispatch Stock Transfer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	quantities := map[uint]int{}
+	for _, item := range input.Items {
+		quantities[item.StockTransferItemID] = item.Quantity
+	}
+
+	var transfer models.StockTransfer
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = pharmacy.DispatchTransfer(tx, input.StockTransferID, input.DispatchedByID, quantities)
+		return err
+	})
+	if !respondTransferError(c, err, "Failed to dispatch stock transfer") {
+		return
+	}
+
+	logger.Info("Stock transfer dispatched", "stock_transfer_id", transfer.ID, "from_entity_id", transfer.FromEntityID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    transfer,
+		"message": "Successfully dispatched stock transfer",
+		"status":  "Success",
+	})
+}
+
+// ReceiveStockTransfer takes a dispatched transfer into the receiving
+// entity's stock, writing off any shortages
+func ReceiveStockTransfer(c *gin.Context) {
+	var input schemas.ReceiveStockTransferInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Receive Stock Transfer", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var shortages []pharmacy.ShortLine
+	for _, shortage := range input.Shortages {
+		shortages = append(shortages, pharmacy.ShortLine{
+			StockTransferBatchID: shortage.StockTransferBatchID,
+			Quantity:             shortage.Quantity,
+			Reason:               shortage.Reason,
+		})
+	}
+
+	var transfer models.StockTransfer
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = pharmacy.ReceiveTransfer(tx, input.StockTransferID, input.ReceivedByID, shortages)
+		return err
+	})
+	if !respondTransferError(c, err, "Failed to receive stock transfer") {
+		return
+	}
+
+	logger.Info("Stock transfer received", "stock_transfer_id", transfer.ID, "to_entity_id", transfer.ToEntityID, "shortages", len(shortages))
+	c.JSON(http.StatusOK, gin.H{
+		"data":    transfer,
+		"message": "Successfully received stock transfer",
+		"status":  "Success",
+	})
+}
+
+// GetStockTransfers lists one transfer (stock_transfer_id) or the transfers
+// of an entity (entity_id), optionally by direction (In or Out) and status
+func GetStockTransfers(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("FromEntity").Preload("ToEntity").Preload("Items.Medicine").Preload("Items.DestinationMedicine").Preload("Items.Batches")
+	switch {
+	case c.Query("stock_transfer_id") != "":
+		query = query.Where("id = ?", c.Query("stock_transfer_id"))
+	case c.Query("entity_id") != "":
+		entityID := c.Query("entity_id")
+		switch c.Query("direction") {
+		case "In":
+			query = query.Where("to_entity_id = ?", entityID)
+		case "Out":
+			query = query.Where("from_entity_id = ?", entityID)
+		default:
+			query = query.Where("from_entity_id = ? OR to_entity_id = ?", entityID, entityID)
+		}
+	default:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "stock_transfer_id or entity_id is required"))
+		return
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var transfers []models.StockTransfer
+	if err := query.Order("created_at DESC").Find(&transfers).Error; err != nil {
+		logger.Error("Failed to fetch stock transfers", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch stock transfers"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    transfers,
+		"message": "Successfully fetched stock transfers",
+		"status":  "Success",
+	})
+}
+
+// GetInTransitStock lists stock dispatched to or from an entity that has not
+// been received yet
+func GetInTransitStock(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	entityID, err := strconv.ParseUint(c.Query("entity_id"), 10, 64)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	var transfers []models.StockTransfer
+	if err := initializers.DB.Preload("Items.Medicine").Preload("Items.DestinationMedicine").Preload("Items.Batches").
+		Where("status = ? AND (from_entity_id = ? OR to_entity_id = ?)", models.StockTransferDispatched, entityID, entityID).
+		Order("dispatched_at").Find(&transfers).Error; err != nil {
+		logger.Error("Failed to fetch in-transit stock", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch in-transit stock"))
+		return
+	}
+
+	rows := []schemas.InTransitRow{}
+	for _, transfer := range transfers {
+		direction := "In"
+		if transfer.FromEntityID == uint(entityID) {
+			direction = "Out"
+		}
+		for _, item := range transfer.Items {
+			for _, batch := range item.Batches {
+				rows = append(rows, schemas.InTransitRow{
+					StockTransferID: transfer.ID,
+					Direction:       direction,
+					FromEntityID:    transfer.FromEntityID,
+					ToEntityID:      transfer.ToEntityID,
+					MedicineID:      item.MedicineID,
+					MedicineName:    item.Medicine.Name,
+					BatchNumber:     batch.BatchNumber,
+					ExpiryDate:      batch.ExpiryDate,
+					Quantity:        batch.Quantity,
+					Value:           math.Round(float64(batch.Quantity)*batch.PurchasePrice*100) / 100,
+					DispatchedAt:    transfer.DispatchedAt,
+				})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    rows,
+		"message": "Successfully fetched in-transit stock",
+		"status":  "Success",
+	})
+}
+
+func respondTransferError(c *gin.Context, err error, failure string) bool {
+	switch {
+	case err == nil:
+		return true
+	case err == gorm.ErrRecordNotFound:
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Stock transfer not found"))
+	case pharmacy.IsTransferError(err):
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, err.Error()))
+	default:
+		loggers.InitializeLogger().Error(failure, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, failure))
+	}
+	return false
+}
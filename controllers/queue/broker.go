@@ -0,0 +1,54 @@
+package queueController
+
+import (
+	"apps90-hms/schemas"
+	"sync"
+)
+
+// broker fans queue snapshots out to the SSE streams watching a doctor's queue.
+// Subscriptions live in process memory, so every display must be connected to
+// the same instance that handles the queue actions.
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan schemas.QueueSnapshot]struct{}
+}
+
+var queueBroker = &broker{subscribers: map[uint]map[chan schemas.QueueSnapshot]struct{}{}}
+
+func (b *broker) subscribe(doctorID uint) chan schemas.QueueSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan schemas.QueueSnapshot, 1)
+	if b.subscribers[doctorID] == nil {
+		b.subscribers[doctorID] = map[chan schemas.QueueSnapshot]struct{}{}
+	}
+	b.subscribers[doctorID][ch] = struct{}{}
+	return ch
+}
+
+func (b *broker) unsubscribe(doctorID uint, ch chan schemas.QueueSnapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscribers[doctorID], ch)
+	if len(b.subscribers[doctorID]) == 0 {
+		delete(b.subscribers, doctorID)
+	}
+}
+
+// publish delivers the snapshot to every subscriber without blocking. A slow
+// subscriber only ever needs the latest state, so a pending stale snapshot is
+// replaced rather than queued.
+func (b *broker) publish(doctorID uint, snapshot schemas.QueueSnapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[doctorID] {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snapshot
+	}
+}
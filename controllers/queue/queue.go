@@ -0,0 +1,473 @@
+package queueController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Used for wait estimates until the doctor has completed a consultation today
+const defaultConsultMinutes = 10
+
+// queueDay returns the calendar day a queue belongs to, normalised to midnight UTC
+func queueDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// lockDoctorQueue serialises token changes for a doctor for the rest of the transaction
+func lockDoctorQueue(tx *gorm.DB, doctorID uint) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", doctorID).Error
+}
+
+func CheckIn(c *gin.Context) {
+	var input schemas.CheckInInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Queue Check In", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var patient models.Patient
+	initializers.DB.First(&patient, input.PatientID)
+	if patient.ID == 0 {
+		logger.Warn("Patient not found", "patient_id", input.PatientID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Patient not found"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+
+	var entity models.Entity
+	initializers.DB.First(&entity, input.EntityID)
+	if entity.ID == 0 {
+		logger.Warn("Entity not found", "entity_id", input.EntityID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Entity not found"))
+		return
+	}
+	if doctor.EntityID != entity.ID {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Doctor does not work at this entity"))
+		return
+	}
+
+	now := time.Now()
+	day := queueDay(now)
+
+	// Check in against an appointment if one is given
+	if input.AppointmentID != nil {
+		var appointment models.Appointment
+		initializers.DB.First(&appointment, *input.AppointmentID)
+		if appointment.ID == 0 {
+			logger.Warn("Appointment not found", "appointment_id", *input.AppointmentID)
+			c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Appointment not found"))
+			return
+		}
+		if appointment.PatientID != input.PatientID || appointment.EmployeeID != input.DoctorID {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Appointment does not belong to this patient and doctor"))
+			return
+		}
+		if appointment.Status == models.AppointmentStatusCancelled {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Appointment has been cancelled"))
+			return
+		}
+		if appointment.EntityID != entity.ID {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Appointment is at another entity"))
+			return
+		}
+		if !queueDay(appointment.AppointmentTime.In(now.Location())).Equal(day) {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Appointment is not today"))
+			return
+		}
+	}
+	token := models.QueueToken{
+		QueueDate:     day,
+		DoctorID:      input.DoctorID,
+		EntityID:      input.EntityID,
+		PatientID:     input.PatientID,
+		AppointmentID: input.AppointmentID,
+		Status:        models.TokenStatusWaiting,
+		CheckedInAt:   now,
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockDoctorQueue(tx, input.DoctorID); err != nil {
+			return err
+		}
+
+		// A patient holds at most one open token per doctor per day
+		var existing models.QueueToken
+		tx.Where("doctor_id = ? AND queue_date = ? AND patient_id = ? AND status IN ?",
+			input.DoctorID, day, input.PatientID, []string{models.TokenStatusWaiting, models.TokenStatusCalled}).
+			Limit(1).Find(&existing)
+		if existing.ID != 0 {
+			token = existing
+			return errors.ErrObjectExists
+		}
+
+		var lastNumber int
+		if err := tx.Model(&models.QueueToken{}).
+			Where("doctor_id = ? AND queue_date = ?", input.DoctorID, day).
+			Select("COALESCE(MAX(token_number), 0)").Scan(&lastNumber).Error; err != nil {
+			return err
+		}
+		token.TokenNumber = lastNumber + 1
+
+		return tx.Create(&token).Error
+	})
+	if err == errors.ErrObjectExists {
+		logger.Warn("Patient already in queue", "patient_id", input.PatientID, "token_number", token.TokenNumber)
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrObjectExists, "Patient already holds token "+strconv.Itoa(token.TokenNumber)))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to issue queue token", "doctor_id", input.DoctorID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to issue queue token"))
+		return
+	}
+
+	logger.Info("Queue token issued", "doctor_id", input.DoctorID, "token_number", token.TokenNumber, "token_id", token.ID)
+	publishSnapshot(input.DoctorID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    gin.H{"id": token.ID, "token_number": token.TokenNumber},
+		"message": "Successfully issued queue token",
+		"status":  "Success",
+	})
+}
+
+// CallNext completes the token currently being seen by the doctor and calls
+// the lowest-numbered waiting token.
+func CallNext(c *gin.Context) {
+	var input schemas.CallNextInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Queue Call Next", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	day := queueDay(time.Now())
+	var next models.QueueToken
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockDoctorQueue(tx, input.DoctorID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.QueueToken{}).
+			Where("doctor_id = ? AND queue_date = ? AND status = ?", input.DoctorID, day, models.TokenStatusCalled).
+			Updates(map[string]interface{}{"status": models.TokenStatusCompleted, "completed_at": now}).Error; err != nil {
+			return err
+		}
+
+		tx.Where("doctor_id = ? AND queue_date = ? AND status = ?", input.DoctorID, day, models.TokenStatusWaiting).
+			Order("token_number").Limit(1).Find(&next)
+		if next.ID == 0 {
+			return nil
+		}
+
+		next.Status = models.TokenStatusCalled
+		next.CalledAt = &now
+		return tx.Select("status", "called_at").Updates(&next).Error
+	})
+	if err != nil {
+		logger.Error("Failed to call next token", "doctor_id", input.DoctorID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to call next token"))
+		return
+	}
+
+	publishSnapshot(input.DoctorID)
+
+	if next.ID == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": nil, "message": "No patients waiting", "status": "Success"})
+		return
+	}
+
+	logger.Info("Queue token called", "doctor_id", input.DoctorID, "token_number", next.TokenNumber)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    gin.H{"id": next.ID, "token_number": next.TokenNumber},
+		"message": "Successfully called next token",
+		"status":  "Success",
+	})
+}
+
+// SkipToken sets aside a waiting or called token, e.g. when the patient does
+// not answer the call. Skipped tokens can be recalled later.
+func SkipToken(c *gin.Context) {
+	updateTokenStatus(c, "Skip", []string{models.TokenStatusWaiting, models.TokenStatusCalled}, models.TokenStatusSkipped)
+}
+
+// RecallToken calls a skipped token again, or re-announces the called token.
+func RecallToken(c *gin.Context) {
+	updateTokenStatus(c, "Recall", []string{models.TokenStatusSkipped, models.TokenStatusCalled}, models.TokenStatusCalled)
+}
+
+func updateTokenStatus(c *gin.Context, action string, allowed []string, status string) {
+	var input schemas.QueueTokenActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Queue "+action, "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var token models.QueueToken
+	initializers.DB.First(&token, input.TokenID)
+	if token.ID == 0 {
+		logger.Warn("Queue token not found", "token_id", input.TokenID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Queue token not found"))
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockDoctorQueue(tx, token.DoctorID); err != nil {
+			return err
+		}
+		if err := tx.First(&token, token.ID).Error; err != nil {
+			return err
+		}
+
+		isAllowed := false
+		for _, s := range allowed {
+			if token.Status == s {
+				isAllowed = true
+			}
+		}
+		if !isAllowed {
+			return errors.ErrBadRequest
+		}
+
+		// Only one token can be with the doctor at a time
+		if status == models.TokenStatusCalled && token.Status != models.TokenStatusCalled {
+			var current int64
+			tx.Model(&models.QueueToken{}).
+				Where("doctor_id = ? AND queue_date = ? AND status = ?", token.DoctorID, token.QueueDate, models.TokenStatusCalled).
+				Count(&current)
+			if current > 0 {
+				return errors.ErrObjectExists
+			}
+		}
+
+		updates := map[string]interface{}{"status": status}
+		if status == models.TokenStatusCalled {
+			updates["called_at"] = time.Now()
+		}
+		return tx.Model(&token).Updates(updates).Error
+	})
+	switch err {
+	case nil:
+	case errors.ErrBadRequest:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, action+" is not allowed for a "+token.Status+" token"))
+		return
+	case errors.ErrObjectExists:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Another token is with the doctor; call next or skip it first"))
+		return
+	default:
+		logger.Error("Failed to update queue token", "token_id", token.ID, "action", action, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update queue token"))
+		return
+	}
+
+	logger.Info("Queue token updated", "token_id", token.ID, "action", action)
+	publishSnapshot(token.DoctorID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    gin.H{"id": token.ID, "token_number": token.TokenNumber, "status": status},
+		"message": "Successfully updated queue token",
+		"status":  "Success",
+	})
+}
+
+// GetQueue returns a doctor's queue for a day (today by default) with
+// estimated wait times for waiting tokens.
+func GetQueue(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	doctorID, err := strconv.ParseUint(c.Query("doctor_id"), 10, 32)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Valid doctor_id is required"))
+		return
+	}
+
+	day := queueDay(time.Now())
+	if date := c.Query("date"); date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Invalid date format, expected YYYY-MM-DD"))
+			return
+		}
+		day = parsed
+	}
+
+	snapshot, err := buildSnapshot(uint(doctorID), day)
+	if err != nil {
+		logger.Error("Failed to load queue", "doctor_id", doctorID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to load queue"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    snapshot,
+		"message": "Successfully fetched queue",
+		"status":  "Success",
+	})
+}
+
+// StreamQueue pushes today's queue for a doctor as Server-Sent Events. The
+// current state is sent on connect and again after every queue change.
+func StreamQueue(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	doctorID64, err := strconv.ParseUint(c.Query("doctor_id"), 10, 32)
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Valid doctor_id is required"))
+		return
+	}
+	doctorID := uint(doctorID64)
+
+	snapshot, err := buildSnapshot(doctorID, queueDay(time.Now()))
+	if err != nil {
+		logger.Error("Failed to load queue", "doctor_id", doctorID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to load queue"))
+		return
+	}
+
+	updates := queueBroker.subscribe(doctorID)
+	defer queueBroker.unsubscribe(doctorID, updates)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("queue", snapshot)
+	c.Writer.Flush()
+
+	// Keep idle connections alive through proxies
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case snapshot := <-updates:
+			c.SSEvent("queue", snapshot)
+			return true
+		case <-keepAlive.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// publishSnapshot sends the doctor's current queue to any live streams
+func publishSnapshot(doctorID uint) {
+	snapshot, err := buildSnapshot(doctorID, queueDay(time.Now()))
+	if err != nil {
+		loggers.InitializeLogger().Error("Failed to build queue snapshot", "doctor_id", doctorID, "error", err.Error())
+		return
+	}
+	queueBroker.publish(doctorID, snapshot)
+}
+
+func buildSnapshot(doctorID uint, day time.Time) (schemas.QueueSnapshot, error) {
+	snapshot := schemas.QueueSnapshot{
+		DoctorID:  doctorID,
+		QueueDate: day.Format("2006-01-02"),
+		Waiting:   []schemas.QueueTokenResponse{},
+		Skipped:   []schemas.QueueTokenResponse{},
+	}
+
+	var tokens []models.QueueToken
+	if err := initializers.DB.Preload("Patient").
+		Where("doctor_id = ? AND queue_date = ?", doctorID, day).
+		Order("token_number").Find(&tokens).Error; err != nil {
+		return snapshot, err
+	}
+
+	// Average consultation length from today's completed tokens
+	var consultTotal time.Duration
+	var consultCount int
+	for _, token := range tokens {
+		if token.Status != models.TokenStatusCompleted {
+			continue
+		}
+		snapshot.CompletedCount++
+		if token.CalledAt != nil && token.CompletedAt != nil {
+			consultTotal += token.CompletedAt.Sub(*token.CalledAt)
+			consultCount++
+		}
+	}
+	avgMinutes := defaultConsultMinutes
+	if env, err := strconv.Atoi(os.Getenv("OPD_DEFAULT_CONSULT_MINUTES")); err == nil && env > 0 {
+		avgMinutes = env
+	}
+	if consultCount > 0 {
+		avgMinutes = int((consultTotal / time.Duration(consultCount)).Round(time.Minute).Minutes())
+		if avgMinutes < 1 {
+			avgMinutes = 1
+		}
+	}
+	snapshot.AvgConsultMinutes = avgMinutes
+
+	ahead := 0
+	for _, token := range tokens {
+		response := schemas.QueueTokenResponse{
+			ID:            token.ID,
+			TokenNumber:   token.TokenNumber,
+			PatientID:     token.PatientID,
+			PatientName:   token.Patient.FirstName + " " + token.Patient.LastName,
+			AppointmentID: token.AppointmentID,
+			Status:        token.Status,
+			CheckedInAt:   token.CheckedInAt,
+			CalledAt:      token.CalledAt,
+		}
+
+		switch token.Status {
+		case models.TokenStatusCalled:
+			snapshot.Current = &response
+			ahead++
+		case models.TokenStatusSkipped:
+			snapshot.Skipped = append(snapshot.Skipped, response)
+		}
+	}
+
+	for _, token := range tokens {
+		if token.Status != models.TokenStatusWaiting {
+			continue
+		}
+		snapshot.Waiting = append(snapshot.Waiting, schemas.QueueTokenResponse{
+			ID:                   token.ID,
+			TokenNumber:          token.TokenNumber,
+			PatientID:            token.PatientID,
+			PatientName:          token.Patient.FirstName + " " + token.Patient.LastName,
+			AppointmentID:        token.AppointmentID,
+			Status:               token.Status,
+			CheckedInAt:          token.CheckedInAt,
+			EstimatedWaitMinutes: ahead * avgMinutes,
+		})
+		ahead++
+	}
+
+	return snapshot, nil
+}
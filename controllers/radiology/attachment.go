@@ -0,0 +1,181 @@
+package radiologyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/storage"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+const maxAttachmentBytes = 100 << 20
+
+// Bytes read to detect the file type, the most mimetype inspects by default
+const sniffBytes = 3072
+
+// UploadRadiologyAttachment attaches an exported image (JPEG, PNG or DICOM)
+// or a PDF report to a performed study. Multipart fields: order_id,
+// uploaded_by_id, kind (Image or Report) and file.
+func UploadRadiologyAttachment(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	// Cap the body at the attachment limit plus room for the form fields, so
+	// an oversized upload is cut off instead of spooled to disk
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAttachmentBytes+1<<20)
+	if _, err := c.MultipartForm(); err != nil {
+		if errors.IsBodyTooLarge(err) {
+			c.Error(models.WrapError(http.StatusRequestEntityTooLarge, errors.ErrBadRequest, "File exceeds the 100 MB limit"))
+			return
+		}
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Request must be multipart form data"))
+		return
+	}
+
+	orderID, err1 := strconv.ParseUint(c.PostForm("order_id"), 10, 64)
+	uploadedBy, err2 := strconv.ParseUint(c.PostForm("uploaded_by_id"), 10, 64)
+	kind := c.PostForm("kind")
+	if err1 != nil || err2 != nil || (kind != models.RadiologyAttachmentImage && kind != models.RadiologyAttachmentReport) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "order_id, uploaded_by_id and kind (Image or Report) are required"))
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "file is required"))
+		return
+	}
+	if header.Size > maxAttachmentBytes {
+		c.Error(models.WrapError(http.StatusRequestEntityTooLarge, errors.ErrBadRequest, "File exceeds the 100 MB limit"))
+		return
+	}
+
+	var order models.RadiologyOrder
+	initializers.DB.First(&order, orderID)
+	if order.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Radiology order not found"))
+		return
+	}
+	if order.Status != models.RadiologyStatusPerformed && order.Status != models.RadiologyStatusReported {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Attachments can only be added to performed studies"))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.Error("Failed to open uploaded file", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Could not read uploaded file"))
+		return
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffBytes)
+	n, _ := io.ReadFull(file, head)
+	head = head[:n]
+
+	contentType := detectContentType(head)
+	if kind == models.RadiologyAttachmentReport && contentType != "application/pdf" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Reports must be PDF files"))
+		return
+	}
+	if kind == models.RadiologyAttachmentImage && contentType != "application/dicom" && contentType != "image/jpeg" && contentType != "image/png" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Images must be JPEG, PNG or DICOM files"))
+		return
+	}
+
+	backend, err := storage.FromEnv()
+	if err != nil {
+		logger.Error("Attachment storage is misconfigured", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.InternalServerError, "Attachment storage is not available"))
+		return
+	}
+
+	suffix := make([]byte, 16)
+	rand.Read(suffix)
+	key := storage.Key("radiology", strconv.FormatUint(orderID, 10), hex.EncodeToString(suffix))
+
+	if err := backend.Put(c.Request.Context(), key, io.MultiReader(bytes.NewReader(head), file), header.Size, contentType); err != nil {
+		logger.Error("Failed to store radiology attachment", "radiology_order_id", order.ID, "backend", backend.Name(), "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.InternalServerError, "Failed to store file"))
+		return
+	}
+
+	attachment := models.RadiologyAttachment{
+		RadiologyOrderID: order.ID,
+		Kind:             kind,
+		FileName:         filepath.Base(header.Filename),
+		ContentType:      contentType,
+		SizeBytes:        header.Size,
+		StorageBackend:   backend.Name(),
+		StorageKey:       key,
+		UploadedByID:     uint(uploadedBy),
+	}
+	if err := initializers.DB.Create(&attachment).Error; err != nil {
+		backend.Delete(c.Request.Context(), key)
+		logger.Error("Failed to save radiology attachment", "radiology_order_id", order.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to save attachment"))
+		return
+	}
+
+	logger.Info("Radiology attachment uploaded", "attachment_id", attachment.ID, "radiology_order_id", order.ID, "kind", kind)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    attachment,
+		"message": "Successfully uploaded attachment",
+		"status":  "Success",
+	})
+}
+
+// DownloadRadiologyAttachment streams an attachment back as a download
+func DownloadRadiologyAttachment(c *gin.Context) {
+	attachmentID := c.Query("attachment_id")
+
+	if attachmentID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "attachment_id is required"))
+		return
+	}
+
+	var attachment models.RadiologyAttachment
+	initializers.DB.First(&attachment, attachmentID)
+	if attachment.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Attachment not found"))
+		return
+	}
+
+	file, err := storage.Open(c.Request.Context(), attachment.StorageBackend, attachment.StorageKey)
+	if err == storage.ErrNotFound {
+		loggers.InitializeLogger().Error("Radiology attachment missing from storage", "attachment_id", attachment.ID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Attachment file not found"))
+		return
+	}
+	if err != nil {
+		loggers.InitializeLogger().Error("Failed to open radiology attachment", "attachment_id", attachment.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.InternalServerError, "Failed to read attachment"))
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.SizeBytes, attachment.ContentType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", attachment.FileName),
+	})
+}
+
+// detectContentType sniffs the file header. DICOM files carry "DICM" after a
+// 128-byte preamble, which is checked first so it wins over whatever the
+// preamble happens to contain.
+func detectContentType(head []byte) string {
+	if len(head) >= 132 && string(head[128:132]) == "DICM" {
+		return "application/dicom"
+	}
+	return strings.Split(mimetype.Detect(head).String(), ";")[0]
+}
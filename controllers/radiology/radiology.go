@@ -0,0 +1,301 @@
+package radiologyController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateRadiologyOrder orders an imaging study for a visit
+func CreateRadiologyOrder(c *gin.Context) {
+	var input schemas.RadiologyOrderInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Create Radiology Order", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var visit models.Visit
+	initializers.DB.First(&visit, input.VisitID)
+	if visit.ID == 0 {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Visit not found"))
+		return
+	}
+	if visit.ClosedAt != nil {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Visit is closed; no further orders can be placed"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+
+	priority := input.Priority
+	if priority == "" {
+		priority = "Routine"
+	}
+
+	order := models.RadiologyOrder{
+		VisitID:            visit.ID,
+		PatientID:          visit.PatientID,
+		DoctorID:           doctor.ID,
+		EntityID:           doctor.EntityID,
+		Modality:           input.Modality,
+		BodyPart:           input.BodyPart,
+		Laterality:         input.Laterality,
+		Priority:           priority,
+		ClinicalIndication: input.ClinicalIndication,
+		Status:             models.RadiologyStatusOrdered,
+	}
+
+	if err := initializers.DB.Create(&order).Error; err != nil {
+		logger.Error("Failed to create radiology order", "visit_id", visit.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create radiology order"))
+		return
+	}
+
+	logger.Info("Radiology order created", "radiology_order_id", order.ID, "visit_id", visit.ID, "modality", order.Modality)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    order.ID,
+		"message": "Successfully created radiology order",
+		"status":  "Success",
+	})
+}
+
+// ScheduleRadiologyOrder books or reschedules the study in a room
+func ScheduleRadiologyOrder(c *gin.Context) {
+	var input schemas.ScheduleRadiologyInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Schedule Radiology Order", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	if input.ScheduledAt.Before(time.Now()) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Studies cannot be scheduled in the past"))
+		return
+	}
+
+	var order models.RadiologyOrder
+	initializers.DB.First(&order, input.OrderID)
+	if order.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Radiology order not found"))
+		return
+	}
+
+	result := initializers.DB.Model(&order).
+		Where("status IN ?", []string{models.RadiologyStatusOrdered, models.RadiologyStatusScheduled}).
+		Updates(map[string]interface{}{
+			"status":       models.RadiologyStatusScheduled,
+			"scheduled_at": input.ScheduledAt,
+			"room":         input.Room,
+		})
+	if result.Error != nil {
+		logger.Error("Failed to schedule radiology order", "radiology_order_id", order.ID, "error", result.Error.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to schedule radiology order"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Only ordered or scheduled studies can be scheduled"))
+		return
+	}
+
+	logger.Info("Radiology order scheduled", "radiology_order_id", order.ID, "scheduled_at", input.ScheduledAt, "room", input.Room)
+	c.JSON(http.StatusOK, gin.H{"data": order.ID, "message": "Successfully scheduled radiology order", "status": "Success"})
+}
+
+// MarkRadiologyPerformed records that the study has been acquired
+func MarkRadiologyPerformed(c *gin.Context) {
+	changeStatus(c, []string{models.RadiologyStatusOrdered, models.RadiologyStatusScheduled}, models.RadiologyStatusPerformed,
+		"Only ordered or scheduled studies can be performed")
+}
+
+// CancelRadiologyOrder cancels a study that has not been performed yet
+func CancelRadiologyOrder(c *gin.Context) {
+	changeStatus(c, []string{models.RadiologyStatusOrdered, models.RadiologyStatusScheduled}, models.RadiologyStatusCancelled,
+		"Performed studies cannot be cancelled")
+}
+
+func changeStatus(c *gin.Context, from []string, to, rejection string) {
+	var input schemas.RadiologyOrderActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Radiology Order Status", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var order models.RadiologyOrder
+	initializers.DB.First(&order, input.OrderID)
+	if order.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Radiology order not found"))
+		return
+	}
+
+	updates := map[string]interface{}{"status": to}
+	if to == models.RadiologyStatusPerformed {
+		updates["performed_at"] = time.Now()
+	}
+
+	result := initializers.DB.Model(&order).Where("status IN ?", from).Updates(updates)
+	if result.Error != nil {
+		logger.Error("Failed to update radiology order", "radiology_order_id", order.ID, "error", result.Error.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update radiology order"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, rejection))
+		return
+	}
+
+	logger.Info("Radiology order status updated", "radiology_order_id", order.ID, "from", order.Status, "to", to)
+	c.JSON(http.StatusOK, gin.H{"data": order.ID, "message": "Radiology order updated successfully", "status": "Success"})
+}
+
+// SaveRadiologyReport creates or edits the draft report of a performed study
+// and optionally finalizes it
+func SaveRadiologyReport(c *gin.Context) {
+	var input schemas.RadiologyReportInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Save Radiology Report", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var radiologist models.Employee
+	initializers.DB.First(&radiologist, input.RadiologistID)
+	if radiologist.ID == 0 {
+		logger.Warn("Radiologist not found", "employee_id", input.RadiologistID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Radiologist not found"))
+		return
+	}
+
+	var report models.RadiologyReport
+	var failure *models.APIError
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var order models.RadiologyOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Report").First(&order, input.OrderID).Error; err != nil {
+			return err
+		}
+		if order.Status != models.RadiologyStatusPerformed {
+			apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Only performed studies without a final report can be reported")
+			failure = &apiErr
+			return nil
+		}
+
+		if order.Report != nil {
+			report = *order.Report
+		}
+		if report.Status == models.RadiologyReportFinal {
+			apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Final reports cannot be edited")
+			failure = &apiErr
+			return nil
+		}
+
+		report.RadiologyOrderID = order.ID
+		report.RadiologistID = radiologist.ID
+		report.Findings = input.Findings
+		report.Impression = input.Impression
+		report.Status = models.RadiologyReportDraft
+		if input.Finalize {
+			now := time.Now()
+			report.Status = models.RadiologyReportFinal
+			report.FinalizedAt = &now
+		}
+		if err := tx.Save(&report).Error; err != nil {
+			return err
+		}
+
+		if input.Finalize {
+			return tx.Model(&order).Update("status", models.RadiologyStatusReported).Error
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Radiology order not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to save radiology report", "radiology_order_id", input.OrderID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to save radiology report"))
+		return
+	}
+	if failure != nil {
+		c.Error(*failure)
+		return
+	}
+
+	logger.Info("Radiology report saved", "radiology_order_id", input.OrderID, "report_status", report.Status)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    report.ID,
+		"message": "Successfully saved radiology report",
+		"status":  "Success",
+	})
+}
+
+// GetRadiologyOrders lists orders by order_id, visit_id or patient_id, or the
+// worklist of an entity (entity_id, optionally a date in YYYY-MM-DD), with
+// their reports and attachments
+func GetRadiologyOrders(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+
+	query := initializers.DB.Preload("Patient").Preload("Doctor").Preload("Report.Radiologist").Preload("Attachments")
+	switch {
+	case c.Query("order_id") != "":
+		query = query.Where("id = ?", c.Query("order_id"))
+	case c.Query("visit_id") != "":
+		query = query.Where("visit_id = ?", c.Query("visit_id"))
+	case c.Query("patient_id") != "":
+		query = query.Where("patient_id = ?", c.Query("patient_id"))
+	case c.Query("entity_id") != "":
+		query = query.Where("entity_id = ?", c.Query("entity_id"))
+		if date := c.Query("date"); date != "" {
+			day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+			if err != nil {
+				c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "date must be YYYY-MM-DD"))
+				return
+			}
+			query = query.Where("scheduled_at >= ? AND scheduled_at < ?", day, day.AddDate(0, 0, 1))
+		}
+	default:
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "order_id, visit_id, patient_id or entity_id is required"))
+		return
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var orders []models.RadiologyOrder
+	if err := query.Order("scheduled_at NULLS LAST, created_at").Find(&orders).Error; err != nil {
+		logger.Error("Failed to fetch radiology orders", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch radiology orders"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    orders,
+		"message": "Successfully fetched radiology orders",
+		"status":  "Success",
+	})
+}
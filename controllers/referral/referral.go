@@ -0,0 +1,425 @@
+package referralController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/notifications"
+	"apps90-hms/scheduling"
+	"apps90-hms/schemas"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultDurationMinutes = 15
+
+// CreateReferral refers a visit to another doctor, or to a department of the
+// target entity
+func CreateReferral(c *gin.Context) {
+	var input schemas.ReferralInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Create Referral", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	if input.ToDoctorID == nil && input.Department == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Either to_doctor_id or department is required"))
+		return
+	}
+
+	var visit models.Visit
+	initializers.DB.First(&visit, input.VisitID)
+	if visit.ID == 0 {
+		logger.Warn("Visit not found", "visit_id", input.VisitID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Visit not found"))
+		return
+	}
+
+	var fromDoctor models.Employee
+	initializers.DB.First(&fromDoctor, input.FromDoctorID)
+	if fromDoctor.ID == 0 {
+		logger.Warn("Referring doctor not found", "employee_id", input.FromDoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Referring doctor not found"))
+		return
+	}
+
+	targetEntityID := input.TargetEntityID
+	if input.ToDoctorID != nil {
+		if *input.ToDoctorID == fromDoctor.ID {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "A doctor cannot refer a patient to themselves"))
+			return
+		}
+		var toDoctor models.Employee
+		initializers.DB.First(&toDoctor, *input.ToDoctorID)
+		if toDoctor.ID == 0 {
+			logger.Warn("Receiving doctor not found", "employee_id", *input.ToDoctorID)
+			c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Receiving doctor not found"))
+			return
+		}
+		if targetEntityID == 0 {
+			targetEntityID = toDoctor.EntityID
+		} else if targetEntityID != toDoctor.EntityID {
+			c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Receiving doctor does not work at the target entity"))
+			return
+		}
+	}
+
+	var entity models.Entity
+	initializers.DB.First(&entity, targetEntityID)
+	if entity.ID == 0 {
+		logger.Warn("Target entity not found", "entity_id", targetEntityID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Target entity not found"))
+		return
+	}
+
+	urgency := input.Urgency
+	if urgency == "" {
+		urgency = models.ReferralUrgencyRoutine
+	}
+
+	referral := models.Referral{
+		PatientID:      visit.PatientID,
+		VisitID:        visit.ID,
+		FromDoctorID:   fromDoctor.ID,
+		FromEntityID:   fromDoctor.EntityID,
+		ToDoctorID:     input.ToDoctorID,
+		Department:     input.Department,
+		TargetEntityID: entity.ID,
+		Reason:         input.Reason,
+		Urgency:        urgency,
+		Status:         models.ReferralStatusPending,
+	}
+
+	if err := initializers.DB.Create(&referral).Error; err != nil {
+		logger.Error("Failed to create referral", "visit_id", visit.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create referral"))
+		return
+	}
+
+	logger.Info("Referral created", "referral_id", referral.ID, "visit_id", visit.ID, "target_entity_id", entity.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    referral.ID,
+		"message": "Successfully created referral",
+		"status":  "Success",
+	})
+}
+
+// GetReferralInbox lists the referrals a doctor can act on: those addressed to
+// them and department referrals to their entity. Most urgent first.
+func GetReferralInbox(c *gin.Context) {
+	doctorID := c.Query("doctor_id")
+	logger := loggers.InitializeLogger()
+
+	if doctorID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "doctor_id is required"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, doctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", doctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+
+	status := c.DefaultQuery("status", models.ReferralStatusPending)
+
+	var referrals []models.Referral
+	query := initializers.DB.Preload("Patient").Preload("FromDoctor").Preload("TargetEntity").Preload("Visit").
+		Where("to_doctor_id = ? OR (to_doctor_id IS NULL AND target_entity_id = ?)", doctor.ID, doctor.EntityID)
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.
+		Order(clause.Expr{SQL: "CASE urgency WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END",
+			Vars: []interface{}{models.ReferralUrgencyEmergency, models.ReferralUrgencyUrgent}}).
+		Order("created_at").Find(&referrals).Error; err != nil {
+		logger.Error("Failed to fetch referral inbox", "employee_id", doctor.ID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch referrals"))
+		return
+	}
+
+	inbox := []schemas.ReferralInboxItem{}
+	for _, referral := range referrals {
+		inbox = append(inbox, schemas.ReferralInboxItem{
+			ID:               referral.ID,
+			Status:           referral.Status,
+			Urgency:          referral.Urgency,
+			Reason:           referral.Reason,
+			Department:       referral.Department,
+			CreatedAt:        referral.CreatedAt,
+			PatientID:        referral.PatientID,
+			PatientName:      referral.Patient.FirstName + " " + referral.Patient.LastName,
+			FromDoctorName:   referral.FromDoctor.FirstName + " " + referral.FromDoctor.LastName,
+			ToDoctorID:       referral.ToDoctorID,
+			TargetEntityName: referral.TargetEntity.Name,
+			AppointmentID:    referral.AppointmentID,
+			VisitID:          referral.VisitID,
+			VisitDate:        referral.Visit.VisitDate,
+			VisitType:        referral.Visit.VisitType,
+			Diagnosis:        referral.Visit.Diagnosis,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    inbox,
+		"message": "Successfully fetched referrals",
+		"status":  "Success",
+	})
+}
+
+// AcceptReferral books the patient with the accepting doctor at the target
+// entity and marks the referral accepted
+func AcceptReferral(c *gin.Context) {
+	var input schemas.AcceptReferralInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Accept Referral", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+	if input.AppointmentTime.Before(time.Now()) {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Appointments cannot be booked in the past"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if doctor.ID == 0 {
+		logger.Warn("Doctor not found", "employee_id", input.DoctorID)
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Doctor not found"))
+		return
+	}
+
+	duration := input.DurationMinutes
+	if duration == 0 {
+		duration = defaultDurationMinutes
+	}
+
+	var appointment models.Appointment
+	var failure *models.APIError
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var referral models.Referral
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&referral, input.ReferralID).Error; err != nil {
+			return err
+		}
+
+		if apiErr := checkReceiver(referral, doctor); apiErr != nil {
+			failure = apiErr
+			return nil
+		}
+
+		conflict, err := scheduling.FindConflict(tx, doctor.ID, referral.PatientID, input.AppointmentTime, duration)
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			apiErr := models.WrapError(http.StatusConflict, errors.ErrObjectExists, "Doctor or patient already has an appointment at this time")
+			failure = &apiErr
+			return nil
+		}
+
+		appointment = models.Appointment{
+			AppointmentTime: input.AppointmentTime,
+			Reason:          "Referral: " + referral.Reason,
+			Notes:           input.Notes,
+			PatientID:       referral.PatientID,
+			EmployeeID:      doctor.ID,
+			EntityID:        referral.TargetEntityID,
+			DurationMinutes: duration,
+			Status:          models.AppointmentStatusScheduled,
+		}
+		if err := tx.Create(&appointment).Error; err != nil {
+			return err
+		}
+		if err := notifications.EnqueueAppointmentReminders(tx, appointment.ID); err != nil {
+			return err
+		}
+
+		return tx.Model(&referral).Updates(map[string]interface{}{
+			"status":         models.ReferralStatusAccepted,
+			"to_doctor_id":   doctor.ID,
+			"appointment_id": appointment.ID,
+			"response_notes": input.Notes,
+			"responded_at":   time.Now(),
+		}).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Referral not found"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to accept referral", "referral_id", input.ReferralID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to accept referral"))
+		return
+	}
+	if failure != nil {
+		logger.Warn("Referral could not be accepted", "referral_id", input.ReferralID, "reason", failure.Message)
+		c.Error(*failure)
+		return
+	}
+
+	logger.Info("Referral accepted", "referral_id", input.ReferralID, "appointment_id", appointment.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":    appointment.ID,
+		"message": "Successfully accepted referral",
+		"status":  "Success",
+	})
+}
+
+// DeclineReferral turns a pending referral down with the receiver's notes
+func DeclineReferral(c *gin.Context) {
+	var input schemas.ReferralActionInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Decline Referral", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var referral models.Referral
+	initializers.DB.First(&referral, input.ReferralID)
+	if referral.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Referral not found"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, input.DoctorID)
+	if apiErr := checkReceiver(referral, doctor); apiErr != nil {
+		c.Error(*apiErr)
+		return
+	}
+
+	result := initializers.DB.Model(&referral).Where("status = ?", models.ReferralStatusPending).Updates(map[string]interface{}{
+		"status":         models.ReferralStatusDeclined,
+		"response_notes": input.Notes,
+		"responded_at":   time.Now(),
+	})
+	if result.Error != nil {
+		logger.Error("Failed to decline referral", "referral_id", referral.ID, "error", result.Error.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to decline referral"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Referral is no longer pending"))
+		return
+	}
+
+	logger.Info("Referral declined", "referral_id", referral.ID, "employee_id", doctor.ID)
+	c.JSON(http.StatusOK, gin.H{"data": referral.ID, "message": "Successfully declined referral", "status": "Success"})
+}
+
+// GetReferredVisit shares the referred visit with the receiving doctor. Only
+// that visit and its prescriptions are returned, never the rest of the
+// patient's history.
+func GetReferredVisit(c *gin.Context) {
+	referralID := c.Query("referral_id")
+	doctorID := c.Query("doctor_id")
+	logger := loggers.InitializeLogger()
+
+	if referralID == "" || doctorID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "referral_id and doctor_id are required"))
+		return
+	}
+
+	var referral models.Referral
+	initializers.DB.Preload("Patient").Preload("Visit.Doctor").First(&referral, referralID)
+	if referral.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Referral not found"))
+		return
+	}
+
+	var doctor models.Employee
+	initializers.DB.First(&doctor, doctorID)
+	if !canView(referral, doctor) {
+		logger.Warn("Referred visit access denied", "referral_id", referral.ID, "employee_id", doctorID)
+		c.Error(models.WrapError(http.StatusForbidden, errors.ErrBadRequest, "Referral is not addressed to this doctor"))
+		return
+	}
+
+	var prescriptions []models.Prescription
+	initializers.DB.Preload("PrescriptionItems").Where("visit_id = ?", referral.VisitID).Order("date_issued").Find(&prescriptions)
+
+	shared := []schemas.ReferredPrescription{}
+	for _, prescription := range prescriptions {
+		items := []string{}
+		for _, item := range prescription.PrescriptionItems {
+			items = append(items, item.PrescriptionDetails)
+		}
+		shared = append(shared, schemas.ReferredPrescription{
+			ID:         prescription.ID,
+			DateIssued: prescription.DateIssued,
+			Items:      items,
+			Notes:      prescription.Notes,
+		})
+	}
+
+	visit := referral.Visit
+	response := schemas.ReferredVisitResponse{
+		ReferralID:  referral.ID,
+		PatientName: referral.Patient.FirstName + " " + referral.Patient.LastName,
+		Gender:      referral.Patient.Gender,
+		DateOfBirth: referral.Patient.DateOfBirth,
+		Reason:      referral.Reason,
+		Urgency:     referral.Urgency,
+		Visit: schemas.VisitResponse{
+			ID:            visit.ID,
+			VisitDate:     visit.VisitDate,
+			Diagnosis:     visit.Diagnosis,
+			TreatmentPlan: visit.TreatmentPlan,
+			Notes:         visit.Notes,
+			VisitType:     visit.VisitType,
+			DischargeDate: visit.DischargeDate,
+			DoctorName:    visit.Doctor.FirstName + " " + visit.Doctor.LastName,
+			Prescriptions: []schemas.PrescriptionResponse{},
+		},
+		Prescriptions: shared,
+	}
+	if visit.VisitType == "IP" {
+		response.Visit.AdmissionDate = &visit.AdmissionDate
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    response,
+		"message": "Successfully fetched referred visit",
+		"status":  "Success",
+	})
+}
+
+// checkReceiver verifies that doctor may act on a pending referral
+func checkReceiver(referral models.Referral, doctor models.Employee) *models.APIError {
+	if referral.Status != models.ReferralStatusPending {
+		apiErr := models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Referral is no longer pending")
+		return &apiErr
+	}
+	if !canView(referral, doctor) {
+		apiErr := models.WrapError(http.StatusForbidden, errors.ErrBadRequest, "Referral is not addressed to this doctor")
+		return &apiErr
+	}
+	return nil
+}
+
+// canView reports whether doctor is the receiver of the referral: the named
+// doctor, or any doctor of the target entity for a department referral
+func canView(referral models.Referral, doctor models.Employee) bool {
+	if doctor.ID == 0 {
+		return false
+	}
+	if referral.ToDoctorID != nil {
+		return *referral.ToDoctorID == doctor.ID
+	}
+	return doctor.EntityID == referral.TargetEntityID
+}
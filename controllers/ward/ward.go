@@ -0,0 +1,252 @@
+package wardController
+
+import (
+	"apps90-hms/errors"
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/schemas"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AddWard(c *gin.Context) {
+	var input schemas.WardInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add Ward", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var existing models.Ward
+	initializers.DB.Where("entity_id = ? AND name = ?", input.EntityID, input.Name).Find(&existing)
+	if existing.ID != 0 {
+		logger.Warn("Ward with this name already exists", "name", input.Name, "entity_id", input.EntityID)
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrObjectExists, "Ward with this name already exists"))
+		return
+	}
+
+	ward := models.Ward{
+		EntityID:          input.EntityID,
+		Name:              input.Name,
+		WardType:          input.WardType,
+		GenderRestriction: input.GenderRestriction,
+	}
+
+	if err := initializers.DB.Create(&ward).Error; err != nil {
+		logger.Error("Failed to create ward", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create ward"))
+		return
+	}
+
+	logger.Info("Ward created successfully", "ward_id", ward.ID)
+	c.JSON(http.StatusOK, gin.H{"data": ward.ID, "message": "Successfully created ward", "status": "Success"})
+}
+
+func AddRoom(c *gin.Context) {
+	var input schemas.RoomInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add Room", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var ward models.Ward
+	initializers.DB.First(&ward, input.WardID)
+	if ward.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Ward not found"))
+		return
+	}
+
+	var existing models.Room
+	initializers.DB.Where("ward_id = ? AND room_number = ?", input.WardID, input.RoomNumber).Find(&existing)
+	if existing.ID != 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrObjectExists, "Room with this number already exists in the ward"))
+		return
+	}
+
+	room := models.Room{
+		WardID:     input.WardID,
+		RoomNumber: input.RoomNumber,
+		RoomType:   input.RoomType,
+		DailyRate:  input.DailyRate,
+	}
+
+	if err := initializers.DB.Create(&room).Error; err != nil {
+		logger.Error("Failed to create room", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create room"))
+		return
+	}
+
+	logger.Info("Room created successfully", "room_id", room.ID, "ward_id", room.WardID)
+	c.JSON(http.StatusOK, gin.H{"data": room.ID, "message": "Successfully created room", "status": "Success"})
+}
+
+func AddBed(c *gin.Context) {
+	var input schemas.BedInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Add Bed", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	var room models.Room
+	initializers.DB.First(&room, input.RoomID)
+	if room.ID == 0 {
+		c.Error(models.WrapError(http.StatusNotFound, errors.ErrObjectNotFound, "Room not found"))
+		return
+	}
+
+	var existing models.Bed
+	initializers.DB.Where("room_id = ? AND bed_number = ?", input.RoomID, input.BedNumber).Find(&existing)
+	if existing.ID != 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrObjectExists, "Bed with this number already exists in the room"))
+		return
+	}
+
+	bed := models.Bed{
+		RoomID:    input.RoomID,
+		BedNumber: input.BedNumber,
+		Status:    models.BedStatusFree,
+	}
+
+	if err := initializers.DB.Create(&bed).Error; err != nil {
+		logger.Error("Failed to create bed", "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to create bed"))
+		return
+	}
+
+	logger.Info("Bed created successfully", "bed_id", bed.ID, "room_id", bed.RoomID)
+	c.JSON(http.StatusOK, gin.H{"data": bed.ID, "message": "Successfully created bed", "status": "Success"})
+}
+
+// UpdateBedStatus moves a bed between free, cleaning and blocked. Occupied
+// beds are released through discharge, never directly.
+func UpdateBedStatus(c *gin.Context) {
+	var input schemas.BedStatusInput
+	logger := loggers.InitializeLogger()
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Error("Error binding JSON for Update Bed Status", "error", err.Error())
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBindingJSON, "Invalid request format"))
+		return
+	}
+
+	result := initializers.DB.Model(&models.Bed{}).
+		Where("id = ? AND status <> ?", input.BedID, models.BedStatusOccupied).
+		Update("status", input.Status)
+	if result.Error != nil {
+		logger.Error("Failed to update bed status", "bed_id", input.BedID, "error", result.Error.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to update bed status"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "Bed not found or currently occupied"))
+		return
+	}
+
+	logger.Info("Bed status updated", "bed_id", input.BedID, "status", input.Status)
+	c.JSON(http.StatusOK, gin.H{"data": input.BedID, "message": "Successfully updated bed status", "status": "Success"})
+}
+
+// GetOccupancyBoard lists every ward, room and bed of an entity with the
+// patient occupying each bed and a per-ward count of beds by status.
+func GetOccupancyBoard(c *gin.Context) {
+	logger := loggers.InitializeLogger()
+	entityID := c.Query("entity_id")
+
+	if entityID == "" {
+		c.Error(models.WrapError(http.StatusBadRequest, errors.ErrBadRequest, "entity_id is required"))
+		return
+	}
+
+	var wards []models.Ward
+	if err := initializers.DB.
+		Preload("Rooms", func(db *gorm.DB) *gorm.DB { return db.Order("room_number") }).
+		Preload("Rooms.Beds", func(db *gorm.DB) *gorm.DB { return db.Order("bed_number") }).
+		Where("entity_id = ?", entityID).Order("name").Find(&wards).Error; err != nil {
+		logger.Error("Failed to fetch wards", "entity_id", entityID, "error", err.Error())
+		c.Error(models.WrapError(http.StatusInternalServerError, errors.ErrDatabaseFailed, "Failed to fetch occupancy board"))
+		return
+	}
+
+	// Load the visits occupying beds in one query
+	var visitIDs []uint
+	for _, ward := range wards {
+		for _, room := range ward.Rooms {
+			for _, bed := range room.Beds {
+				if bed.CurrentVisitID != nil {
+					visitIDs = append(visitIDs, *bed.CurrentVisitID)
+				}
+			}
+		}
+	}
+	visits := map[uint]models.Visit{}
+	if len(visitIDs) > 0 {
+		var occupying []models.Visit
+		initializers.DB.Preload("Patient").Where("id IN ?", visitIDs).Find(&occupying)
+		for _, visit := range occupying {
+			visits[visit.ID] = visit
+		}
+	}
+
+	board := []schemas.WardOccupancyResponse{}
+	for _, ward := range wards {
+		wardResponse := schemas.WardOccupancyResponse{
+			ID:                ward.ID,
+			Name:              ward.Name,
+			WardType:          ward.WardType,
+			GenderRestriction: ward.GenderRestriction,
+			Summary: map[string]int{
+				models.BedStatusFree:     0,
+				models.BedStatusOccupied: 0,
+				models.BedStatusCleaning: 0,
+				models.BedStatusBlocked:  0,
+			},
+			Rooms: []schemas.RoomOccupancyResponse{},
+		}
+
+		for _, room := range ward.Rooms {
+			roomResponse := schemas.RoomOccupancyResponse{
+				ID:         room.ID,
+				RoomNumber: room.RoomNumber,
+				RoomType:   room.RoomType,
+				DailyRate:  room.DailyRate,
+				Beds:       []schemas.BedOccupancyResponse{},
+			}
+
+			for _, bed := range room.Beds {
+				bedResponse := schemas.BedOccupancyResponse{
+					ID:        bed.ID,
+					BedNumber: bed.BedNumber,
+					Status:    bed.Status,
+					VisitID:   bed.CurrentVisitID,
+				}
+				if bed.CurrentVisitID != nil {
+					if visit, ok := visits[*bed.CurrentVisitID]; ok {
+						bedResponse.PatientID = visit.PatientID
+						bedResponse.PatientName = visit.Patient.FirstName + " " + visit.Patient.LastName
+					}
+				}
+				wardResponse.Summary[bed.Status]++
+				roomResponse.Beds = append(roomResponse.Beds, bedResponse)
+			}
+			wardResponse.Rooms = append(wardResponse.Rooms, roomResponse)
+		}
+		board = append(board, wardResponse)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    board,
+		"message": "Successfully fetched occupancy board",
+		"status":  "Success",
+	})
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 // Predefined error types
 var (
@@ -16,4 +19,12 @@ var (
 	ErrDatabaseFailed   = errors.New("DATABASE_ERROR")
 	ErrBadRequest       = errors.New("BAD_REQUEST")
 	ErrObjectNotFound   = errors.New("OBJECT_NOT_FOUND")
+	ErrForbidden        = errors.New("FORBIDDEN")
 )
+
+// IsBodyTooLarge reports whether err comes from reading a request body past
+// the limit set with http.MaxBytesReader
+func IsBodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
@@ -0,0 +1,35 @@
+package hl7
+
+import "time"
+
+// Acknowledgment codes (MSA-1)
+const (
+	AckAccept = "AA"
+	AckError  = "AE"
+	AckReject = "AR"
+)
+
+// BuildAck answers msg with an ACK carrying code and text. msg may be nil
+// when the incoming message could not be parsed at all.
+func BuildAck(msg *Message, code, text string) string {
+	var msh Segment
+	if msg != nil {
+		msh, _ = msg.Get("MSH")
+	}
+
+	messageType := "ACK"
+	if trigger := msh.Component(9, 2); trigger != "" {
+		messageType += "^" + trigger
+	}
+
+	// The ACK goes back to whoever sent the message
+	b := NewBuilder(messageType, msh.Field(5), msh.Field(6), msh.Field(3), msh.Field(4), "", time.Now())
+	b.Add("MSA", code, Escape(msh.Field(10)), Escape(text))
+	return b.String()
+}
+
+// IsPositive reports whether an MSA-1 code accepts the message, in original
+// (AA) or enhanced (CA) mode
+func IsPositive(code string) bool {
+	return code == AckAccept || code == "CA"
+}
@@ -0,0 +1,97 @@
+package hl7
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+// Sender delivers outbound messages to a remote MLLP listener and waits for
+// the ACK
+type Sender struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+// SenderFromEnv sends to HL7_LIS_ADDR. It returns nil when no LIS is
+// configured.
+func SenderFromEnv() *Sender {
+	addr := os.Getenv("HL7_LIS_ADDR")
+	if addr == "" {
+		return nil
+	}
+	return &Sender{Addr: addr, Timeout: 30 * time.Second}
+}
+
+// Send delivers payload and logs the exchange. A negative or missing ACK is
+// returned as an error; the log entry records the outcome either way.
+func (s *Sender) Send(payload string, labOrderID, replayOf *uint) (models.Hl7Message, error) {
+	logger := loggers.InitializeLogger()
+
+	entry := models.Hl7Message{
+		Direction:  models.Hl7DirectionOutbound,
+		Peer:       s.Addr,
+		Payload:    payload,
+		Status:     models.Hl7StatusPending,
+		LabOrderID: labOrderID,
+		ReplayOfID: replayOf,
+	}
+	if msg, err := Parse(payload); err == nil {
+		entry.MessageType = msg.Type()
+		entry.ControlID = msg.ControlID()
+	}
+	initializers.DB.Create(&entry)
+
+	ackCode, text, err := s.exchange(payload)
+	now := time.Now()
+	entry.AckCode, entry.ProcessedAt = ackCode, &now
+	switch {
+	case err != nil:
+		entry.Status, entry.Error = models.Hl7StatusFailed, err.Error()
+	case !IsPositive(ackCode):
+		entry.Status, entry.Error = models.Hl7StatusRejected, text
+		err = errors.New("message rejected with " + ackCode + ": " + text)
+	default:
+		entry.Status = models.Hl7StatusAcked
+	}
+	if saveErr := initializers.DB.Save(&entry).Error; saveErr != nil {
+		logger.Error("Failed to log HL7 message", "control_id", entry.ControlID, "error", saveErr.Error())
+	}
+
+	logger.Info("HL7 message sent", "hl7_message_id", entry.ID, "type", entry.MessageType,
+		"peer", s.Addr, "status", entry.Status)
+	return entry, err
+}
+
+// exchange sends one frame and returns MSA-1 and MSA-3 of the ACK
+func (s *Sender) exchange(payload string) (string, string, error) {
+	conn, err := net.DialTimeout("tcp", s.Addr, s.Timeout)
+	if err != nil {
+		return "", "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	if err := WriteFrame(conn, payload); err != nil {
+		return "", "", err
+	}
+	response, err := ReadFrame(bufio.NewReader(conn))
+	if err != nil {
+		return "", "", err
+	}
+
+	ack, err := Parse(response)
+	if err != nil {
+		return "", "", err
+	}
+	msa, ok := ack.Get("MSA")
+	if !ok {
+		return "", "", errors.New("ACK has no MSA segment")
+	}
+	return msa.Field(1), msa.Field(3), nil
+}
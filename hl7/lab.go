@@ -0,0 +1,258 @@
+package hl7
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/laboratory"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoLIS = errors.New("HL7_LIS_ADDR is not configured")
+
+// ResultHandler records the OBX values of an ORU^R01 message as lab results
+// entered by employeeID. Each OBR names the order by its placer number (our
+// order ID, OBR-2) or by sample barcode in OBR-2 or OBR-18. OBX-3 carries the
+// analyte code and OBX-5 the value. Orders still marked Collected are taken
+// as received by the lab.
+func ResultHandler(employeeID uint) Handler {
+	return func(tx *gorm.DB, msg *Message, entry *models.Hl7Message) error {
+		if employeeID == 0 {
+			return errors.New("HL7_INTERFACE_EMPLOYEE_ID is not configured")
+		}
+
+		type orderResults struct {
+			order  models.LabOrder
+			codes  map[string]uint
+			values []laboratory.ResultValue
+		}
+		var groups []*orderResults
+		byOrder := map[uint]*orderResults{}
+		var current *orderResults
+
+		for _, segment := range msg.Segments {
+			switch segment.Name {
+			case "OBR":
+				order, err := findOrder(tx, segment)
+				if err != nil {
+					return err
+				}
+				if group, ok := byOrder[order.ID]; ok {
+					current = group
+					continue
+				}
+				codes, err := analyteCodes(tx, order.ID)
+				if err != nil {
+					return err
+				}
+				current = &orderResults{order: order, codes: codes}
+				byOrder[order.ID] = current
+				groups = append(groups, current)
+
+			case "OBX":
+				if current == nil {
+					return errors.New("OBX segment before any OBR segment")
+				}
+				// Skip observations reported as deleted, wrong or not obtainable
+				switch segment.Field(11) {
+				case "D", "W", "X":
+					continue
+				}
+				code := segment.Component(3, 1)
+				analyteID, ok := current.codes[code]
+				if !ok {
+					return fmt.Errorf("analyte %q is not part of lab order %d", code, current.order.ID)
+				}
+				current.values = append(current.values, laboratory.ResultValue{AnalyteID: analyteID, Value: segment.Field(5)})
+			}
+		}
+
+		if len(groups) == 0 {
+			return errors.New("message has no OBR segment")
+		}
+		entry.LabOrderID = &groups[0].order.ID
+
+		for _, group := range groups {
+			if group.order.Status == models.LabOrderStatusCollected {
+				if err := laboratory.MarkReceived(tx, &group.order); err != nil {
+					return err
+				}
+				group.order.Status = models.LabOrderStatusInProgress
+			}
+			if _, err := laboratory.RecordResults(tx, &group.order, group.values, employeeID); err != nil {
+				return fmt.Errorf("lab order %d: %w", group.order.ID, err)
+			}
+		}
+		return nil
+	}
+}
+
+// findOrder locks the lab order an OBR segment refers to
+func findOrder(tx *gorm.DB, obr Segment) (models.LabOrder, error) {
+	var order models.LabOrder
+	for _, reference := range []string{obr.Component(2, 1), obr.Field(18)} {
+		if reference == "" {
+			continue
+		}
+		orderID, err := strconv.ParseUint(reference, 10, 64)
+		if err != nil {
+			var sample models.LabSample
+			tx.Where("barcode = ?", reference).Find(&sample)
+			if sample.ID == 0 {
+				continue
+			}
+			orderID = uint64(sample.LabOrderID)
+		}
+		tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Patient").Find(&order, orderID)
+		if order.ID != 0 {
+			return order, nil
+		}
+	}
+	return order, fmt.Errorf("no lab order matches OBR placer %q", obr.Component(2, 1))
+}
+
+// analyteCodes maps the codes of the analytes an order expects to their IDs
+func analyteCodes(tx *gorm.DB, orderID uint) (map[string]uint, error) {
+	expected, err := laboratory.ExpectedAnalytes(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	codes := map[string]uint{}
+	if len(expected) == 0 {
+		return codes, nil
+	}
+	var ids []uint
+	for id := range expected {
+		ids = append(ids, id)
+	}
+	var analytes []models.LabAnalyte
+	if err := tx.Where("id IN ?", ids).Find(&analytes).Error; err != nil {
+		return nil, err
+	}
+	for _, analyte := range analytes {
+		codes[analyte.Code] = analyte.ID
+	}
+	return codes, nil
+}
+
+// BuildORM renders a lab order as an ORM^O01 new-order message with one OBR
+// per ordered test
+func BuildORM(db *gorm.DB, orderID uint) (string, error) {
+	var order models.LabOrder
+	if err := db.Preload("Patient").Preload("Doctor").Preload("Visit").
+		Preload("Items.LabTest").Preload("Samples").First(&order, orderID).Error; err != nil {
+		return "", err
+	}
+	var entity models.Entity
+	db.First(&entity, order.EntityID)
+
+	patientClass := "O"
+	if order.Visit.VisitType == "IP" {
+		patientClass = "I"
+	}
+	priority := "R"
+	if order.Priority == models.LabPriorityStat {
+		priority = "S"
+	}
+	placer := strconv.FormatUint(uint64(order.ID), 10)
+	doctor := Components(strconv.FormatUint(uint64(order.DoctorID), 10), order.Doctor.LastName, order.Doctor.FirstName)
+
+	b := NewBuilder("ORM^O01", "APPS90-HMS", entity.Name, "LIS", "LAB", "", time.Now())
+	b.AddAt("PID", map[int]string{
+		1:  "1",
+		3:  Components(strconv.FormatUint(uint64(order.PatientID), 10), "", "", "HMS"),
+		5:  Components(order.Patient.LastName, order.Patient.FirstName),
+		7:  date(order.Patient.DateOfBirth),
+		8:  sexCode(order.Patient.Gender),
+		11: Escape(order.Patient.Address),
+		13: Escape(order.Patient.ContactNumber),
+	})
+	b.AddAt("PV1", map[int]string{
+		1:  "1",
+		2:  patientClass,
+		3:  Escape(order.Visit.RoomNumber),
+		7:  doctor,
+		19: strconv.FormatUint(uint64(order.VisitID), 10),
+	})
+	b.AddAt("ORC", map[int]string{
+		1:  "NW",
+		2:  placer,
+		9:  Timestamp(order.OrderedAt),
+		12: doctor,
+	})
+
+	samples := map[uint]models.LabSample{}
+	for _, sample := range order.Samples {
+		samples[sample.ID] = sample
+	}
+	for i, item := range order.Items {
+		fields := map[int]string{
+			1:  strconv.Itoa(i + 1),
+			2:  placer,
+			4:  Components(item.LabTest.Code, item.LabTest.Name, "L"),
+			5:  priority,
+			6:  Timestamp(order.OrderedAt),
+			13: Escape(order.ClinicalNotes),
+			15: Escape(item.LabTest.SpecimenType),
+			16: doctor,
+		}
+		if item.LabSampleID != nil {
+			if sample, ok := samples[*item.LabSampleID]; ok {
+				fields[7] = Timestamp(sample.CollectedAt)
+				fields[18] = Escape(sample.Barcode)
+			}
+		}
+		b.AddAt("OBR", fields)
+	}
+	return b.String(), nil
+}
+
+// SendLabOrder sends a lab order to the LIS as ORM^O01
+func SendLabOrder(orderID uint) (models.Hl7Message, error) {
+	sender := SenderFromEnv()
+	if sender == nil {
+		return models.Hl7Message{}, ErrNoLIS
+	}
+	payload, err := BuildORM(initializers.DB, orderID)
+	if err != nil {
+		return models.Hl7Message{}, err
+	}
+	return sender.Send(payload, &orderID, nil)
+}
+
+// EmitLabOrder sends a new order to the LIS in the background when one is
+// configured. Failures stay in the message log for replay.
+func EmitLabOrder(orderID uint) {
+	if SenderFromEnv() == nil {
+		return
+	}
+	go func() {
+		if _, err := SendLabOrder(orderID); err != nil {
+			loggers.InitializeLogger().Warn("Failed to send lab order to LIS", "lab_order_id", orderID, "error", err.Error())
+		}
+	}()
+}
+
+// date formats t as an HL7 date, or empty for the zero time
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("20060102")
+}
+
+func sexCode(gender string) string {
+	switch gender {
+	case "Male", "M":
+		return "M"
+	case "Female", "F":
+		return "F"
+	}
+	return "U"
+}
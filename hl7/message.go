@@ -0,0 +1,251 @@
+package hl7
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Timestamp layout of HL7 v2 DTM/TS values
+const timeLayout = "20060102150405"
+
+var ErrNotHL7 = errors.New("message does not start with an MSH segment")
+
+// Segment is one parsed segment. Fields are numbered as in the HL7 spec, so
+// Field(1) of PID is PID-1. For MSH, MSH-1 is the field separator and MSH-2
+// the encoding characters.
+type Segment struct {
+	Name   string
+	fields []string
+	enc    encoding
+}
+
+type encoding struct {
+	field, component, repetition, escape, subcomponent byte
+}
+
+var defaultEncoding = encoding{'|', '^', '~', '\\', '&'}
+
+// Field returns the first repetition of field n, unescaped
+func (s Segment) Field(n int) string {
+	return s.enc.unescape(s.repetition(n))
+}
+
+// Component returns component c of the first repetition of field n, unescaped
+func (s Segment) Component(n, c int) string {
+	parts := strings.Split(s.repetition(n), string(s.enc.component))
+	if c < 1 || c > len(parts) {
+		return ""
+	}
+	return s.enc.unescape(parts[c-1])
+}
+
+func (s Segment) repetition(n int) string {
+	if n < 0 || n >= len(s.fields) {
+		return ""
+	}
+	if s.Name == "MSH" && n <= 2 {
+		return s.fields[n]
+	}
+	return strings.SplitN(s.fields[n], string(s.enc.repetition), 2)[0]
+}
+
+// Message is a parsed HL7 v2 message
+type Message struct {
+	Segments []Segment
+}
+
+// Parse splits an HL7 v2 message into segments using the delimiters declared
+// in its MSH segment. Segments may end in CR, LF or CRLF.
+func Parse(raw string) (*Message, error) {
+	raw = strings.TrimLeft(raw, "\r\n\t ")
+	if len(raw) < 8 || !strings.HasPrefix(raw, "MSH") {
+		return nil, ErrNotHL7
+	}
+	enc := encoding{raw[3], raw[4], raw[5], raw[6], raw[7]}
+
+	lines := strings.FieldsFunc(raw, func(r rune) bool { return r == '\r' || r == '\n' })
+	msg := &Message{}
+	for _, line := range lines {
+		if len(line) < 3 {
+			continue
+		}
+		fields := strings.Split(line, string(enc.field))
+		segment := Segment{Name: fields[0], enc: enc}
+		if segment.Name == "MSH" {
+			// MSH-1 is the separator itself, so shift the remaining fields by one
+			segment.fields = append([]string{"MSH", string(enc.field)}, fields[1:]...)
+		} else {
+			segment.fields = fields
+		}
+		msg.Segments = append(msg.Segments, segment)
+	}
+	return msg, nil
+}
+
+// Get returns the first segment with the given name
+func (m *Message) Get(name string) (Segment, bool) {
+	for _, segment := range m.Segments {
+		if segment.Name == name {
+			return segment, true
+		}
+	}
+	return Segment{}, false
+}
+
+// Type returns the message type and trigger event, e.g. ORU^R01
+func (m *Message) Type() string {
+	msh, _ := m.Get("MSH")
+	if msh.Component(9, 2) == "" {
+		return msh.Component(9, 1)
+	}
+	return msh.Component(9, 1) + "^" + msh.Component(9, 2)
+}
+
+// ControlID returns MSH-10
+func (m *Message) ControlID() string {
+	msh, _ := m.Get("MSH")
+	return msh.Field(10)
+}
+
+// Builder assembles an outgoing message with the default delimiters
+type Builder struct {
+	segments []string
+}
+
+// NewBuilder starts a message with its MSH segment. controlID is generated
+// when empty.
+func NewBuilder(messageType, sendingApp, sendingFacility, receivingApp, receivingFacility, controlID string, at time.Time) *Builder {
+	if controlID == "" {
+		controlID = NewControlID()
+	}
+	b := &Builder{}
+	b.segments = append(b.segments, strings.Join([]string{
+		"MSH", `^~\&`, Escape(sendingApp), Escape(sendingFacility), Escape(receivingApp), Escape(receivingFacility),
+		at.Format(timeLayout), "", messageType, controlID, "P", "2.3",
+	}, "|"))
+	return b
+}
+
+// Add appends a segment. Fields must already be encoded, see Escape and
+// Components.
+func (b *Builder) Add(name string, fields ...string) *Builder {
+	// Trailing empty fields are dropped, as most receivers expect
+	for len(fields) > 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	b.segments = append(b.segments, strings.Join(append([]string{name}, fields...), "|"))
+	return b
+}
+
+// AddAt appends a segment from fields keyed by their position, leaving the
+// fields in between empty
+func (b *Builder) AddAt(name string, fields map[int]string) *Builder {
+	last := 0
+	for position := range fields {
+		if position > last {
+			last = position
+		}
+	}
+	values := make([]string, last)
+	for position, value := range fields {
+		if position >= 1 {
+			values[position-1] = value
+		}
+	}
+	return b.Add(name, values...)
+}
+
+// String returns the message with CR segment terminators
+func (b *Builder) String() string {
+	return strings.Join(b.segments, "\r") + "\r"
+}
+
+// Components escapes each value and joins them into one field
+func Components(values ...string) string {
+	for i, value := range values {
+		values[i] = Escape(value)
+	}
+	return strings.TrimRight(strings.Join(values, "^"), "^")
+}
+
+// Escape encodes delimiter characters in a value for the default delimiters
+func Escape(value string) string {
+	return defaultEncoding.escapeValue(value)
+}
+
+// Timestamp formats t as an HL7 timestamp, or empty for the zero time
+func Timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeLayout)
+}
+
+// NewControlID returns a unique message control ID (MSH-10)
+func NewControlID() string {
+	raw := make([]byte, 8)
+	rand.Read(raw)
+	return "HMS" + strings.ToUpper(hex.EncodeToString(raw))
+}
+
+func (e encoding) escapeValue(value string) string {
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case e.escape:
+			out.WriteString(string(e.escape) + "E" + string(e.escape))
+		case e.field:
+			out.WriteString(string(e.escape) + "F" + string(e.escape))
+		case e.component:
+			out.WriteString(string(e.escape) + "S" + string(e.escape))
+		case e.subcomponent:
+			out.WriteString(string(e.escape) + "T" + string(e.escape))
+		case e.repetition:
+			out.WriteString(string(e.escape) + "R" + string(e.escape))
+		case '\r', '\n':
+			out.WriteString(string(e.escape) + ".br" + string(e.escape))
+		default:
+			out.WriteByte(value[i])
+		}
+	}
+	return out.String()
+}
+
+func (e encoding) unescape(value string) string {
+	if strings.IndexByte(value, e.escape) < 0 {
+		return value
+	}
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != e.escape {
+			out.WriteByte(value[i])
+			continue
+		}
+		end := strings.IndexByte(value[i+1:], e.escape)
+		if end < 0 {
+			out.WriteString(value[i:])
+			break
+		}
+		switch value[i+1 : i+1+end] {
+		case "F":
+			out.WriteByte(e.field)
+		case "S":
+			out.WriteByte(e.component)
+		case "T":
+			out.WriteByte(e.subcomponent)
+		case "R":
+			out.WriteByte(e.repetition)
+		case "E":
+			out.WriteByte(e.escape)
+		case ".br":
+			out.WriteByte('\n')
+		default:
+			// Unsupported escapes (hex, formatting) are dropped
+		}
+		i += end + 1
+	}
+	return out.String()
+}
@@ -0,0 +1,186 @@
+package hl7
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	type check struct {
+		segment          string
+		field, component int
+		want             string
+	}
+	tests := []struct {
+		name     string
+		raw      string
+		segments []string
+		checks   []check
+		err      error
+	}{
+		{
+			name:     "CR terminators",
+			raw:      "MSH|^~\\&|LIS|LAB|HMS|H1|20240101120000||ORU^R01|C1|P|2.3\rPID|1||42^^^HMS||Doe^John\r",
+			segments: []string{"MSH", "PID"},
+			checks: []check{
+				{"MSH", 1, 0, "|"},
+				{"MSH", 2, 0, "^~\\&"},
+				{"MSH", 3, 0, "LIS"},
+				{"MSH", 10, 0, "C1"},
+				{"MSH", 9, 2, "R01"},
+				{"PID", 3, 1, "42"},
+				{"PID", 5, 2, "John"},
+			},
+		},
+		{
+			name:     "LF and CRLF terminators",
+			raw:      "MSH|^~\\&|A\nOBR|1|7\r\nOBX|1|NM|GLU||5.4\n",
+			segments: []string{"MSH", "OBR", "OBX"},
+			checks: []check{
+				{"OBR", 2, 0, "7"},
+				{"OBX", 5, 0, "5.4"},
+			},
+		},
+		{
+			name:     "leading whitespace and blank lines",
+			raw:      "\r\n MSH|^~\\&|A\r\r\rPID|1\r",
+			segments: []string{"MSH", "PID"},
+		},
+		{
+			name:     "custom delimiters",
+			raw:      "MSH#$*@!#A#B\rPID#1##42$X$Y*43\r",
+			segments: []string{"MSH", "PID"},
+			checks: []check{
+				{"MSH", 1, 0, "#"},
+				{"MSH", 3, 0, "A"},
+				{"PID", 3, 0, "42$X$Y"},
+				{"PID", 3, 3, "Y"},
+			},
+		},
+		{
+			name:     "escapes and repetitions",
+			raw:      "MSH|^~\\&|A\rNTE|1||a\\F\\b\\S\\c\\E\\d\\.br\\e~second\r",
+			segments: []string{"MSH", "NTE"},
+			checks: []check{
+				{"NTE", 3, 0, "a|b^c\\d\ne"},
+			},
+		},
+		{
+			name:     "missing fields and components",
+			raw:      "MSH|^~\\&|A\rPID|1\r",
+			segments: []string{"MSH", "PID"},
+			checks: []check{
+				{"PID", 9, 0, ""},
+				{"PID", 1, 2, ""},
+				{"PID", -1, 0, ""},
+			},
+		},
+		{name: "not HL7", raw: "PID|1|2\r", err: ErrNotHL7},
+		{name: "too short", raw: "MSH|^~", err: ErrNotHL7},
+		{name: "empty", raw: "", err: ErrNotHL7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg, err := Parse(test.raw)
+			if err != test.err {
+				t.Fatalf("Parse() error = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(msg.Segments) != len(test.segments) {
+				t.Fatalf("Parse() returned %d segments, want %d", len(msg.Segments), len(test.segments))
+			}
+			for i, name := range test.segments {
+				if msg.Segments[i].Name != name {
+					t.Errorf("segment %d = %s, want %s", i, msg.Segments[i].Name, name)
+				}
+			}
+			for _, c := range test.checks {
+				segment, ok := msg.Get(c.segment)
+				if !ok {
+					t.Fatalf("segment %s not found", c.segment)
+				}
+				got := segment.Field(c.field)
+				if c.component > 0 {
+					got = segment.Component(c.field, c.component)
+				}
+				if got != c.want {
+					t.Errorf("%s-%d.%d = %q, want %q", c.segment, c.field, c.component, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageTypeAndControlID(t *testing.T) {
+	msg, err := Parse("MSH|^~\\&|A|B|C|D|20240101||ADT^A01|CTRL9|P|2.3\r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Type(); got != "ADT^A01" {
+		t.Errorf("Type() = %q, want ADT^A01", got)
+	}
+	if got := msg.ControlID(); got != "CTRL9" {
+		t.Errorf("ControlID() = %q, want CTRL9", got)
+	}
+
+	msg, _ = Parse("MSH|^~\\&|A|B|C|D|20240101||ACK|X|P|2.3\r")
+	if got := msg.Type(); got != "ACK" {
+		t.Errorf("Type() = %q, want ACK", got)
+	}
+}
+
+func TestBuilderRoundTrip(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	b := NewBuilder("ORM^O01", "HMS", "North|Wing", "LIS", "LAB", "CTRL1", at)
+	b.AddAt("PID", map[int]string{3: Components("42", "", "", "HMS"), 5: Components("O^Brien", "Pat")})
+	b.Add("NTE", "1", "", Escape("line one\nline two"), "", "")
+
+	msg, err := Parse(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msh, _ := msg.Get("MSH")
+	pid, _ := msg.Get("PID")
+	nte, _ := msg.Get("NTE")
+
+	checks := map[string][2]string{
+		"MSH-4":   {msh.Field(4), "North|Wing"},
+		"MSH-7":   {msh.Field(7), "20240301093000"},
+		"MSH-10":  {msh.Field(10), "CTRL1"},
+		"PID-3.4": {pid.Component(3, 4), "HMS"},
+		"PID-5.1": {pid.Component(5, 1), "O^Brien"},
+		"PID-5.2": {pid.Component(5, 2), "Pat"},
+		"NTE-3":   {nte.Field(3), "line one\nline two"},
+	}
+	for name, check := range checks {
+		if check[0] != check[1] {
+			t.Errorf("%s = %q, want %q", name, check[0], check[1])
+		}
+	}
+}
+
+func TestBuildAck(t *testing.T) {
+	msg, _ := Parse("MSH|^~\\&|LIS|LAB|HMS|H1|20240101||ORU^R01|C7|P|2.3\r")
+	ack, err := Parse(BuildAck(msg, AckError, "bad|value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msh, _ := ack.Get("MSH")
+	msa, _ := ack.Get("MSA")
+	if got := ack.Type(); got != "ACK^R01" {
+		t.Errorf("Type() = %q, want ACK^R01", got)
+	}
+	if msh.Field(3) != "HMS" || msh.Field(5) != "LIS" {
+		t.Errorf("ACK addressed from %q to %q, want HMS to LIS", msh.Field(3), msh.Field(5))
+	}
+	if msa.Field(1) != AckError || msa.Field(2) != "C7" || msa.Field(3) != "bad|value" {
+		t.Errorf("MSA = %q %q %q", msa.Field(1), msa.Field(2), msa.Field(3))
+	}
+
+	if _, err := Parse(BuildAck(nil, AckReject, "unparseable")); err != nil {
+		t.Errorf("ACK for nil message does not parse: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package hl7
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// MLLP frames each message as <VT> message <FS><CR>
+const (
+	startBlock    = 0x0b
+	endBlock      = 0x1c
+	carriageRet   = 0x0d
+	maxFrameBytes = 4 << 20
+)
+
+var ErrFrameTooLarge = errors.New("MLLP frame exceeds size limit")
+
+// ReadFrame reads the next MLLP frame and returns the message inside it.
+// Bytes before the start block are discarded.
+func ReadFrame(r *bufio.Reader) (string, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if b == startBlock {
+			break
+		}
+	}
+
+	var payload []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		if b == endBlock {
+			// Only <FS><CR> ends the frame; a lone FS is part of the payload
+			next, err := r.Peek(1)
+			if err != nil {
+				if err == io.EOF {
+					return "", io.ErrUnexpectedEOF
+				}
+				return "", err
+			}
+			if next[0] == carriageRet {
+				r.ReadByte()
+				return string(payload), nil
+			}
+		}
+		payload = append(payload, b)
+		if len(payload) > maxFrameBytes {
+			return "", ErrFrameTooLarge
+		}
+	}
+}
+
+// WriteFrame writes message wrapped in an MLLP frame
+func WriteFrame(w io.Writer, message string) error {
+	frame := make([]byte, 0, len(message)+3)
+	frame = append(frame, startBlock)
+	frame = append(frame, message...)
+	frame = append(frame, endBlock, carriageRet)
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,69 @@
+package hl7
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+		err   error
+	}{
+		{name: "single frame", input: "\x0bMSH|^~\\&|A\r\x1c\r", want: []string{"MSH|^~\\&|A\r"}},
+		{name: "bytes before start block", input: "noise\r\n\x0bPAYLOAD\x1c\r", want: []string{"PAYLOAD"}},
+		{name: "embedded end block", input: "\x0bA\x1cB\x1c\r", want: []string{"A\x1cB"}},
+		{name: "end block before terminator", input: "\x0bA\x1c\x1c\r", want: []string{"A\x1c"}},
+		{name: "consecutive frames", input: "\x0bONE\x1c\r\x0bTWO\x1c\r", want: []string{"ONE", "TWO"}},
+		{name: "empty frame", input: "\x0b\x1c\r", want: []string{""}},
+		{name: "no frame", input: "", err: io.EOF},
+		{name: "missing end block", input: "\x0bPAYLOAD", err: io.ErrUnexpectedEOF},
+		{name: "cut after end block", input: "\x0bPAYLOAD\x1c", err: io.ErrUnexpectedEOF},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(test.input))
+			for _, want := range test.want {
+				got, err := ReadFrame(reader)
+				if err != nil {
+					t.Fatalf("ReadFrame() error = %v", err)
+				}
+				if got != want {
+					t.Fatalf("ReadFrame() = %q, want %q", got, want)
+				}
+			}
+			if test.err != nil {
+				if _, err := ReadFrame(reader); err != test.err {
+					t.Fatalf("ReadFrame() error = %v, want %v", err, test.err)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	input := "\x0b" + strings.Repeat("x", maxFrameBytes+1) + "\x1c\r"
+	if _, err := ReadFrame(bufio.NewReader(strings.NewReader(input))); err != ErrFrameTooLarge {
+		t.Fatalf("ReadFrame() error = %v, want %v", err, ErrFrameTooLarge)
+	}
+}
+
+func TestWriteFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	message := "MSH|^~\\&|A\rPID|1\x1cX\r"
+	if err := WriteFrame(&buf, message); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadFrame(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != message {
+		t.Fatalf("round trip = %q, want %q", got, message)
+	}
+}
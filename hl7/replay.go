@@ -0,0 +1,30 @@
+package hl7
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/models"
+	"errors"
+	"time"
+)
+
+// Replay runs a logged message again. Inbound messages are re-applied through
+// the processor even if they were processed before; outbound messages are
+// resent to the peer they originally went to. The new attempt is logged with
+// ReplayOfID pointing at the original.
+func Replay(messageID uint, processor *Processor) (models.Hl7Message, error) {
+	var original models.Hl7Message
+	if err := initializers.DB.First(&original, messageID).Error; err != nil {
+		return models.Hl7Message{}, err
+	}
+
+	if original.Direction == models.Hl7DirectionOutbound {
+		sender := &Sender{Addr: original.Peer, Timeout: 30 * time.Second}
+		return sender.Send(original.Payload, original.LabOrderID, &original.ID)
+	}
+
+	_, entry := processor.Process(original.Payload, original.Peer, &original.ID)
+	if entry.Status != models.Hl7StatusProcessed {
+		return entry, errors.New(entry.Error)
+	}
+	return entry, nil
+}
@@ -0,0 +1,187 @@
+package hl7
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Handler applies an inbound message inside a transaction. It may fill in
+// fields of the log entry, such as the lab order the message concerns.
+type Handler func(tx *gorm.DB, msg *Message, entry *models.Hl7Message) error
+
+// Processor logs inbound messages, dispatches them by message type and builds
+// the ACK
+type Processor struct {
+	Handlers map[string]Handler
+}
+
+// NewProcessorFromEnv registers the ORU^R01 handler. Results are recorded as
+// entered by HL7_INTERFACE_EMPLOYEE_ID and still need verification by staff.
+func NewProcessorFromEnv() *Processor {
+	employeeID, _ := strconv.Atoi(os.Getenv("HL7_INTERFACE_EMPLOYEE_ID"))
+	return &Processor{
+		Handlers: map[string]Handler{
+			"ORU^R01": ResultHandler(uint(employeeID)),
+		},
+	}
+}
+
+// Process handles one inbound message and returns the ACK to send back along
+// with its log entry. replayOf links a replayed message to the original;
+// duplicates of an already processed message are acknowledged without being
+// applied again unless replayed.
+func (p *Processor) Process(payload, peer string, replayOf *uint) (string, models.Hl7Message) {
+	logger := loggers.InitializeLogger()
+
+	entry := models.Hl7Message{
+		Direction:  models.Hl7DirectionInbound,
+		Peer:       peer,
+		Payload:    payload,
+		Status:     models.Hl7StatusReceived,
+		ReplayOfID: replayOf,
+	}
+
+	msg, err := Parse(payload)
+	if err != nil {
+		entry.Status, entry.AckCode, entry.Error = models.Hl7StatusFailed, AckReject, err.Error()
+		p.save(&entry)
+		logger.Warn("Rejected unparseable HL7 message", "peer", peer, "error", err.Error())
+		return BuildAck(nil, AckReject, err.Error()), entry
+	}
+	entry.MessageType = msg.Type()
+	entry.ControlID = msg.ControlID()
+	p.save(&entry)
+
+	code, text := AckAccept, ""
+	handler, ok := p.Handlers[entry.MessageType]
+	switch {
+	case !ok:
+		code, text = AckReject, "Unsupported message type "+entry.MessageType
+	case replayOf == nil && p.isDuplicate(entry):
+		text = "Duplicate message ignored"
+	default:
+		err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+			return handler(tx, msg, &entry)
+		})
+		if err != nil {
+			code, text = AckError, err.Error()
+		}
+	}
+
+	now := time.Now()
+	entry.AckCode, entry.Error, entry.ProcessedAt = code, text, &now
+	entry.Status = models.Hl7StatusProcessed
+	if code != AckAccept {
+		entry.Status = models.Hl7StatusFailed
+	}
+	p.save(&entry)
+
+	logger.Info("HL7 message processed", "hl7_message_id", entry.ID, "type", entry.MessageType,
+		"control_id", entry.ControlID, "ack", code)
+	return BuildAck(msg, code, text), entry
+}
+
+func (p *Processor) isDuplicate(entry models.Hl7Message) bool {
+	if entry.ControlID == "" {
+		return false
+	}
+	var count int64
+	initializers.DB.Model(&models.Hl7Message{}).
+		Where("direction = ? AND control_id = ? AND message_type = ? AND status = ? AND id <> ?",
+			models.Hl7DirectionInbound, entry.ControlID, entry.MessageType, models.Hl7StatusProcessed, entry.ID).
+		Count(&count)
+	return count > 0
+}
+
+func (p *Processor) save(entry *models.Hl7Message) {
+	if err := initializers.DB.Save(entry).Error; err != nil {
+		loggers.InitializeLogger().Error("Failed to log HL7 message", "control_id", entry.ControlID, "error", err.Error())
+	}
+}
+
+// Acknowledger answers an inbound payload with the ACK to send back.
+// *Processor is the production implementation.
+type Acknowledger interface {
+	Process(payload, peer string, replayOf *uint) (string, models.Hl7Message)
+}
+
+// Listener accepts MLLP connections from analyzers and the LIS
+type Listener struct {
+	Addr        string
+	Processor   Acknowledger
+	IdleTimeout time.Duration
+}
+
+// NewListenerFromEnv listens on HL7_LISTEN_ADDR, e.g. ":2575"
+func NewListenerFromEnv() *Listener {
+	return &Listener{
+		Addr:        os.Getenv("HL7_LISTEN_ADDR"),
+		Processor:   NewProcessorFromEnv(),
+		IdleTimeout: 10 * time.Minute,
+	}
+}
+
+// ListenAndServe accepts connections until ctx is cancelled. Each connection
+// may carry any number of messages, each answered with an ACK.
+func (l *Listener) ListenAndServe(ctx context.Context) error {
+	logger := loggers.InitializeLogger()
+
+	listener, err := net.Listen("tcp", l.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	logger.Info("HL7 MLLP listener started", "address", listener.Addr().String())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Info("HL7 MLLP listener stopped")
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		go l.serve(conn)
+	}
+}
+
+func (l *Listener) serve(conn net.Conn) {
+	defer conn.Close()
+	logger := loggers.InitializeLogger()
+	peer := conn.RemoteAddr().String()
+	reader := bufio.NewReader(conn)
+
+	for {
+		if l.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(l.IdleTimeout))
+		}
+		payload, err := ReadFrame(reader)
+		if err != nil {
+			return
+		}
+
+		ack, _ := l.Processor.Process(payload, peer, nil)
+		if err := WriteFrame(conn, ack); err != nil {
+			logger.Warn("Failed to send HL7 ACK", "peer", peer, "error", err.Error())
+			return
+		}
+	}
+}
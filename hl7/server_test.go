@@ -0,0 +1,219 @@
+package hl7
+
+import (
+	"apps90-hms/models"
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// echoAcknowledger accepts every message without touching the database and
+// remembers what it was given
+type echoAcknowledger struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *echoAcknowledger) Process(payload, peer string, replayOf *uint) (string, models.Hl7Message) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, payload)
+	e.mu.Unlock()
+	msg, err := Parse(payload)
+	if err != nil {
+		return BuildAck(nil, AckReject, err.Error()), models.Hl7Message{}
+	}
+	return BuildAck(msg, AckAccept, ""), models.Hl7Message{}
+}
+
+func TestListenerAnswersEachFrame(t *testing.T) {
+	acknowledger := &echoAcknowledger{}
+	listener := &Listener{Addr: freeAddr(t), Processor: acknowledger, IdleTimeout: time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- listener.ListenAndServe(ctx) }()
+
+	conn := dialRetry(t, listener.Addr)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	tests := []struct {
+		payload, code, controlID string
+	}{
+		{"MSH|^~\\&|LIS|LAB|HMS|H1|20240101||ORU^R01|C1|P|2.3\rOBR|1|7\r", AckAccept, "C1"},
+		{"MSH|^~\\&|LIS|LAB|HMS|H1|20240101||ORU^R01|C2|P|2.3\r", AckAccept, "C2"},
+		{"garbage", AckReject, ""},
+	}
+	for _, test := range tests {
+		if err := WriteFrame(conn, test.payload); err != nil {
+			t.Fatal(err)
+		}
+		response, err := ReadFrame(reader)
+		if err != nil {
+			t.Fatalf("reading ACK: %v", err)
+		}
+		ack, err := Parse(response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msa, _ := ack.Get("MSA")
+		if msa.Field(1) != test.code || msa.Field(2) != test.controlID {
+			t.Errorf("ACK for %q = %s/%s, want %s/%s", test.controlID, msa.Field(1), msa.Field(2), test.code, test.controlID)
+		}
+	}
+
+	acknowledger.mu.Lock()
+	if len(acknowledger.payloads) != len(tests) {
+		t.Errorf("processed %d messages, want %d", len(acknowledger.payloads), len(tests))
+	}
+	acknowledger.mu.Unlock()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ListenAndServe() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenAndServe did not stop after cancel")
+	}
+}
+
+func TestSenderExchange(t *testing.T) {
+	payload := "MSH|^~\\&|HMS|H1|LIS|LAB|20240101||ORM^O01|C9|P|2.3\rPID|1\r"
+	tests := []struct {
+		name     string
+		respond  func(msg *Message) string
+		wantCode string
+		wantText string
+		wantErr  bool
+	}{
+		{
+			name:     "accepted",
+			respond:  func(msg *Message) string { return BuildAck(msg, AckAccept, "") },
+			wantCode: AckAccept,
+		},
+		{
+			name:     "rejected",
+			respond:  func(msg *Message) string { return BuildAck(msg, AckError, "unknown test") },
+			wantCode: AckError,
+			wantText: "unknown test",
+		},
+		{
+			name:    "no MSA segment",
+			respond: func(msg *Message) string { return "MSH|^~\\&|LIS|LAB|HMS|H1|20240101||ACK|X|P|2.3\r" },
+			wantErr: true,
+		},
+		{
+			name:    "not HL7",
+			respond: func(msg *Message) string { return "OK" },
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			received := make(chan string, 1)
+			addr := mllpStub(t, func(request string) string {
+				received <- request
+				msg, _ := Parse(request)
+				return test.respond(msg)
+			})
+
+			sender := &Sender{Addr: addr, Timeout: 5 * time.Second}
+			code, text, err := sender.exchange(payload)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("exchange() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if code != test.wantCode || text != test.wantText {
+				t.Errorf("exchange() = %q, %q, want %q, %q", code, text, test.wantCode, test.wantText)
+			}
+			if got := <-received; got != payload {
+				t.Errorf("stub received %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+func TestSenderTimesOut(t *testing.T) {
+	addr := mllpStub(t, nil)
+	sender := &Sender{Addr: addr, Timeout: 200 * time.Millisecond}
+
+	start := time.Now()
+	_, _, err := sender.exchange("MSH|^~\\&|A\r")
+	if err == nil {
+		t.Fatal("exchange() succeeded against a silent peer")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("exchange() took %v, want it to give up after the timeout", elapsed)
+	}
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("exchange() error = %v, want a timeout", err)
+	}
+}
+
+// mllpStub serves one connection on a local port, answering each frame with
+// respond. With a nil respond it reads frames and never answers.
+func mllpStub(t *testing.T, respond func(string) string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		reader := bufio.NewReader(conn)
+		for {
+			request, err := ReadFrame(reader)
+			if err != nil {
+				return
+			}
+			if respond == nil {
+				continue
+			}
+			if err := WriteFrame(conn, respond(request)); err != nil {
+				return
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// freeAddr returns a local address nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+func dialRetry(t *testing.T, addr string) net.Conn {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("listener never came up: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
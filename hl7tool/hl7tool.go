@@ -0,0 +1,122 @@
+package main
+
+import (
+	"apps90-hms/hl7"
+	"apps90-hms/initializers"
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usage = `usage:
+  hl7tool stub <listen-addr>       run an MLLP stub that prints messages and ACKs them with AA
+  hl7tool send <addr> <file>       send an HL7 message file over MLLP and print the ACK
+  hl7tool replay <message-id>      replay a logged message (needs the database)`
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "stub":
+		err = stub(os.Args[2])
+	case "send":
+		if len(os.Args) < 4 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		err = send(os.Args[2], os.Args[3])
+	case "replay":
+		err = replay(os.Args[2])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// stub stands in for a LIS or analyzer when testing outbound messages
+func stub(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	fmt.Println("MLLP stub listening on", listener.Addr())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			reader := bufio.NewReader(conn)
+			for {
+				payload, err := hl7.ReadFrame(reader)
+				if err != nil {
+					return
+				}
+				fmt.Printf("--- %s from %s\n%s\n", time.Now().Format(time.RFC3339), conn.RemoteAddr(),
+					strings.ReplaceAll(payload, "\r", "\n"))
+
+				msg, err := hl7.Parse(payload)
+				code := hl7.AckAccept
+				if err != nil {
+					code = hl7.AckReject
+				}
+				hl7.WriteFrame(conn, hl7.BuildAck(msg, code, ""))
+			}
+		}(conn)
+	}
+}
+
+// send delivers a message file, e.g. a sample ORU^R01, to an MLLP listener.
+// Line breaks in the file are converted to segment terminators.
+func send(addr, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	payload := strings.ReplaceAll(strings.ReplaceAll(string(content), "\r\n", "\r"), "\n", "\r")
+
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := hl7.WriteFrame(conn, payload); err != nil {
+		return err
+	}
+	ack, err := hl7.ReadFrame(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.ReplaceAll(ack, "\r", "\n"))
+	return nil
+}
+
+func replay(id string) error {
+	messageID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid message id %q", id)
+	}
+
+	initializers.LoadEnvVariables()
+	initializers.ConnectToDB()
+
+	entry, err := hl7.Replay(uint(messageID), hl7.NewProcessorFromEnv())
+	fmt.Printf("replay %d: status=%s ack=%s\n", entry.ID, entry.Status, entry.AckCode)
+	return err
+}
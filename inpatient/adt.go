@@ -0,0 +1,175 @@
+package inpatient
+
+import (
+	"apps90-hms/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOpenAdmission     = errors.New("patient already has an open admission")
+	ErrNotInpatient      = errors.New("visit is not an IP visit")
+	ErrAlreadyDischarged = errors.New("visit has already been discharged")
+	ErrEventBeforeLast   = errors.New("event time is before the previous ADT event")
+	ErrNothingToTransfer = errors.New("transfer needs a new bed, ward or doctor")
+)
+
+// Advisory lock namespace for admissions, used with the two-key form so it
+// never collides with single-key locks taken elsewhere
+const admissionLockSpace = 1
+
+// Admit creates the IP visit, allocates a bed when bedID or wardID is given
+// and records the admission event. A patient may only have one open admission.
+func Admit(tx *gorm.DB, visit *models.Visit, patient models.Patient, bedID, wardID *uint, notes string) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", admissionLockSpace, patient.ID).Error; err != nil {
+		return err
+	}
+
+	var open int64
+	if err := tx.Model(&models.Visit{}).
+		Where("patient_id = ? AND visit_type = ? AND discharge_date IS NULL", patient.ID, "IP").
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrOpenAdmission
+	}
+
+	visit.VisitType = "IP"
+	visit.DischargeDate = nil
+	if visit.AdmissionDate.IsZero() {
+		visit.AdmissionDate = time.Now()
+	}
+	if visit.VisitDate.IsZero() {
+		visit.VisitDate = visit.AdmissionDate
+	}
+	if err := tx.Create(visit).Error; err != nil {
+		return err
+	}
+
+	if bedID != nil || wardID != nil {
+		if _, err := AllocateBed(tx, visit, patient, bedID, wardID, visit.AdmissionDate); err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(&models.AdtEvent{
+		VisitID:    visit.ID,
+		EventType:  models.AdtEventAdmit,
+		EventTime:  visit.AdmissionDate,
+		ToBedID:    visit.BedID,
+		ToDoctorID: &visit.DoctorID,
+		Notes:      notes,
+	}).Error
+}
+
+// Transfer moves an admitted patient to another bed or ward, hands them over
+// to another doctor, or both, and records the transfer event.
+func Transfer(tx *gorm.DB, visit *models.Visit, patient models.Patient, bedID, wardID, doctorID *uint, at time.Time, notes string) error {
+	if err := checkOpen(tx, visit, at); err != nil {
+		return err
+	}
+
+	moveBed := bedID != nil || wardID != nil
+	changeDoctor := doctorID != nil && *doctorID != visit.DoctorID
+	if !moveBed && !changeDoctor {
+		return ErrNothingToTransfer
+	}
+
+	event := models.AdtEvent{
+		VisitID:   visit.ID,
+		EventType: models.AdtEventTransfer,
+		EventTime: at,
+		FromBedID: visit.BedID,
+		ToBedID:   visit.BedID,
+		Notes:     notes,
+	}
+
+	if moveBed {
+		if err := ReleaseBed(tx, visit, at); err != nil {
+			return err
+		}
+		if _, err := AllocateBed(tx, visit, patient, bedID, wardID, at); err != nil {
+			return err
+		}
+		event.ToBedID = visit.BedID
+	}
+
+	if changeDoctor {
+		previous := visit.DoctorID
+		event.FromDoctorID = &previous
+		event.ToDoctorID = doctorID
+		visit.DoctorID = *doctorID
+		if err := tx.Model(visit).Update("doctor_id", *doctorID).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(&event).Error
+}
+
+// Discharge closes the admission, releases the bed and records the discharge event
+func Discharge(tx *gorm.DB, visit *models.Visit, at time.Time, notes string) error {
+	if err := checkOpen(tx, visit, at); err != nil {
+		return err
+	}
+
+	visit.DischargeDate = &at
+	if err := tx.Model(visit).Update("discharge_date", at).Error; err != nil {
+		return err
+	}
+	if err := ReleaseBed(tx, visit, at); err != nil {
+		return err
+	}
+
+	return tx.Create(&models.AdtEvent{
+		VisitID:   visit.ID,
+		EventType: models.AdtEventDischarge,
+		EventTime: at,
+		FromBedID: visit.BedID,
+		Notes:     notes,
+	}).Error
+}
+
+// checkOpen locks and re-reads the visit, so concurrent transfers and
+// discharges of it run one after the other, then verifies it is an
+// undischarged admission and that at does not precede its latest ADT event
+func checkOpen(tx *gorm.DB, visit *models.Visit, at time.Time) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(visit, visit.ID).Error; err != nil {
+		return err
+	}
+	if visit.VisitType != "IP" {
+		return ErrNotInpatient
+	}
+	if visit.DischargeDate != nil {
+		return ErrAlreadyDischarged
+	}
+
+	last := visit.AdmissionDate
+	var latest models.AdtEvent
+	tx.Where("visit_id = ?", visit.ID).Order("event_time DESC").Limit(1).Find(&latest)
+	if latest.ID != 0 && latest.EventTime.After(last) {
+		last = latest.EventTime
+	}
+	if at.Before(last) {
+		return ErrEventBeforeLast
+	}
+	return nil
+}
+
+// IsWorkflowError reports whether err is a validation failure from the ADT
+// workflow or bed allocation rather than a database error
+func IsWorkflowError(err error) bool {
+	for _, target := range []error{
+		ErrOpenAdmission, ErrNotInpatient, ErrAlreadyDischarged, ErrEventBeforeLast, ErrNothingToTransfer,
+		ErrBedNotFound, ErrBedNotAvailable, ErrGenderMismatch, ErrNoFreeBed,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package inpatient
+
+import (
+	"apps90-hms/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrBedNotFound     = errors.New("bed not found")
+	ErrBedNotAvailable = errors.New("bed is not free")
+	ErrGenderMismatch  = errors.New("ward does not admit patients of this gender")
+	ErrNoFreeBed       = errors.New("no free bed in ward")
+)
+
+// AllocateBed puts the visit's patient in a bed. A specific bedID takes
+// precedence; otherwise the first free bed in wardID that admits the patient
+// is chosen. Only wards of the attending doctor's entity are considered. The
+// bed row is locked so two admissions cannot take the same bed.
+func AllocateBed(tx *gorm.DB, visit *models.Visit, patient models.Patient, bedID, wardID *uint, at time.Time) (*models.Bed, error) {
+	var doctor models.Employee
+	if err := tx.Select("id", "entity_id").First(&doctor, visit.DoctorID).Error; err != nil {
+		return nil, err
+	}
+
+	var bed models.Bed
+	locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Room.Ward")
+
+	if bedID != nil {
+		if err := locked.First(&bed, *bedID).Error; err != nil {
+			return nil, ErrBedNotFound
+		}
+		if bed.Room.Ward.EntityID != doctor.EntityID {
+			return nil, ErrBedNotFound
+		}
+		if bed.Status != models.BedStatusFree {
+			return nil, ErrBedNotAvailable
+		}
+		if !admitsGender(bed.Room.Ward, patient.Gender) {
+			return nil, ErrGenderMismatch
+		}
+	} else {
+		var ward models.Ward
+		if err := tx.Where("entity_id = ?", doctor.EntityID).First(&ward, *wardID).Error; err != nil {
+			return nil, ErrBedNotFound
+		}
+		if !admitsGender(ward, patient.Gender) {
+			return nil, ErrGenderMismatch
+		}
+		// Lock only the bed, not the room and ward rows joined to find it
+		if err := locked.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "bed"}}).
+			Joins("JOIN room ON room.id = bed.room_id").
+			Joins("JOIN ward ON ward.id = room.ward_id").
+			Where("room.ward_id = ? AND ward.entity_id = ? AND bed.status = ?", ward.ID, doctor.EntityID, models.BedStatusFree).
+			Order("room.room_number, bed.bed_number").Limit(1).Find(&bed).Error; err != nil {
+			return nil, err
+		}
+		if bed.ID == 0 {
+			return nil, ErrNoFreeBed
+		}
+	}
+
+	if err := tx.Model(&bed).Updates(map[string]interface{}{
+		"status":           models.BedStatusOccupied,
+		"current_visit_id": visit.ID,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	allocation := models.BedAllocation{
+		BedID:       bed.ID,
+		VisitID:     visit.ID,
+		PatientID:   visit.PatientID,
+		AllocatedAt: at,
+	}
+	if err := tx.Create(&allocation).Error; err != nil {
+		return nil, err
+	}
+
+	visit.BedID = &bed.ID
+	visit.RoomNumber = bed.Room.RoomNumber + "/" + bed.BedNumber
+	if err := tx.Model(visit).Select("bed_id", "room_number").Updates(visit).Error; err != nil {
+		return nil, err
+	}
+
+	return &bed, nil
+}
+
+// ReleaseBed frees the visit's current bed. The bed goes to cleaning rather
+// than straight back to free so housekeeping can turn it over first.
+func ReleaseBed(tx *gorm.DB, visit *models.Visit, at time.Time) error {
+	if visit.BedID == nil {
+		return nil
+	}
+
+	if err := tx.Model(&models.BedAllocation{}).
+		Where("visit_id = ? AND bed_id = ? AND released_at IS NULL", visit.ID, *visit.BedID).
+		Update("released_at", at).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Bed{}).
+		Where("id = ? AND current_visit_id = ?", *visit.BedID, visit.ID).
+		Updates(map[string]interface{}{
+			"status":           models.BedStatusCleaning,
+			"current_visit_id": nil,
+		}).Error
+}
+
+func admitsGender(ward models.Ward, gender string) bool {
+	restriction := strings.TrimSpace(ward.GenderRestriction)
+	if restriction == "" || strings.EqualFold(restriction, "Any") {
+		return true
+	}
+	return strings.EqualFold(restriction, strings.TrimSpace(gender))
+}
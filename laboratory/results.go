@@ -0,0 +1,193 @@
+package laboratory
+
+import (
+	"apps90-hms/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderNotInProgress = errors.New("results can only be entered for orders in progress")
+	ErrAnalyteNotOrdered  = errors.New("analyte is not part of this order")
+	ErrResultVerified     = errors.New("verified results cannot be changed")
+)
+
+// ResultValue is a raw value reported for one analyte
+type ResultValue struct {
+	AnalyteID uint
+	Value     string
+}
+
+// RecordResults saves values for an order that is in progress, replacing
+// earlier values that are not yet verified. Numeric values are flagged
+// against the reference range for the patient's sex and their age when the
+// sample was taken. order.Patient must be loaded.
+func RecordResults(tx *gorm.DB, order *models.LabOrder, values []ResultValue, enteredByID uint) ([]models.LabResult, error) {
+	if order.Status != models.LabOrderStatusInProgress {
+		return nil, ErrOrderNotInProgress
+	}
+
+	expected, err := ExpectedAnalytes(tx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sample models.LabSample
+	tx.Where("lab_order_id = ?", order.ID).Order("collected_at").Limit(1).Find(&sample)
+	takenAt := sample.CollectedAt
+	if sample.ID == 0 {
+		takenAt = time.Now()
+	}
+	ageDays := int(takenAt.Sub(order.Patient.DateOfBirth).Hours() / 24)
+
+	now := time.Now()
+	var results []models.LabResult
+	for _, value := range values {
+		itemID, ok := expected[value.AnalyteID]
+		if !ok {
+			return nil, ErrAnalyteNotOrdered
+		}
+
+		var analyte models.LabAnalyte
+		if err := tx.Preload("Ranges").First(&analyte, value.AnalyteID).Error; err != nil {
+			return nil, err
+		}
+
+		var result models.LabResult
+		tx.Where("lab_order_id = ? AND lab_analyte_id = ?", order.ID, analyte.ID).Find(&result)
+		if result.Status == models.LabResultStatusVerified {
+			return nil, fmt.Errorf("%w: %s", ErrResultVerified, analyte.Name)
+		}
+
+		result.LabOrderID = order.ID
+		result.LabOrderItemID = itemID
+		result.PatientID = order.PatientID
+		result.LabAnalyteID = analyte.ID
+		result.Value = strings.TrimSpace(value.Value)
+		result.Unit = analyte.Unit
+		result.NumericValue, result.RangeLow, result.RangeHigh, result.Flag = nil, nil, nil, ""
+		result.Status = models.LabResultStatusEntered
+		result.EnteredByID = enteredByID
+		result.EnteredAt = now
+
+		if number, err := strconv.ParseFloat(result.Value, 64); err == nil {
+			result.NumericValue = &number
+			if r := SelectRange(analyte.Ranges, order.Patient.Gender, ageDays); r != nil {
+				result.RangeLow, result.RangeHigh = r.Low, r.High
+				result.Flag = FlagValue(number, *r)
+			}
+		}
+
+		if err := tx.Save(&result).Error; err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// MarkReceived moves a collected order to InProgress and stamps its samples
+// as received by the lab
+func MarkReceived(tx *gorm.DB, order *models.LabOrder) error {
+	result := tx.Model(order).Where("status = ?", models.LabOrderStatusCollected).
+		Update("status", models.LabOrderStatusInProgress)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return tx.Model(&models.LabSample{}).
+		Where("lab_order_id = ? AND received_at IS NULL", order.ID).
+		Update("received_at", time.Now()).Error
+}
+
+// ExpectedAnalytes maps every analyte an order should report to the order
+// item it belongs to. Panels contribute the analytes of their components.
+func ExpectedAnalytes(tx *gorm.DB, orderID uint) (map[uint]uint, error) {
+	var items []models.LabOrderItem
+	if err := tx.Preload("LabTest.Components").Where("lab_order_id = ?", orderID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	itemByTest := map[uint]uint{}
+	for _, item := range items {
+		tests := []models.LabTest{item.LabTest}
+		if item.LabTest.IsPanel {
+			tests = item.LabTest.Components
+		}
+		for _, test := range tests {
+			if _, ok := itemByTest[test.ID]; !ok {
+				itemByTest[test.ID] = item.ID
+			}
+		}
+	}
+
+	expected := map[uint]uint{}
+	if len(itemByTest) == 0 {
+		return expected, nil
+	}
+	var testIDs []uint
+	for testID := range itemByTest {
+		testIDs = append(testIDs, testID)
+	}
+	var analytes []models.LabAnalyte
+	if err := tx.Where("lab_test_id IN ? AND is_active = ?", testIDs, true).Find(&analytes).Error; err != nil {
+		return nil, err
+	}
+	for _, analyte := range analytes {
+		expected[analyte.ID] = itemByTest[analyte.LabTestID]
+	}
+	return expected, nil
+}
+
+// SelectRange picks the reference range for a patient, preferring a range for
+// their sex over one for any sex
+func SelectRange(ranges []models.LabReferenceRange, sex string, ageDays int) *models.LabReferenceRange {
+	var fallback *models.LabReferenceRange
+	for i := range ranges {
+		r := &ranges[i]
+		if ageDays < r.AgeMinDays || (r.AgeMaxDays != nil && ageDays >= *r.AgeMaxDays) {
+			continue
+		}
+		if strings.EqualFold(r.Sex, sex) {
+			return r
+		}
+		if r.Sex == "Any" && fallback == nil {
+			fallback = r
+		}
+	}
+	return fallback
+}
+
+// FlagValue flags a value against a range. Critical limits win over the
+// normal limits.
+func FlagValue(value float64, r models.LabReferenceRange) string {
+	switch {
+	case r.CriticalLow != nil && value <= *r.CriticalLow:
+		return models.LabFlagCriticalLow
+	case r.CriticalHigh != nil && value >= *r.CriticalHigh:
+		return models.LabFlagCriticalHigh
+	case r.Low != nil && value < *r.Low:
+		return models.LabFlagLow
+	case r.High != nil && value > *r.High:
+		return models.LabFlagHigh
+	}
+	return models.LabFlagNormal
+}
+
+// IsWorkflowError reports whether err is a validation failure from result
+// entry rather than a database error
+func IsWorkflowError(err error) bool {
+	for _, target := range []error{ErrOrderNotInProgress, ErrAnalyteNotOrdered, ErrResultVerified} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"apps90-hms/hl7"
 	"apps90-hms/initializers"
+	"apps90-hms/notifications"
+	"apps90-hms/pharmacy"
 	"apps90-hms/routes"
+	"apps90-hms/scheduling"
+	"context"
+	"os"
+	"strconv"
+	"time"
 
 	"apps90-hms/loggers"
 
@@ -25,14 +33,40 @@ func main() {
 
 	router := routes.InitRoutes()
 
+	// Deliver queued notifications in the background
+	go notifications.NewWorkerFromEnv().Run(context.Background())
+
+	// Pass expired waitlist offers on to the next patient
+	go scheduling.RunOfferExpiry(context.Background(), time.Minute)
+
+	// Raise low-stock alerts and draft purchase orders from reorder rules
+	go pharmacy.RunReorderChecks(context.Background(), reorderInterval(), pharmacy.ReorderSettingsFromEnv())
+
+	// Accept HL7 results from analyzers and the LIS
+	if os.Getenv("HL7_LISTEN_ADDR") != "" {
+		go func() {
+			if err := hl7.NewListenerFromEnv().ListenAndServe(context.Background()); err != nil {
+				logger.Error("HL7 listener stopped", "error", err.Error())
+			}
+		}()
+	}
+
 	// Use the custom error-handling middleware
 	//router.Use(middlewares.APIErrorMiddleware())
 
 	// Start the server
 	logger.Info("Starting server", "address", ":8080")
 	if err := router.Run(); err != nil {
-		logger.Error("Failed to start server: %v", err)
+		logger.Error("Failed to start server", "error", err.Error())
 	}
 
 	//router.Run() // listen and serve on 0.0.0.0:3000
 }
+
+// reorderInterval is PHARMACY_REORDER_CHECK_MINUTES, hourly by default
+func reorderInterval() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("PHARMACY_REORDER_CHECK_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return time.Hour
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"apps90-hms/catalog"
+	"apps90-hms/initializers"
+	"apps90-hms/models"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const usage = `usage: medicineimport -entity <id> -file <catalog.csv|catalog.xlsx> [-dry-run] [-map field=Header,...] [-by <employee id>]
+
+Imports a medicine catalog into an entity, creating medicines and updating
+those of the same name and category. The first row must be the header;
+columns are recognised by name unless mapped. Fields: name, generic_name,
+strength, dosage_form, manufacturer, category, description, hsn_code,
+tax_rate, pack_size, mrp, selling_price. The run is recorded as an import job.`
+
+// Issues printed after a run; the job keeps all of them
+const printedIssues = 50
+
+func main() {
+	entityID := flag.Uint("entity", 0, "entity to import into")
+	path := flag.String("file", "", "CSV or XLSX file")
+	dryRun := flag.Bool("dry-run", false, "validate and report without writing")
+	spec := flag.String("map", "", "column mapping as field=Header pairs")
+	startedBy := flag.Uint("by", 0, "employee recorded as starting the import")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+	if *entityID == 0 || *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(uint(*entityID), *path, *dryRun, *spec, uint(*startedBy)); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(entityID uint, path string, dryRun bool, spec string, startedBy uint) error {
+	mapping, err := catalog.ParseMapping(spec)
+	if err != nil {
+		return err
+	}
+	format, err := catalog.Format(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	rows, err := catalog.ReadSheet(file, info.Size(), format)
+	if err != nil {
+		return err
+	}
+	prepared, err := catalog.PrepareMedicineImport(rows, mapping)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Columns: %s\n", prepared.Mapping())
+
+	initializers.LoadEnvVariables()
+	initializers.ConnectToDB()
+
+	var entity models.Entity
+	initializers.DB.First(&entity, entityID)
+	if entity.ID == 0 {
+		return fmt.Errorf("entity %d not found", entityID)
+	}
+
+	job := models.ImportJob{
+		EntityID:    entity.ID,
+		Kind:        models.ImportKindMedicine,
+		FileName:    filepath.Base(path),
+		Format:      format,
+		DryRun:      dryRun,
+		Mapping:     prepared.Mapping(),
+		Status:      models.ImportJobQueued,
+		TotalRows:   prepared.Rows(),
+		StartedByID: startedBy,
+	}
+	if err := initializers.DB.Create(&job).Error; err != nil {
+		return err
+	}
+	runErr := prepared.Run(initializers.DB, &job)
+
+	verb := "Imported"
+	if dryRun {
+		verb = "Dry run of"
+	}
+	fmt.Printf("%s %d rows into %s (import job %d): %d created, %d updated, %d unchanged, %d with issues\n",
+		verb, job.ProcessedRows, entity.Name, job.ID, job.CreatedCount, job.UpdatedCount, job.UnchangedCount, job.ErrorCount)
+	if job.Message != "" {
+		fmt.Println(job.Message)
+	}
+
+	var issues []models.ImportJobIssue
+	initializers.DB.Where("import_job_id = ?", job.ID).Order("row_number").Limit(printedIssues).Find(&issues)
+	for _, issue := range issues {
+		fmt.Printf("  row %d %s: %s\n", issue.RowNumber, issue.Name, issue.Message)
+	}
+	if job.ErrorCount > len(issues) {
+		fmt.Printf("  ... and %d more\n", job.ErrorCount-len(issues))
+	}
+	return runErr
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"apps90-hms/initializers"
 	"apps90-hms/models"
+
+	"gorm.io/gorm"
 )
 
 func init() {
@@ -19,10 +21,101 @@ func main() {
 	initializers.DB.AutoMigrate(&models.EmployeeCategory{})
 	initializers.DB.AutoMigrate(&models.Employee{})
 	initializers.DB.AutoMigrate(&models.Patient{})
+	initializers.DB.AutoMigrate(&models.AppointmentSeries{})
 	initializers.DB.AutoMigrate(&models.Appointment{})
+	initializers.DB.AutoMigrate(&models.Ward{})
+	initializers.DB.AutoMigrate(&models.Room{})
+	initializers.DB.AutoMigrate(&models.Bed{})
 	initializers.DB.AutoMigrate(&models.Visit{})
+	initializers.DB.AutoMigrate(&models.BedAllocation{})
+	initializers.DB.AutoMigrate(&models.AdtEvent{})
+	initializers.DB.AutoMigrate(&models.QueueToken{})
+	initializers.DB.AutoMigrate(&models.NotificationOutbox{})
+	initializers.DB.AutoMigrate(&models.NotificationDelivery{})
+	initializers.DB.AutoMigrate(&models.InAppNotification{})
+	initializers.DB.AutoMigrate(&models.CalendarFeedToken{})
+	initializers.DB.AutoMigrate(&models.WaitlistEntry{})
+	initializers.DB.AutoMigrate(&models.WaitlistOffer{})
+	dropGlobalNameConstraints()
 	initializers.DB.AutoMigrate(&models.MedicineCategory{})
+	renameMedicinePrice()
 	initializers.DB.AutoMigrate(&models.Medicine{})
+	initializers.DB.AutoMigrate(&models.MedicineBatch{})
+	initializers.DB.AutoMigrate(&models.StockLevel{})
+	initializers.DB.AutoMigrate(&models.StockMovement{})
+	moveLegacyStock()
 	initializers.DB.AutoMigrate(&models.Prescription{})
 	initializers.DB.AutoMigrate(&models.PrescriptionItem{})
+	initializers.DB.AutoMigrate(&models.Dispense{})
+	initializers.DB.AutoMigrate(&models.DispenseItem{})
+	initializers.DB.AutoMigrate(&models.Supplier{})
+	initializers.DB.AutoMigrate(&models.PurchaseOrder{})
+	initializers.DB.AutoMigrate(&models.PurchaseOrderItem{})
+	initializers.DB.AutoMigrate(&models.GoodsReceipt{})
+	initializers.DB.AutoMigrate(&models.GoodsReceiptItem{})
+	initializers.DB.AutoMigrate(&models.StockTransfer{})
+	initializers.DB.AutoMigrate(&models.StockTransferItem{})
+	initializers.DB.AutoMigrate(&models.StockTransferBatch{})
+	initializers.DB.AutoMigrate(&models.DischargeSummary{})
+	initializers.DB.AutoMigrate(&models.ClinicalNote{})
+	initializers.DB.AutoMigrate(&models.Referral{})
+	initializers.DB.AutoMigrate(&models.LabTest{})
+	initializers.DB.AutoMigrate(&models.LabOrder{})
+	initializers.DB.AutoMigrate(&models.LabSample{})
+	initializers.DB.AutoMigrate(&models.LabOrderItem{})
+	initializers.DB.AutoMigrate(&models.LabAnalyte{})
+	initializers.DB.AutoMigrate(&models.LabReferenceRange{})
+	initializers.DB.AutoMigrate(&models.LabResult{})
+	initializers.DB.AutoMigrate(&models.Hl7Message{})
+	initializers.DB.AutoMigrate(&models.RadiologyOrder{})
+	initializers.DB.AutoMigrate(&models.RadiologyReport{})
+	initializers.DB.AutoMigrate(&models.RadiologyAttachment{})
+	initializers.DB.AutoMigrate(&models.Document{})
+	initializers.DB.AutoMigrate(&models.ImportJob{})
+	initializers.DB.AutoMigrate(&models.ImportJobIssue{})
+}
+
+// moveLegacyStock turns the old medicine.stock counter into opening balances
+// on the stock ledger, then drops the column
+func moveLegacyStock() {
+	migrator := initializers.DB.Migrator()
+	if !migrator.HasColumn(&models.Medicine{}, "stock") {
+		return
+	}
+	initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO stock_level (entity_id, medicine_id, quantity, created_at, updated_at, is_active)
+			SELECT entity_id, id, stock, NOW(), NOW(), true FROM medicine WHERE stock > 0
+			ON CONFLICT (entity_id, medicine_id) DO NOTHING`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO stock_movement (entity_id, medicine_id, type, quantity, balance_after, reason_code, notes, performed_by_id, occurred_at, created_at, updated_at, is_active)
+			SELECT entity_id, id, ?, stock, stock, ?, 'Migrated from medicine.stock', 0, NOW(), NOW(), NOW(), true FROM medicine WHERE stock > 0`,
+			models.StockMovementAdjustment, models.StockReasonOpening).Error; err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&models.Medicine{}, "stock")
+	})
+}
+
+// renameMedicinePrice keeps prices entered before MRP was tracked separately
+// as the selling price
+func renameMedicinePrice() {
+	migrator := initializers.DB.Migrator()
+	if migrator.HasColumn(&models.Medicine{}, "price") && !migrator.HasColumn(&models.Medicine{}, "selling_price") {
+		migrator.RenameColumn(&models.Medicine{}, "price", "selling_price")
+	}
+}
+
+// dropGlobalNameConstraints removes the old catalog-wide unique constraints on
+// medicine and category names, which are now unique per entity. Postgres names
+// them <table>_name_key, or uni_<table>_name when GORM added them later.
+func dropGlobalNameConstraints() {
+	for _, table := range []string{"medicine", "medicine_category"} {
+		if !initializers.DB.Migrator().HasTable(table) {
+			continue
+		}
+		for _, constraint := range []string{table + "_name_key", "uni_" + table + "_name"} {
+			initializers.DB.Exec("ALTER TABLE " + table + " DROP CONSTRAINT IF EXISTS " + constraint)
+		}
+	}
 }
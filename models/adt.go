@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// AdtEvent records an admission, transfer or discharge of an IP visit
+type AdtEvent struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	VisitID      uint              `json:"visit_id" gorm:"index"`
+	Visit        Visit             `json:"-" gorm:"foreignKey:VisitID"`
+	EventType    string            `json:"event_type" gorm:"type:varchar(20)"`
+	EventTime    time.Time         `json:"event_time"`
+	FromBedID    *uint             `json:"from_bed_id"`
+	FromBed      *Bed              `json:"from_bed,omitempty" gorm:"foreignKey:FromBedID"`
+	ToBedID      *uint             `json:"to_bed_id"`
+	ToBed        *Bed              `json:"to_bed,omitempty" gorm:"foreignKey:ToBedID"`
+	FromDoctorID *uint             `json:"from_doctor_id"`
+	ToDoctorID   *uint             `json:"to_doctor_id"`
+	Notes        string            `json:"notes" gorm:"type:text"`
+	AuditFields  `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (AdtEvent) TableName() string {
+	return "adt_event"
+}
+
+// ADT event types
+const (
+	AdtEventAdmit     = "Admit"
+	AdtEventTransfer  = "Transfer"
+	AdtEventDischarge = "Discharge"
+)
@@ -4,23 +4,52 @@ import "time"
 
 // Appointment represents an appointment between a patient and a doctor
 type Appointment struct {
-	ID              uint              `json:"id" gorm:"primaryKey"`
-	AppointmentTime time.Time         `json:"appointment_time"`
-	Reason          string            `json:"reason"`
-	Notes           string            `json:"notes"`
-	PatientID       uint              `json:"patient_id"`
-	Patient         Patient           `json:"patient" gorm:"foreignKey:PatientID"`
-	EmployeeID      uint              `json:"employee_id"`
-	Employee        Employee          `json:"employee" gorm:"foreignKey:EmployeeID"`
-	EntityID        uint              `json:"entity_id"`
-	Entity          Entity            `json:"enity" gorm:"foreignKey:EntityID"`
-	AuditFields     `gorm:"embedded"` // Embedding AuditFields
+	ID              uint               `json:"id" gorm:"primaryKey"`
+	AppointmentTime time.Time          `json:"appointment_time"`
+	Reason          string             `json:"reason"`
+	Notes           string             `json:"notes"`
+	PatientID       uint               `json:"patient_id"`
+	Patient         Patient            `json:"patient" gorm:"foreignKey:PatientID"`
+	EmployeeID      uint               `json:"employee_id"`
+	Employee        Employee           `json:"employee" gorm:"foreignKey:EmployeeID"`
+	EntityID        uint               `json:"entity_id"`
+	Entity          Entity             `json:"enity" gorm:"foreignKey:EntityID"`
+	DurationMinutes int                `json:"duration_minutes" gorm:"default:15"`
+	Status          string             `json:"status" gorm:"type:varchar(20);default:Scheduled"`
+	SeriesID        *uint              `json:"series_id" gorm:"index"` // Nullable for one-off appointments
+	Series          *AppointmentSeries `json:"series,omitempty" gorm:"foreignKey:SeriesID"`
+	AuditFields     `gorm:"embedded"`  // Embedding AuditFields
 }
 
 func (Appointment) TableName() string {
 	return "appointment"
 }
 
+// Appointment statuses
+const (
+	AppointmentStatusScheduled = "Scheduled"
+	AppointmentStatusCancelled = "Cancelled"
+)
+
+// AppointmentSeries groups the appointments generated from a single recurrence rule
+type AppointmentSeries struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	Rule         string            `json:"rule"`      // RRULE representation, e.g. FREQ=WEEKLY;INTERVAL=1;COUNT=10
+	Frequency    string            `json:"frequency"` // DAILY or WEEKLY
+	Interval     int               `json:"interval"`
+	Count        int               `json:"count"`
+	Until        *time.Time        `json:"until"`
+	PatientID    uint              `json:"patient_id"`
+	EmployeeID   uint              `json:"employee_id"`
+	EntityID     uint              `json:"entity_id"`
+	Appointments []Appointment     `json:"appointments" gorm:"foreignKey:SeriesID"`
+	AuditFields  `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (AppointmentSeries) TableName() string {
+	return "appointment_series"
+}
+
 type Visit struct {
 	ID            uint              `json:"id" gorm:"primaryKey"`
 	AppointmentID *uint             `json:"appointment_id"` // Nullable for walk-ins
@@ -29,6 +58,8 @@ type Visit struct {
 	AdmissionDate time.Time         `json:"admission_date"`
 	DischargeDate *time.Time        `json:"discharge_date"` // Nullable for ongoing admissions
 	RoomNumber    string            `json:"room_number"`
+	BedID         *uint             `json:"bed_id"` // Bed assigned to an IP visit
+	Bed           *Bed              `json:"bed,omitempty" gorm:"foreignKey:BedID"`
 	Diagnosis     string            `json:"diagnosis"`
 	TreatmentPlan string            `json:"treatment_plan"`
 	Notes         string            `json:"notes"`
@@ -38,6 +69,7 @@ type Visit struct {
 	Doctor        Employee          `json:"doctor" gorm:"foreignKey:DoctorID"`
 	Prescriptions []Prescription    `json:"prescriptions" gorm:"foreignKey:VisitID;references:ID"`
 	VisitType     string            `json:"visit_type" `
+	ClosedAt      *time.Time        `json:"closed_at"` // Closed visits take no further prescriptions
 	AuditFields   `gorm:"embedded"` // Embedding AuditFields
 }
 
@@ -0,0 +1,15 @@
+package models
+
+// CalendarFeedToken grants read access to a doctor's iCalendar feed without a
+// login, so the feed URL can be subscribed to from a phone calendar.
+type CalendarFeedToken struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	Token       string            `json:"token" gorm:"type:varchar(64);uniqueIndex"`
+	EmployeeID  uint              `json:"employee_id" gorm:"index"`
+	Employee    Employee          `json:"employee" gorm:"foreignKey:EmployeeID"`
+	AuditFields `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (CalendarFeedToken) TableName() string {
+	return "calendar_feed_token"
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// ClinicalNote is a time-stamped, author-attributed note on a visit. Notes
+// are editable while in draft; once signed they are corrected by addenda,
+// which are themselves notes pointing at the signed note through ParentNoteID.
+type ClinicalNote struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	VisitID      uint              `json:"visit_id" gorm:"index"`
+	Visit        Visit             `json:"-" gorm:"foreignKey:VisitID"`
+	PatientID    uint              `json:"patient_id" gorm:"index"`
+	AuthorID     uint              `json:"author_id"`
+	Author       Employee          `json:"-" gorm:"foreignKey:AuthorID"`
+	NoteType     string            `json:"note_type" gorm:"type:varchar(20)"`
+	NoteTime     time.Time         `json:"note_time"`
+	Subjective   string            `json:"subjective" gorm:"type:text"`
+	Objective    string            `json:"objective" gorm:"type:text"`
+	Assessment   string            `json:"assessment" gorm:"type:text"`
+	Plan         string            `json:"plan" gorm:"type:text"`
+	Body         string            `json:"body" gorm:"type:text"` // Free text for progress, nursing notes and addenda
+	Status       string            `json:"status" gorm:"type:varchar(20)"`
+	SignedAt     *time.Time        `json:"signed_at"`
+	ParentNoteID *uint             `json:"parent_note_id" gorm:"index"`
+	Addenda      []ClinicalNote    `json:"addenda" gorm:"foreignKey:ParentNoteID"`
+	AuditFields  `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (ClinicalNote) TableName() string {
+	return "clinical_note"
+}
+
+// Clinical note types
+const (
+	NoteTypeSOAP     = "SOAP"
+	NoteTypeProgress = "Progress"
+	NoteTypeNursing  = "Nursing"
+	NoteTypeAddendum = "Addendum"
+)
+
+// Clinical note statuses
+const (
+	NoteStatusDraft  = "Draft"
+	NoteStatusSigned = "Signed"
+)
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// DischargeSummary is the consolidated document for a discharged IP visit. It
+// is generated from the visit as a draft, edited by the doctor and locked once signed.
+type DischargeSummary struct {
+	ID                   uint              `json:"id" gorm:"primaryKey"`
+	VisitID              uint              `json:"visit_id" gorm:"uniqueIndex"`
+	Visit                Visit             `json:"-" gorm:"foreignKey:VisitID"`
+	PatientID            uint              `json:"patient_id"`
+	Patient              Patient           `json:"-" gorm:"foreignKey:PatientID"`
+	DoctorID             uint              `json:"doctor_id"`
+	Doctor               Employee          `json:"-" gorm:"foreignKey:DoctorID"`
+	AdmissionDate        time.Time         `json:"admission_date"`
+	DischargeDate        *time.Time        `json:"discharge_date"`
+	Diagnosis            string            `json:"diagnosis" gorm:"type:text"`
+	HospitalCourse       string            `json:"hospital_course" gorm:"type:text"`
+	TreatmentPlan        string            `json:"treatment_plan" gorm:"type:text"`
+	Procedures           string            `json:"procedures" gorm:"type:text"`
+	Vitals               string            `json:"vitals" gorm:"type:text"`
+	Medications          string            `json:"medications" gorm:"type:text"`
+	FollowUpInstructions string            `json:"follow_up_instructions" gorm:"type:text"`
+	Status               string            `json:"status" gorm:"type:varchar(20)"`
+	SignedByID           *uint             `json:"signed_by_id"`
+	SignedBy             *Employee         `json:"-" gorm:"foreignKey:SignedByID"`
+	SignedAt             *time.Time        `json:"signed_at"`
+	AuditFields          `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (DischargeSummary) TableName() string {
+	return "discharge_summary"
+}
+
+// Discharge summary statuses
+const (
+	SummaryStatusDraft  = "Draft"
+	SummaryStatusSigned = "Signed"
+)
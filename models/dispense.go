@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Dispense is one handover of medicines by the pharmacy against a
+// prescription. A prescription may be dispensed in several parts.
+type Dispense struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	PrescriptionID uint              `json:"prescription_id" gorm:"index"`
+	EntityID       uint              `json:"entity_id"` // Pharmacy the stock came from
+	PharmacistID   uint              `json:"pharmacist_id"`
+	Pharmacist     Employee          `json:"-" gorm:"foreignKey:PharmacistID"`
+	DispensedAt    time.Time         `json:"dispensed_at"`
+	Notes          string            `json:"notes"`
+	Items          []DispenseItem    `json:"items" gorm:"foreignKey:DispenseID"`
+	AuditFields    `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Dispense) TableName() string {
+	return "dispense"
+}
+
+// DispenseItem is the quantity of a medicine handed over for one prescription
+// item. SubstitutedForID is the prescribed medicine when another one was
+// given instead.
+type DispenseItem struct {
+	ID                 uint              `json:"id" gorm:"primaryKey"`
+	DispenseID         uint              `json:"dispense_id" gorm:"index"`
+	PrescriptionItemID uint              `json:"prescription_item_id" gorm:"index"`
+	MedicineID         uint              `json:"medicine_id"`
+	Medicine           Medicine          `json:"medicine" gorm:"foreignKey:MedicineID"`
+	Quantity           int               `json:"quantity"`
+	SubstitutedForID   *uint             `json:"substituted_for_id,omitempty"`
+	SubstitutionReason string            `json:"substitution_reason,omitempty"`
+	AuditFields        `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (DispenseItem) TableName() string {
+	return "dispense_item"
+}
@@ -0,0 +1,33 @@
+package models
+
+// Document is a scanned or uploaded file kept on a patient's record, such as
+// a referral letter, an outside report or a signed consent form
+type Document struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	PatientID      uint              `json:"patient_id" gorm:"index"`
+	VisitID        *uint             `json:"visit_id,omitempty" gorm:"index"`
+	Category       string            `json:"category" gorm:"type:varchar(20)"`
+	Title          string            `json:"title"`
+	FileName       string            `json:"file_name"`
+	ContentType    string            `json:"content_type"`
+	SizeBytes      int64             `json:"size_bytes"`
+	Checksum       string            `json:"checksum" gorm:"type:char(64);index"` // SHA-256 of the content, hex
+	StorageBackend string            `json:"-" gorm:"type:varchar(10)"`
+	StorageKey     string            `json:"-"`
+	UploadedByID   uint              `json:"uploaded_by_id"`
+	UploadedBy     Employee          `json:"uploaded_by" gorm:"foreignKey:UploadedByID"`
+	AuditFields    `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Document) TableName() string {
+	return "document"
+}
+
+// Document categories
+const (
+	DocumentCategoryReferral = "Referral"
+	DocumentCategoryReport   = "Report"
+	DocumentCategoryConsent  = "Consent"
+	DocumentCategoryIdentity = "Identity"
+	DocumentCategoryOther    = "Other"
+)
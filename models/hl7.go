@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Hl7Message logs every HL7 v2 message exchanged over MLLP, inbound and
+// outbound, so failed messages can be inspected and replayed
+type Hl7Message struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	Direction   string            `json:"direction" gorm:"type:varchar(10);index"`
+	MessageType string            `json:"message_type" gorm:"type:varchar(20)"` // e.g. ORU^R01
+	ControlID   string            `json:"control_id" gorm:"type:varchar(50);index"`
+	Peer        string            `json:"peer"` // Remote address
+	Payload     string            `json:"payload" gorm:"type:text"`
+	Status      string            `json:"status" gorm:"type:varchar(20);index"`
+	AckCode     string            `json:"ack_code" gorm:"type:varchar(2)"`
+	Error       string            `json:"error" gorm:"type:text"`
+	LabOrderID  *uint             `json:"lab_order_id" gorm:"index"`
+	ReplayOfID  *uint             `json:"replay_of_id"` // Original message when this is a replay
+	ProcessedAt *time.Time        `json:"processed_at"`
+	AuditFields `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Hl7Message) TableName() string {
+	return "hl7_message"
+}
+
+// HL7 message directions
+const (
+	Hl7DirectionInbound  = "Inbound"
+	Hl7DirectionOutbound = "Outbound"
+)
+
+// HL7 message statuses. Inbound messages end Processed or Failed; outbound
+// ones Acked, Rejected (negative ACK) or Failed (no ACK).
+const (
+	Hl7StatusReceived  = "Received"
+	Hl7StatusProcessed = "Processed"
+	Hl7StatusPending   = "Pending"
+	Hl7StatusAcked     = "Acked"
+	Hl7StatusRejected  = "Rejected"
+	Hl7StatusFailed    = "Failed"
+)
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// ImportJob tracks a bulk import of a CSV or XLSX file. Large files are
+// processed in the background; counts are updated as chunks complete.
+type ImportJob struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	EntityID       uint              `json:"entity_id" gorm:"index"`
+	Kind           string            `json:"kind" gorm:"type:varchar(30)"`
+	FileName       string            `json:"file_name"`
+	Format         string            `json:"format" gorm:"type:varchar(10)"`
+	DryRun         bool              `json:"dry_run"`
+	Mapping        string            `json:"mapping" gorm:"type:text"` // Columns used, as JSON of field to header
+	Status         string            `json:"status" gorm:"type:varchar(20);index"`
+	TotalRows      int               `json:"total_rows"`
+	ProcessedRows  int               `json:"processed_rows"`
+	CreatedCount   int               `json:"created_count"`
+	UpdatedCount   int               `json:"updated_count"`
+	UnchangedCount int               `json:"unchanged_count"`
+	ErrorCount     int               `json:"error_count"`
+	Message        string            `json:"message,omitempty"`
+	StartedByID    uint              `json:"started_by_id"`
+	StartedAt      *time.Time        `json:"started_at,omitempty"`
+	FinishedAt     *time.Time        `json:"finished_at,omitempty"`
+	Issues         []ImportJobIssue  `json:"issues,omitempty" gorm:"foreignKey:ImportJobID"`
+	AuditFields    `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (ImportJob) TableName() string {
+	return "import_job"
+}
+
+// Import job statuses
+const (
+	ImportJobQueued    = "Queued"
+	ImportJobRunning   = "Running"
+	ImportJobCompleted = "Completed"
+	ImportJobFailed    = "Failed"
+)
+
+// Import kinds
+const (
+	ImportKindMedicine = "Medicine"
+)
+
+// ImportJobIssue is a row that could not be imported, or would not be in a
+// dry run
+type ImportJobIssue struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	ImportJobID uint              `json:"import_job_id" gorm:"index"`
+	RowNumber   int               `json:"row_number"`
+	Name        string            `json:"name,omitempty"` // Medicine name on the row, if any
+	Message     string            `json:"message"`
+	AuditFields `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (ImportJobIssue) TableName() string {
+	return "import_job_issue"
+}
@@ -0,0 +1,170 @@
+package models
+
+import "time"
+
+// LabTest is an entry in an entity's lab catalog. A panel groups other tests
+// of the same catalog under one orderable code.
+type LabTest struct {
+	ID              uint              `json:"id" gorm:"primaryKey"`
+	EntityID        uint              `json:"entity_id" gorm:"uniqueIndex:idx_lab_test_code"`
+	Entity          Entity            `json:"-" gorm:"foreignKey:EntityID"`
+	Code            string            `json:"code" gorm:"type:varchar(30);uniqueIndex:idx_lab_test_code"`
+	Name            string            `json:"name"`
+	SpecimenType    string            `json:"specimen_type" gorm:"type:varchar(30)"` // e.g. Blood, Serum, Urine
+	Price           float64           `json:"price"`
+	TurnaroundHours int               `json:"turnaround_hours"`
+	IsPanel         bool              `json:"is_panel"`
+	Components      []LabTest         `json:"components,omitempty" gorm:"many2many:lab_panel_item;joinForeignKey:PanelID;joinReferences:TestID"`
+	AuditFields     `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (LabTest) TableName() string {
+	return "lab_test"
+}
+
+// LabOrder is a set of tests ordered by a doctor for a visit
+type LabOrder struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	VisitID       uint              `json:"visit_id" gorm:"index"`
+	Visit         Visit             `json:"-" gorm:"foreignKey:VisitID"`
+	PatientID     uint              `json:"patient_id" gorm:"index"`
+	Patient       Patient           `json:"patient" gorm:"foreignKey:PatientID"`
+	DoctorID      uint              `json:"doctor_id"`
+	Doctor        Employee          `json:"doctor" gorm:"foreignKey:DoctorID"`
+	EntityID      uint              `json:"entity_id" gorm:"index"`
+	Priority      string            `json:"priority" gorm:"type:varchar(20)"`
+	ClinicalNotes string            `json:"clinical_notes" gorm:"type:text"`
+	Status        string            `json:"status" gorm:"type:varchar(20);index"`
+	OrderedAt     time.Time         `json:"ordered_at"`
+	DueAt         time.Time         `json:"due_at"` // Ordered time plus the longest turnaround
+	Items         []LabOrderItem    `json:"items" gorm:"foreignKey:LabOrderID"`
+	Samples       []LabSample       `json:"samples" gorm:"foreignKey:LabOrderID"`
+	AuditFields   `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (LabOrder) TableName() string {
+	return "lab_order"
+}
+
+// LabOrderItem is one catalog test (or panel) of an order, priced at order time
+type LabOrderItem struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	LabOrderID  uint              `json:"lab_order_id" gorm:"index"`
+	LabTestID   uint              `json:"lab_test_id"`
+	LabTest     LabTest           `json:"lab_test" gorm:"foreignKey:LabTestID"`
+	Price       float64           `json:"price"`
+	LabSampleID *uint             `json:"lab_sample_id"` // Sample the test is run on, once collected
+	AuditFields `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (LabOrderItem) TableName() string {
+	return "lab_order_item"
+}
+
+// LabSample is a labelled specimen collected for an order
+type LabSample struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	LabOrderID    uint              `json:"lab_order_id" gorm:"index"`
+	Barcode       string            `json:"barcode" gorm:"type:varchar(40);uniqueIndex"`
+	SpecimenType  string            `json:"specimen_type" gorm:"type:varchar(30)"`
+	CollectedAt   time.Time         `json:"collected_at"`
+	CollectedByID uint              `json:"collected_by_id"`
+	ReceivedAt    *time.Time        `json:"received_at"` // Set when the lab receives the sample
+	AuditFields   `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (LabSample) TableName() string {
+	return "lab_sample"
+}
+
+// Lab order priorities
+const (
+	LabPriorityRoutine = "Routine"
+	LabPriorityStat    = "Stat"
+)
+
+// Lab order statuses, in the order an order moves through them
+const (
+	LabOrderStatusOrdered    = "Ordered"
+	LabOrderStatusCollected  = "Collected"
+	LabOrderStatusInProgress = "InProgress"
+	LabOrderStatusResulted   = "Resulted"
+	LabOrderStatusCancelled  = "Cancelled"
+)
+
+// LabAnalyte is a single measured value of a catalog test, e.g. Hemoglobin in a CBC
+type LabAnalyte struct {
+	ID          uint                `json:"id" gorm:"primaryKey"`
+	LabTestID   uint                `json:"lab_test_id" gorm:"index"`
+	Code        string              `json:"code" gorm:"type:varchar(30)"`
+	Name        string              `json:"name"`
+	Unit        string              `json:"unit" gorm:"type:varchar(20)"`
+	SortOrder   int                 `json:"sort_order"`
+	Ranges      []LabReferenceRange `json:"ranges" gorm:"foreignKey:LabAnalyteID"`
+	AuditFields `gorm:"embedded"`   // Embedding AuditFields
+}
+
+func (LabAnalyte) TableName() string {
+	return "lab_analyte"
+}
+
+// LabReferenceRange applies to patients of the given sex whose age in days is
+// in [AgeMinDays, AgeMaxDays). Nil bounds are open.
+type LabReferenceRange struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	LabAnalyteID uint              `json:"lab_analyte_id" gorm:"index"`
+	Sex          string            `json:"sex" gorm:"type:varchar(10);default:Any"` // Male, Female or Any
+	AgeMinDays   int               `json:"age_min_days"`
+	AgeMaxDays   *int              `json:"age_max_days"`
+	Low          *float64          `json:"low"`
+	High         *float64          `json:"high"`
+	CriticalLow  *float64          `json:"critical_low"`
+	CriticalHigh *float64          `json:"critical_high"`
+	AuditFields  `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (LabReferenceRange) TableName() string {
+	return "lab_reference_range"
+}
+
+// LabResult is the value of one analyte for an order. It is released to
+// clinicians once a second user has verified it.
+type LabResult struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	LabOrderID     uint              `json:"lab_order_id" gorm:"uniqueIndex:idx_lab_result_analyte"`
+	LabOrderItemID uint              `json:"lab_order_item_id"`
+	PatientID      uint              `json:"patient_id" gorm:"index"`
+	LabAnalyteID   uint              `json:"lab_analyte_id" gorm:"uniqueIndex:idx_lab_result_analyte"`
+	LabAnalyte     LabAnalyte        `json:"lab_analyte" gorm:"foreignKey:LabAnalyteID"`
+	Value          string            `json:"value"`
+	NumericValue   *float64          `json:"numeric_value"`
+	Unit           string            `json:"unit"`
+	RangeLow       *float64          `json:"range_low"` // Reference range applied when the result was entered
+	RangeHigh      *float64          `json:"range_high"`
+	Flag           string            `json:"flag" gorm:"type:varchar(2)"`
+	Status         string            `json:"status" gorm:"type:varchar(20);index"`
+	EnteredByID    uint              `json:"entered_by_id"`
+	EnteredAt      time.Time         `json:"entered_at"`
+	VerifiedByID   *uint             `json:"verified_by_id"`
+	VerifiedAt     *time.Time        `json:"verified_at"`
+	AuditFields    `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (LabResult) TableName() string {
+	return "lab_result"
+}
+
+// Lab result flags. Non-numeric results carry no flag.
+const (
+	LabFlagNormal       = "N"
+	LabFlagLow          = "L"
+	LabFlagHigh         = "H"
+	LabFlagCriticalLow  = "LL"
+	LabFlagCriticalHigh = "HH"
+)
+
+// Lab result statuses
+const (
+	LabResultStatusEntered  = "Entered"
+	LabResultStatusVerified = "Verified"
+)
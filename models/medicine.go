@@ -2,9 +2,9 @@ package models
 
 type MedicineCategory struct {
 	ID          uint              `json:"id" gorm:"primaryKey"`
-	Name        string            `json:"name" gorm:"type:varchar(100);unique"`
+	Name        string            `json:"name" gorm:"type:varchar(100);uniqueIndex:idx_medicine_category_entity_name,priority:2"`
 	Description string            `json:"description"`
-	EntityID    uint              `json:"entity_id"` // Foreign key to Entity
+	EntityID    uint              `json:"entity_id" gorm:"uniqueIndex:idx_medicine_category_entity_name,priority:1"` // Foreign key to Entity
 	Entity      Entity            `json:"entity" gorm:"foreignKey:EntityID"`
 	Medicines   []Medicine        `json:"medicines" gorm:"foreignKey:CategoryID"`
 	AuditFields `gorm:"embedded"` // Embedding AuditFields
@@ -15,18 +15,42 @@ func (MedicineCategory) TableName() string {
 }
 
 type Medicine struct {
-	ID          uint              `json:"id" gorm:"primaryKey"`
-	Name        string            `json:"name" gorm:"type:varchar(100);unique"`
-	CategoryID  uint              `json:"category_id"` // Foreign key to MedicineCategory
-	Category    MedicineCategory  `json:"category" gorm:"foreignKey:CategoryID"`
-	EntityID    uint              `json:"entity_id"` // Foreign key to Entity
-	Entity      Entity            `json:"entity" gorm:"foreignKey:EntityID"`
-	Description string            `json:"description"`
-	Price       float64           `json:"price"`
-	Stock       int               `json:"stock"`
-	AuditFields `gorm:"embedded"` // Embedding AuditFields
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	Name         string            `json:"name" gorm:"type:varchar(100);uniqueIndex:idx_medicine_entity_name,priority:2"` // Brand or trade name, unique within the entity
+	GenericName  string            `json:"generic_name" gorm:"type:varchar(150);index"`
+	Strength     string            `json:"strength" gorm:"type:varchar(50)"` // e.g. 500 mg, 250 mg/5 ml
+	DosageForm   string            `json:"dosage_form" gorm:"type:varchar(20)"`
+	Manufacturer string            `json:"manufacturer" gorm:"type:varchar(150)"`
+	CategoryID   uint              `json:"category_id"` // Foreign key to MedicineCategory
+	Category     MedicineCategory  `json:"category" gorm:"foreignKey:CategoryID"`
+	EntityID     uint              `json:"entity_id" gorm:"uniqueIndex:idx_medicine_entity_name,priority:1"` // Foreign key to Entity
+	Entity       Entity            `json:"entity" gorm:"foreignKey:EntityID"`
+	Description  string            `json:"description"`
+	HSNCode      string            `json:"hsn_code" gorm:"type:varchar(8)"`
+	TaxRate      float64           `json:"tax_rate"`  // GST percentage of the tax class
+	PackSize     int               `json:"pack_size"` // Units per pack; stock is counted in units
+	MRP          float64           `json:"mrp"`
+	SellingPrice float64           `json:"selling_price"`
+	Batches      []MedicineBatch   `json:"batches,omitempty" gorm:"foreignKey:MedicineID"`
+	AuditFields  `gorm:"embedded"` // Embedding AuditFields
 }
 
 func (Medicine) TableName() string {
 	return "medicine"
 }
+
+// Dosage forms
+const (
+	DosageFormTablet     = "Tablet"
+	DosageFormCapsule    = "Capsule"
+	DosageFormSyrup      = "Syrup"
+	DosageFormSuspension = "Suspension"
+	DosageFormInjection  = "Injection"
+	DosageFormInfusion   = "Infusion"
+	DosageFormOintment   = "Ointment"
+	DosageFormCream      = "Cream"
+	DosageFormDrops      = "Drops"
+	DosageFormInhaler    = "Inhaler"
+	DosageFormPowder     = "Powder"
+	DosageFormOther      = "Other"
+)
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// NotificationOutbox holds an outbound message until the notification worker
+// delivers it. Rows are written in the same transaction as the change that
+// triggers them, so a message is never lost or sent for a rolled-back change.
+type NotificationOutbox struct {
+	ID            uint                   `json:"id" gorm:"primaryKey"`
+	Channel       string                 `json:"channel" gorm:"type:varchar(20)"` // email or sms
+	Recipient     string                 `json:"recipient"`
+	Subject       string                 `json:"subject"`
+	Body          string                 `json:"body" gorm:"type:text"`
+	SendAt        time.Time              `json:"send_at" gorm:"index"`
+	Status        string                 `json:"status" gorm:"type:varchar(20);index"`
+	Attempts      int                    `json:"attempts"`
+	NextAttemptAt time.Time              `json:"next_attempt_at" gorm:"index"`
+	SentAt        *time.Time             `json:"sent_at"`
+	LastError     string                 `json:"last_error" gorm:"type:text"`
+	EntityID      uint                   `json:"entity_id"`
+	AppointmentID *uint                  `json:"appointment_id" gorm:"index"`
+	Appointment   *Appointment           `json:"appointment,omitempty" gorm:"foreignKey:AppointmentID"`
+	Deliveries    []NotificationDelivery `json:"deliveries" gorm:"foreignKey:OutboxID"`
+	AuditFields   `gorm:"embedded"`      // Embedding AuditFields
+}
+
+func (NotificationOutbox) TableName() string {
+	return "notification_outbox"
+}
+
+// Notification statuses
+const (
+	NotificationStatusPending   = "Pending"
+	NotificationStatusSending   = "Sending" // Claimed by a worker until NextAttemptAt
+	NotificationStatusSent      = "Sent"
+	NotificationStatusFailed    = "Failed"
+	NotificationStatusCancelled = "Cancelled"
+)
+
+// NotificationDelivery records each attempt to deliver an outbox message
+type NotificationDelivery struct {
+	ID          uint               `json:"id" gorm:"primaryKey"`
+	OutboxID    uint               `json:"outbox_id" gorm:"index"`
+	Outbox      NotificationOutbox `json:"-" gorm:"foreignKey:OutboxID"`
+	Attempt     int                `json:"attempt"`
+	Channel     string             `json:"channel"`
+	Status      string             `json:"status"`
+	Error       string             `json:"error" gorm:"type:text"`
+	AttemptedAt time.Time          `json:"attempted_at"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_delivery"
+}
+
+// InAppNotification is an alert shown in the staff notification feed of an
+// entity, such as a low-stock warning. DedupKey keeps one unread alert per
+// condition instead of a new one every time it is checked.
+type InAppNotification struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	EntityID      uint              `json:"entity_id" gorm:"index"`
+	Category      string            `json:"category" gorm:"type:varchar(30);index"`
+	Severity      string            `json:"severity" gorm:"type:varchar(10)"`
+	Title         string            `json:"title"`
+	Body          string            `json:"body" gorm:"type:text"`
+	ReferenceType string            `json:"reference_type,omitempty" gorm:"type:varchar(30)"`
+	ReferenceID   *uint             `json:"reference_id,omitempty"`
+	DedupKey      string            `json:"-" gorm:"index"`
+	ReadAt        *time.Time        `json:"read_at"`
+	ReadByID      *uint             `json:"read_by_id,omitempty"`
+	AuditFields   `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (InAppNotification) TableName() string {
+	return "in_app_notification"
+}
+
+// In-app notification severities
+const (
+	SeverityInfo     = "Info"
+	SeverityWarning  = "Warning"
+	SeverityCritical = "Critical"
+)
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// StockMovement is one entry of the pharmacy stock ledger. Quantity is signed:
+// positive for stock coming in, negative for stock going out.
+type StockMovement struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	EntityID      uint              `json:"entity_id" gorm:"index:idx_stock_movement_card,priority:1"`
+	MedicineID    uint              `json:"medicine_id" gorm:"index:idx_stock_movement_card,priority:2"`
+	Medicine      Medicine          `json:"-" gorm:"foreignKey:MedicineID"`
+	BatchID       *uint             `json:"batch_id,omitempty" gorm:"index"` // Empty for stock not tracked by batch
+	Batch         *MedicineBatch    `json:"batch,omitempty" gorm:"foreignKey:BatchID"`
+	Type          string            `json:"type" gorm:"type:varchar(20)"`
+	Quantity      int               `json:"quantity"`
+	BalanceAfter  int               `json:"balance_after"` // Stock at the entity after this movement
+	ReasonCode    string            `json:"reason_code,omitempty" gorm:"type:varchar(20)"`
+	Notes         string            `json:"notes,omitempty"`
+	ReferenceType string            `json:"reference_type,omitempty" gorm:"type:varchar(30)"` // Document that caused the movement, e.g. Prescription
+	ReferenceID   *uint             `json:"reference_id,omitempty"`
+	PerformedByID uint              `json:"performed_by_id"`
+	OccurredAt    time.Time         `json:"occurred_at" gorm:"index:idx_stock_movement_card,priority:3"`
+	AuditFields   `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movement"
+}
+
+// Stock movement types
+const (
+	StockMovementReceipt        = "Receipt"
+	StockMovementDispense       = "Dispense"
+	StockMovementReturn         = "Return"
+	StockMovementAdjustment     = "Adjustment"
+	StockMovementExpiryWriteOff = "ExpiryWriteOff"
+	StockMovementTransferOut    = "TransferOut"
+	StockMovementTransferIn     = "TransferIn"
+)
+
+// Reason codes for returns, adjustments and write-offs
+const (
+	StockReasonPatientReturn  = "PatientReturn"
+	StockReasonSupplierReturn = "SupplierReturn"
+	StockReasonStockCount     = "StockCount"
+	StockReasonDamaged        = "Damaged"
+	StockReasonLost           = "Lost"
+	StockReasonExpired        = "Expired"
+	StockReasonOpening        = "Opening"
+	StockReasonOther          = "Other"
+)
+
+// StockLevel is the current stock of a medicine at an entity, maintained from
+// the ledger so it never has to be summed on read
+type StockLevel struct {
+	ID                  uint              `json:"id" gorm:"primaryKey"`
+	EntityID            uint              `json:"entity_id" gorm:"uniqueIndex:idx_stock_level_entity_medicine"`
+	MedicineID          uint              `json:"medicine_id" gorm:"uniqueIndex:idx_stock_level_entity_medicine"`
+	Medicine            Medicine          `json:"medicine" gorm:"foreignKey:MedicineID"`
+	Quantity            int               `json:"quantity"`
+	ReorderLevel        int               `json:"reorder_level"`    // Reorder when stock falls to this; 0 disables the rule
+	ReorderQuantity     int               `json:"reorder_quantity"` // Units to order, 0 to size the order from consumption
+	PreferredSupplierID *uint             `json:"preferred_supplier_id,omitempty"`
+	AuditFields         `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (StockLevel) TableName() string {
+	return "stock_level"
+}
+
+// MedicineBatch is a lot of a medicine held at an entity. Quantity is the
+// part of the entity's stock level that belongs to this batch.
+type MedicineBatch struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	EntityID      uint              `json:"entity_id" gorm:"uniqueIndex:idx_medicine_batch_number"`
+	MedicineID    uint              `json:"medicine_id" gorm:"uniqueIndex:idx_medicine_batch_number"`
+	Medicine      *Medicine         `json:"medicine,omitempty" gorm:"foreignKey:MedicineID"`
+	BatchNumber   string            `json:"batch_number" gorm:"type:varchar(50);uniqueIndex:idx_medicine_batch_number"`
+	ExpiryDate    time.Time         `json:"expiry_date" gorm:"type:date;index"`
+	MRP           float64           `json:"mrp"`
+	PurchasePrice float64           `json:"purchase_price"`
+	Quantity      int               `json:"quantity"`
+	AuditFields   `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (MedicineBatch) TableName() string {
+	return "medicine_batch"
+}
+
+// Expired reports whether the batch is past its expiry date on day
+func (b MedicineBatch) Expired(day time.Time) bool {
+	y, m, d := day.Date()
+	return b.ExpiryDate.Before(time.Date(y, m, d, 0, 0, 0, 0, b.ExpiryDate.Location()))
+}
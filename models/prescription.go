@@ -13,6 +13,7 @@ type Prescription struct {
 	Doctor            Employee           `json:"doctor" gorm:"foreignKey:DoctorID"`
 	DateIssued        time.Time          `json:"date_issued"`
 	Notes             string             `json:"notes"`
+	DispenseStatus    string             `json:"dispense_status" gorm:"type:varchar(20);default:NotDispensed"`
 	PrescriptionItems []PrescriptionItem `json:"items" gorm:"foreignKey:PrescriptionID"`
 	AuditFields       `gorm:"embedded"`
 }
@@ -27,8 +28,19 @@ type PrescriptionItem struct {
 	PrescriptionID      uint         `json:"prescription_id"`
 	Prescription        Prescription `json:"prescription" gorm:"foreignKey:PrescriptionID"`
 	PrescriptionDetails string       `json:"prescription_details" gorm:"type:text"` // Added prescription details
+	MedicineID          *uint        `json:"medicine_id,omitempty"`                 // Set when the item names a catalog medicine
+	Medicine            *Medicine    `json:"medicine,omitempty" gorm:"foreignKey:MedicineID"`
+	Quantity            int          `json:"quantity"`           // Units to dispense, 0 when not specified
+	DispensedQuantity   int          `json:"dispensed_quantity"` // Units handed over so far
 }
 
 func (PrescriptionItem) TableName() string {
 	return "prescription_item"
 }
+
+// Prescription dispense statuses
+const (
+	PrescriptionNotDispensed       = "NotDispensed"
+	PrescriptionPartiallyDispensed = "PartiallyDispensed"
+	PrescriptionDispensed          = "Dispensed"
+)
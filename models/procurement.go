@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// Supplier is a vendor an entity buys medicines from
+type Supplier struct {
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	EntityID         uint              `json:"entity_id" gorm:"uniqueIndex:idx_supplier_entity_name"`
+	Name             string            `json:"name" gorm:"type:varchar(150);uniqueIndex:idx_supplier_entity_name"`
+	ContactPerson    string            `json:"contact_person"`
+	Phone            string            `json:"phone" gorm:"type:varchar(20)"`
+	Email            string            `json:"email"`
+	Address          string            `json:"address"`
+	TaxNumber        string            `json:"tax_number" gorm:"type:varchar(30)"` // GSTIN or similar
+	DrugLicense      string            `json:"drug_license" gorm:"type:varchar(50)"`
+	PaymentTermsDays int               `json:"payment_terms_days"`
+	AuditFields      `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Supplier) TableName() string {
+	return "supplier"
+}
+
+// PurchaseOrder is an order for medicines placed with a supplier. It must be
+// approved by someone other than the person who raised it before goods can
+// be received against it.
+type PurchaseOrder struct {
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	EntityID     uint                `json:"entity_id" gorm:"index"`
+	SupplierID   uint                `json:"supplier_id" gorm:"index"`
+	Supplier     Supplier            `json:"supplier" gorm:"foreignKey:SupplierID"`
+	Status       string              `json:"status" gorm:"type:varchar(20);index"`
+	OrderedByID  uint                `json:"ordered_by_id"`
+	ApprovedByID *uint               `json:"approved_by_id,omitempty"`
+	ApprovedAt   *time.Time          `json:"approved_at,omitempty"`
+	ExpectedAt   *time.Time          `json:"expected_at,omitempty" gorm:"type:date"`
+	Notes        string              `json:"notes"`
+	TotalAmount  float64             `json:"total_amount"`
+	Items        []PurchaseOrderItem `json:"items" gorm:"foreignKey:PurchaseOrderID"`
+	AuditFields  `gorm:"embedded"`   // Embedding AuditFields
+}
+
+func (PurchaseOrder) TableName() string {
+	return "purchase_order"
+}
+
+// Purchase order statuses
+const (
+	PurchaseOrderDraft             = "Draft"
+	PurchaseOrderApproved          = "Approved"
+	PurchaseOrderPartiallyReceived = "PartiallyReceived"
+	PurchaseOrderReceived          = "Received"
+	PurchaseOrderCancelled         = "Cancelled"
+)
+
+type PurchaseOrderItem struct {
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID  uint              `json:"purchase_order_id" gorm:"index"`
+	MedicineID       uint              `json:"medicine_id"`
+	Medicine         Medicine          `json:"medicine" gorm:"foreignKey:MedicineID"`
+	Quantity         int               `json:"quantity"`
+	UnitPrice        float64           `json:"unit_price"`
+	ReceivedQuantity int               `json:"received_quantity"` // Accepted into stock so far
+	AuditFields      `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (PurchaseOrderItem) TableName() string {
+	return "purchase_order_item"
+}
+
+// GoodsReceipt (GRN) records a delivery against a purchase order. Accepted
+// quantities are posted to the stock ledger as receipts into batches.
+type GoodsReceipt struct {
+	ID                uint               `json:"id" gorm:"primaryKey"`
+	PurchaseOrderID   uint               `json:"purchase_order_id" gorm:"index"`
+	EntityID          uint               `json:"entity_id"`
+	SupplierID        uint               `json:"supplier_id"`
+	SupplierInvoiceNo string             `json:"supplier_invoice_no" gorm:"type:varchar(50)"`
+	ReceivedByID      uint               `json:"received_by_id"`
+	ReceivedAt        time.Time          `json:"received_at"`
+	Notes             string             `json:"notes"`
+	Items             []GoodsReceiptItem `json:"items" gorm:"foreignKey:GoodsReceiptID"`
+	AuditFields       `gorm:"embedded"`  // Embedding AuditFields
+}
+
+func (GoodsReceipt) TableName() string {
+	return "goods_receipt"
+}
+
+type GoodsReceiptItem struct {
+	ID                  uint              `json:"id" gorm:"primaryKey"`
+	GoodsReceiptID      uint              `json:"goods_receipt_id" gorm:"index"`
+	PurchaseOrderItemID uint              `json:"purchase_order_item_id" gorm:"index"`
+	MedicineID          uint              `json:"medicine_id"`
+	BatchID             *uint             `json:"batch_id,omitempty"`
+	BatchNumber         string            `json:"batch_number" gorm:"type:varchar(50)"`
+	ExpiryDate          time.Time         `json:"expiry_date" gorm:"type:date"`
+	Quantity            int               `json:"quantity"`          // Accepted into stock
+	RejectedQuantity    int               `json:"rejected_quantity"` // Delivered but refused, e.g. damaged
+	RejectionReason     string            `json:"rejection_reason,omitempty"`
+	UnitPrice           float64           `json:"unit_price"` // As invoiced
+	MRP                 float64           `json:"mrp"`
+	AuditFields         `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (GoodsReceiptItem) TableName() string {
+	return "goods_receipt_item"
+}
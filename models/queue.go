@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// QueueToken is a numbered place in a doctor's outpatient queue for a given day
+type QueueToken struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	TokenNumber   int               `json:"token_number" gorm:"uniqueIndex:idx_queue_token"`
+	QueueDate     time.Time         `json:"queue_date" gorm:"type:date;uniqueIndex:idx_queue_token"`
+	DoctorID      uint              `json:"doctor_id" gorm:"uniqueIndex:idx_queue_token"`
+	Doctor        Employee          `json:"doctor" gorm:"foreignKey:DoctorID"`
+	EntityID      uint              `json:"entity_id"`
+	Entity        Entity            `json:"entity" gorm:"foreignKey:EntityID"`
+	PatientID     uint              `json:"patient_id"`
+	Patient       Patient           `json:"patient" gorm:"foreignKey:PatientID"`
+	AppointmentID *uint             `json:"appointment_id"` // Nullable for walk-ins
+	Appointment   *Appointment      `json:"appointment" gorm:"foreignKey:AppointmentID"`
+	Status        string            `json:"status" gorm:"type:varchar(20)"`
+	CheckedInAt   time.Time         `json:"checked_in_at"`
+	CalledAt      *time.Time        `json:"called_at"`
+	CompletedAt   *time.Time        `json:"completed_at"`
+	AuditFields   `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (QueueToken) TableName() string {
+	return "queue_token"
+}
+
+// Queue token statuses
+const (
+	TokenStatusWaiting   = "Waiting"
+	TokenStatusCalled    = "Called"
+	TokenStatusSkipped   = "Skipped"
+	TokenStatusCompleted = "Completed"
+)
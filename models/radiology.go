@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// RadiologyOrder is an imaging study ordered for a visit
+type RadiologyOrder struct {
+	ID                 uint                  `json:"id" gorm:"primaryKey"`
+	VisitID            uint                  `json:"visit_id" gorm:"index"`
+	PatientID          uint                  `json:"patient_id" gorm:"index"`
+	Patient            Patient               `json:"patient" gorm:"foreignKey:PatientID"`
+	DoctorID           uint                  `json:"doctor_id"`
+	Doctor             Employee              `json:"doctor" gorm:"foreignKey:DoctorID"`
+	EntityID           uint                  `json:"entity_id" gorm:"index"`
+	Modality           string                `json:"modality" gorm:"type:varchar(10)"` // DICOM modality code, e.g. CR, CT, MR, US
+	BodyPart           string                `json:"body_part"`
+	Laterality         string                `json:"laterality" gorm:"type:varchar(10)"`
+	Priority           string                `json:"priority" gorm:"type:varchar(20)"`
+	ClinicalIndication string                `json:"clinical_indication" gorm:"type:text"`
+	Status             string                `json:"status" gorm:"type:varchar(20);index"`
+	ScheduledAt        *time.Time            `json:"scheduled_at" gorm:"index"`
+	Room               string                `json:"room"` // Scanner or room the study is booked in
+	PerformedAt        *time.Time            `json:"performed_at"`
+	Report             *RadiologyReport      `json:"report,omitempty" gorm:"foreignKey:RadiologyOrderID"`
+	Attachments        []RadiologyAttachment `json:"attachments" gorm:"foreignKey:RadiologyOrderID"`
+	AuditFields        `gorm:"embedded"`     // Embedding AuditFields
+}
+
+func (RadiologyOrder) TableName() string {
+	return "radiology_order"
+}
+
+// Radiology order statuses
+const (
+	RadiologyStatusOrdered   = "Ordered"
+	RadiologyStatusScheduled = "Scheduled"
+	RadiologyStatusPerformed = "Performed"
+	RadiologyStatusReported  = "Reported"
+	RadiologyStatusCancelled = "Cancelled"
+)
+
+// RadiologyReport holds the radiologist's reading of a study. Final reports
+// are locked.
+type RadiologyReport struct {
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	RadiologyOrderID uint              `json:"radiology_order_id" gorm:"uniqueIndex"`
+	RadiologistID    uint              `json:"radiologist_id"`
+	Radiologist      Employee          `json:"radiologist" gorm:"foreignKey:RadiologistID"`
+	Findings         string            `json:"findings" gorm:"type:text"`
+	Impression       string            `json:"impression" gorm:"type:text"`
+	Status           string            `json:"status" gorm:"type:varchar(20)"`
+	FinalizedAt      *time.Time        `json:"finalized_at"`
+	AuditFields      `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (RadiologyReport) TableName() string {
+	return "radiology_report"
+}
+
+// Radiology report statuses
+const (
+	RadiologyReportDraft = "Draft"
+	RadiologyReportFinal = "Final"
+)
+
+// RadiologyAttachment is an exported image or PDF report attached to an order
+type RadiologyAttachment struct {
+	ID               uint              `json:"id" gorm:"primaryKey"`
+	RadiologyOrderID uint              `json:"radiology_order_id" gorm:"index"`
+	Kind             string            `json:"kind" gorm:"type:varchar(10)"`
+	FileName         string            `json:"file_name"`
+	ContentType      string            `json:"content_type"`
+	SizeBytes        int64             `json:"size_bytes"`
+	StorageBackend   string            `json:"-" gorm:"type:varchar(10)"`
+	StorageKey       string            `json:"-"`
+	UploadedByID     uint              `json:"uploaded_by_id"`
+	AuditFields      `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (RadiologyAttachment) TableName() string {
+	return "radiology_attachment"
+}
+
+// Radiology attachment kinds
+const (
+	RadiologyAttachmentImage  = "Image"
+	RadiologyAttachmentReport = "Report"
+)
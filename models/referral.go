@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Referral hands a patient's visit over to another doctor, or to a department
+// of a (possibly different) entity when no particular doctor is named.
+type Referral struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	PatientID      uint              `json:"patient_id"`
+	Patient        Patient           `json:"patient" gorm:"foreignKey:PatientID"`
+	VisitID        uint              `json:"visit_id"` // The visit whose data is shared with the receiver
+	Visit          Visit             `json:"-" gorm:"foreignKey:VisitID"`
+	FromDoctorID   uint              `json:"from_doctor_id" gorm:"index"`
+	FromDoctor     Employee          `json:"from_doctor" gorm:"foreignKey:FromDoctorID"`
+	FromEntityID   uint              `json:"from_entity_id"`
+	ToDoctorID     *uint             `json:"to_doctor_id" gorm:"index"` // Nullable for department referrals
+	ToDoctor       *Employee         `json:"to_doctor,omitempty" gorm:"foreignKey:ToDoctorID"`
+	Department     string            `json:"department"`
+	TargetEntityID uint              `json:"target_entity_id" gorm:"index"`
+	TargetEntity   Entity            `json:"target_entity" gorm:"foreignKey:TargetEntityID"`
+	Reason         string            `json:"reason" gorm:"type:text"`
+	Urgency        string            `json:"urgency" gorm:"type:varchar(20)"`
+	Status         string            `json:"status" gorm:"type:varchar(20);index"`
+	ResponseNotes  string            `json:"response_notes"`
+	RespondedAt    *time.Time        `json:"responded_at"`
+	AppointmentID  *uint             `json:"appointment_id"` // Set once the referral is accepted
+	AuditFields    `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Referral) TableName() string {
+	return "referral"
+}
+
+// Referral urgencies
+const (
+	ReferralUrgencyRoutine   = "Routine"
+	ReferralUrgencyUrgent    = "Urgent"
+	ReferralUrgencyEmergency = "Emergency"
+)
+
+// Referral statuses
+const (
+	ReferralStatusPending   = "Pending"
+	ReferralStatusAccepted  = "Accepted"
+	ReferralStatusDeclined  = "Declined"
+	ReferralStatusCancelled = "Cancelled"
+)
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// StockTransfer moves medicines from one entity to another. The receiving
+// entity requests it, the sending entity approves and dispatches it, and
+// stock is in transit until the receiving entity records its arrival.
+type StockTransfer struct {
+	ID              uint                `json:"id" gorm:"primaryKey"`
+	FromEntityID    uint                `json:"from_entity_id" gorm:"index"`
+	FromEntity      Entity              `json:"from_entity" gorm:"foreignKey:FromEntityID"`
+	ToEntityID      uint                `json:"to_entity_id" gorm:"index"`
+	ToEntity        Entity              `json:"to_entity" gorm:"foreignKey:ToEntityID"`
+	Status          string              `json:"status" gorm:"type:varchar(20);index"`
+	RequestedByID   uint                `json:"requested_by_id"`
+	ApprovedByID    *uint               `json:"approved_by_id,omitempty"`
+	ApprovedAt      *time.Time          `json:"approved_at,omitempty"`
+	DispatchedByID  *uint               `json:"dispatched_by_id,omitempty"`
+	DispatchedAt    *time.Time          `json:"dispatched_at,omitempty"`
+	ReceivedByID    *uint               `json:"received_by_id,omitempty"`
+	ReceivedAt      *time.Time          `json:"received_at,omitempty"`
+	Notes           string              `json:"notes"`
+	RejectionReason string              `json:"rejection_reason,omitempty"`
+	Items           []StockTransferItem `json:"items" gorm:"foreignKey:StockTransferID"`
+	AuditFields     `gorm:"embedded"`   // Embedding AuditFields
+}
+
+func (StockTransfer) TableName() string {
+	return "stock_transfer"
+}
+
+// Stock transfer statuses
+const (
+	StockTransferRequested  = "Requested"
+	StockTransferApproved   = "Approved"
+	StockTransferRejected   = "Rejected"
+	StockTransferDispatched = "Dispatched" // In transit
+	StockTransferReceived   = "Received"
+	StockTransferCancelled  = "Cancelled"
+)
+
+type StockTransferItem struct {
+	ID              uint     `json:"id" gorm:"primaryKey"`
+	StockTransferID uint     `json:"stock_transfer_id" gorm:"index"`
+	MedicineID      uint     `json:"medicine_id"` // In the sending entity's catalog
+	Medicine        Medicine `json:"medicine" gorm:"foreignKey:MedicineID"`
+	// The same medicine in the receiving entity's catalog, which received
+	// stock is posted against
+	DestinationMedicineID uint                 `json:"destination_medicine_id"`
+	DestinationMedicine   Medicine             `json:"destination_medicine" gorm:"foreignKey:DestinationMedicineID"`
+	RequestedQuantity     int                  `json:"requested_quantity"`
+	DispatchedQuantity    int                  `json:"dispatched_quantity"`
+	ReceivedQuantity      int                  `json:"received_quantity"`
+	Batches               []StockTransferBatch `json:"batches" gorm:"foreignKey:StockTransferItemID"`
+	AuditFields           `gorm:"embedded"`    // Embedding AuditFields
+}
+
+func (StockTransferItem) TableName() string {
+	return "stock_transfer_item"
+}
+
+// StockTransferBatch is the part of a transfer item dispatched from one
+// batch, or from stock not tracked by batch when SourceBatchID is nil. Units
+// that do not arrive are taken in and written off as lost at the receiving
+// entity, so the ledgers of both entities account for every unit.
+type StockTransferBatch struct {
+	ID                  uint              `json:"id" gorm:"primaryKey"`
+	StockTransferItemID uint              `json:"stock_transfer_item_id" gorm:"index"`
+	SourceBatchID       *uint             `json:"source_batch_id,omitempty"`
+	DestinationBatchID  *uint             `json:"destination_batch_id,omitempty"`
+	BatchNumber         string            `json:"batch_number,omitempty" gorm:"type:varchar(50)"`
+	ExpiryDate          *time.Time        `json:"expiry_date,omitempty" gorm:"type:date"`
+	MRP                 float64           `json:"mrp"`
+	PurchasePrice       float64           `json:"purchase_price"`
+	Quantity            int               `json:"quantity"`          // Dispatched
+	ReceivedQuantity    int               `json:"received_quantity"` // Arrived in usable condition
+	ShortQuantity       int               `json:"short_quantity"`    // Missing or damaged on arrival
+	AuditFields         `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (StockTransferBatch) TableName() string {
+	return "stock_transfer_batch"
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// WaitlistEntry parks a request for a fully-booked doctor until a slot frees up.
+// Priority 1 is the most urgent; entries of equal priority are served first come, first served.
+type WaitlistEntry struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	PatientID     uint              `json:"patient_id"`
+	Patient       Patient           `json:"patient" gorm:"foreignKey:PatientID"`
+	DoctorID      uint              `json:"doctor_id" gorm:"index"`
+	Doctor        Employee          `json:"doctor" gorm:"foreignKey:DoctorID"`
+	EntityID      uint              `json:"entity_id"`
+	Entity        Entity            `json:"entity" gorm:"foreignKey:EntityID"`
+	PreferredFrom time.Time         `json:"preferred_from" gorm:"type:date"`
+	PreferredTo   time.Time         `json:"preferred_to" gorm:"type:date"`
+	Priority      int               `json:"priority" gorm:"default:3"`
+	Reason        string            `json:"reason"`
+	Status        string            `json:"status" gorm:"type:varchar(20);index"`
+	Offers        []WaitlistOffer   `json:"offers" gorm:"foreignKey:WaitlistEntryID"`
+	AuditFields   `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (WaitlistEntry) TableName() string {
+	return "waitlist_entry"
+}
+
+// Waitlist entry statuses
+const (
+	WaitlistStatusWaiting = "Waiting"
+	WaitlistStatusOffered = "Offered"
+	WaitlistStatusBooked  = "Booked"
+	WaitlistStatusRemoved = "Removed"
+)
+
+// WaitlistOffer is a freed slot held for a waitlisted patient until it expires
+type WaitlistOffer struct {
+	ID                  uint              `json:"id" gorm:"primaryKey"`
+	WaitlistEntryID     uint              `json:"waitlist_entry_id" gorm:"index"`
+	SlotTime            time.Time         `json:"slot_time"`
+	DurationMinutes     int               `json:"duration_minutes"`
+	ExpiresAt           time.Time         `json:"expires_at" gorm:"index"`
+	Status              string            `json:"status" gorm:"type:varchar(20);index"`
+	SourceAppointmentID uint              `json:"source_appointment_id"` // The cancelled appointment that freed the slot
+	AppointmentID       *uint             `json:"appointment_id"`        // Set once the offer is accepted
+	AuditFields         `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (WaitlistOffer) TableName() string {
+	return "waitlist_offer"
+}
+
+// Waitlist offer statuses
+const (
+	OfferStatusPending  = "Pending"
+	OfferStatusAccepted = "Accepted"
+	OfferStatusDeclined = "Declined"
+	OfferStatusExpired  = "Expired"
+)
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Ward groups rooms within an entity, e.g. a general male ward or an ICU
+type Ward struct {
+	ID                uint              `json:"id" gorm:"primaryKey"`
+	Name              string            `json:"name" gorm:"type:varchar(100)"`
+	WardType          string            `json:"ward_type" gorm:"type:varchar(50)"`
+	GenderRestriction string            `json:"gender_restriction" gorm:"type:varchar(10);default:Any"` // Male, Female or Any
+	EntityID          uint              `json:"entity_id" gorm:"index"`
+	Entity            Entity            `json:"entity" gorm:"foreignKey:EntityID"`
+	Rooms             []Room            `json:"rooms" gorm:"foreignKey:WardID"`
+	AuditFields       `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Ward) TableName() string {
+	return "ward"
+}
+
+type Room struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	RoomNumber  string            `json:"room_number" gorm:"type:varchar(20)"`
+	RoomType    string            `json:"room_type" gorm:"type:varchar(50)"` // e.g. General, Semi-private, Private
+	DailyRate   float64           `json:"daily_rate"`
+	WardID      uint              `json:"ward_id" gorm:"index"`
+	Ward        Ward              `json:"ward" gorm:"foreignKey:WardID"`
+	Beds        []Bed             `json:"beds" gorm:"foreignKey:RoomID"`
+	AuditFields `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Room) TableName() string {
+	return "room"
+}
+
+type Bed struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	BedNumber      string            `json:"bed_number" gorm:"type:varchar(20)"`
+	Status         string            `json:"status" gorm:"type:varchar(20);default:Free"`
+	RoomID         uint              `json:"room_id" gorm:"index"`
+	Room           Room              `json:"room" gorm:"foreignKey:RoomID"`
+	CurrentVisitID *uint             `json:"current_visit_id"` // Set while the bed is occupied
+	AuditFields    `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (Bed) TableName() string {
+	return "bed"
+}
+
+// Bed statuses
+const (
+	BedStatusFree     = "Free"
+	BedStatusOccupied = "Occupied"
+	BedStatusCleaning = "Cleaning"
+	BedStatusBlocked  = "Blocked"
+)
+
+// BedAllocation records each period a visit spent in a bed
+type BedAllocation struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	BedID       uint              `json:"bed_id" gorm:"index"`
+	Bed         Bed               `json:"bed" gorm:"foreignKey:BedID"`
+	VisitID     uint              `json:"visit_id" gorm:"index"`
+	PatientID   uint              `json:"patient_id"`
+	AllocatedAt time.Time         `json:"allocated_at"`
+	ReleasedAt  *time.Time        `json:"released_at"` // Nullable while the bed is in use
+	AuditFields `gorm:"embedded"` // Embedding AuditFields
+}
+
+func (BedAllocation) TableName() string {
+	return "bed_allocation"
+}
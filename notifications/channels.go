@@ -0,0 +1,222 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a single notification ready for delivery
+type Message struct {
+	Recipient string
+	Subject   string
+	Body      string
+}
+
+// Channel delivers messages over one transport such as email or SMS
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPChannel sends email through an SMTP relay
+type SMTPChannel struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration // Limit on the whole exchange, 30 seconds if zero
+}
+
+func (s *SMTPChannel) Name() string {
+	return "email"
+}
+
+func (s *SMTPChannel) Send(ctx context.Context, msg Message) error {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, s.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	recipient := headerValue(msg.Recipient)
+	if err := client.Mail(headerValue(s.From)); err != nil {
+		return err
+	}
+	if err := client.Rcpt(recipient); err != nil {
+		return err
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	body := "From: " + headerValue(s.From) + "\r\n" +
+		"To: " + recipient + "\r\n" +
+		"Subject: " + headerValue(msg.Subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		msg.Body
+	if _, err := data.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// headerValue removes line breaks so a value cannot add mail headers
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// HTTPSMSChannel posts SMS messages as JSON to a generic gateway webhook
+type HTTPSMSChannel struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (h *HTTPSMSChannel) Name() string {
+	return "sms"
+}
+
+func (h *HTTPSMSChannel) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]string{
+		"to":      msg.Recipient,
+		"message": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sms gateway returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// FakeChannel records messages in memory instead of sending them. It stands in
+// for real transports in local development and tests.
+type FakeChannel struct {
+	ChannelName string
+	FailWith    error
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (f *FakeChannel) Name() string {
+	return f.ChannelName
+}
+
+func (f *FakeChannel) Send(ctx context.Context, msg Message) error {
+	if f.FailWith != nil {
+		return f.FailWith
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// Sent returns the messages recorded so far
+func (f *FakeChannel) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.sent...)
+}
+
+// ChannelsFromEnv builds the configured channels keyed by name. Setting
+// NOTIFICATION_CHANNELS=fake replaces every transport with a FakeChannel.
+func ChannelsFromEnv() map[string]Channel {
+	channels := map[string]Channel{}
+
+	if strings.EqualFold(os.Getenv("NOTIFICATION_CHANNELS"), "fake") {
+		channels["email"] = &FakeChannel{ChannelName: "email"}
+		channels["sms"] = &FakeChannel{ChannelName: "sms"}
+		return channels
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		channels["email"] = &SMTPChannel{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		}
+	}
+
+	if url := os.Getenv("SMS_GATEWAY_URL"); url != "" {
+		channels["sms"] = &HTTPSMSChannel{
+			URL:   url,
+			Token: os.Getenv("SMS_GATEWAY_TOKEN"),
+		}
+	}
+
+	return channels
+}
@@ -0,0 +1,108 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpStub accepts one SMTP session on a local port and returns the DATA it
+// received. With silent set it accepts the connection and never answers.
+func smtpStub(t *testing.T, silent bool) (host, port string, data <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if silent {
+			time.Sleep(2 * time.Second)
+			return
+		}
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 stub ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 stub")
+			case strings.HasPrefix(command, "DATA"):
+				reply("354 go ahead")
+				var body strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					body.WriteString(line)
+				}
+				received <- body.String()
+				reply("250 queued")
+			case strings.HasPrefix(command, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(listener.Addr().String())
+	return host, port, received
+}
+
+func TestSMTPChannelStripsHeaderLineBreaks(t *testing.T) {
+	host, port, data := smtpStub(t, false)
+	channel := &SMTPChannel{Host: host, Port: port, From: "clinic@example.com", Timeout: 5 * time.Second}
+
+	err := channel.Send(context.Background(), Message{
+		Recipient: "patient@example.com\r\nBcc: victim@example.com",
+		Subject:   "Reminder\r\nX-Injected: yes",
+		Body:      "See you tomorrow",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := <-data
+	headers, _, _ := strings.Cut(received, "\r\n\r\n")
+	for _, line := range strings.Split(headers, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") || strings.HasPrefix(line, "X-Injected:") {
+			t.Fatalf("injected header %q in:\n%s", line, received)
+		}
+	}
+	if !strings.Contains(headers, "Subject: ReminderX-Injected: yes") {
+		t.Fatalf("unexpected headers:\n%s", headers)
+	}
+}
+
+func TestSMTPChannelTimesOut(t *testing.T) {
+	host, port, _ := smtpStub(t, true)
+	channel := &SMTPChannel{Host: host, Port: port, From: "clinic@example.com", Timeout: 200 * time.Millisecond}
+
+	start := time.Now()
+	err := channel.Send(context.Background(), Message{Recipient: "patient@example.com", Subject: "Hi", Body: "Hi"})
+	if err == nil {
+		t.Fatal("expected a timeout from a server that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("send took %s despite a 200ms timeout", elapsed)
+	}
+}
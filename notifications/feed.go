@@ -0,0 +1,28 @@
+package notifications
+
+import (
+	"apps90-hms/models"
+
+	"gorm.io/gorm"
+)
+
+// PostInApp adds an alert to an entity's in-app feed. When an unread alert
+// with the same DedupKey exists it is refreshed instead, so a condition that
+// persists does not flood the feed. It reports whether a new alert was added.
+func PostInApp(tx *gorm.DB, alert models.InAppNotification) (bool, error) {
+	if alert.DedupKey != "" {
+		var existing models.InAppNotification
+		if err := tx.Where("entity_id = ? AND dedup_key = ? AND read_at IS NULL", alert.EntityID, alert.DedupKey).
+			Find(&existing).Error; err != nil {
+			return false, err
+		}
+		if existing.ID != 0 {
+			return false, tx.Model(&existing).Updates(map[string]interface{}{
+				"severity": alert.Severity,
+				"title":    alert.Title,
+				"body":     alert.Body,
+			}).Error
+		}
+	}
+	return true, tx.Create(&alert).Error
+}
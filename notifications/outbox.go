@@ -0,0 +1,129 @@
+package notifications
+
+import (
+	"apps90-hms/models"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Used when APPOINTMENT_REMINDER_OFFSETS is not set
+var defaultReminderOffsets = []time.Duration{24 * time.Hour}
+
+// ReminderOffsets returns how long before an appointment reminders are sent,
+// read from APPOINTMENT_REMINDER_OFFSETS as a comma separated list such as "24h,2h".
+func ReminderOffsets() []time.Duration {
+	raw := os.Getenv("APPOINTMENT_REMINDER_OFFSETS")
+	if raw == "" {
+		return defaultReminderOffsets
+	}
+
+	var offsets []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		offset, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || offset <= 0 {
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	if len(offsets) == 0 {
+		return defaultReminderOffsets
+	}
+	return offsets
+}
+
+// EnqueueAppointmentReminders writes reminder messages for the appointment to
+// the outbox using tx, one per configured offset and patient contact channel.
+// Reminders whose send time has already passed are not queued.
+func EnqueueAppointmentReminders(tx *gorm.DB, appointmentID uint) error {
+	var appointment models.Appointment
+	if err := tx.Preload("Patient").Preload("Employee").Preload("Entity").
+		First(&appointment, appointmentID).Error; err != nil {
+		return err
+	}
+
+	subject := "Appointment reminder"
+	body := "Dear " + appointment.Patient.FirstName + ", this is a reminder of your appointment with Dr. " +
+		appointment.Employee.FirstName + " " + appointment.Employee.LastName +
+		" at " + appointment.Entity.Name +
+		" on " + appointment.AppointmentTime.Format("Mon, 02 Jan 2006 at 15:04") + "."
+
+	recipients := patientRecipients(appointment.Patient)
+
+	now := time.Now()
+	var messages []models.NotificationOutbox
+	for _, offset := range ReminderOffsets() {
+		sendAt := appointment.AppointmentTime.Add(-offset)
+		if sendAt.Before(now) {
+			continue
+		}
+		for channel, recipient := range recipients {
+			messages = append(messages, models.NotificationOutbox{
+				Channel:       channel,
+				Recipient:     recipient,
+				Subject:       subject,
+				Body:          body,
+				SendAt:        sendAt,
+				NextAttemptAt: sendAt,
+				Status:        models.NotificationStatusPending,
+				EntityID:      appointment.EntityID,
+				AppointmentID: &appointment.ID,
+			})
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	return tx.Create(&messages).Error
+}
+
+// EnqueuePatientMessage writes a one-off message for the patient to the outbox
+// using tx, on every channel the patient has contact details for.
+func EnqueuePatientMessage(tx *gorm.DB, patient models.Patient, entityID uint, subject, body string) error {
+	now := time.Now()
+	var messages []models.NotificationOutbox
+	for channel, recipient := range patientRecipients(patient) {
+		messages = append(messages, models.NotificationOutbox{
+			Channel:       channel,
+			Recipient:     recipient,
+			Subject:       subject,
+			Body:          body,
+			SendAt:        now,
+			NextAttemptAt: now,
+			Status:        models.NotificationStatusPending,
+			EntityID:      entityID,
+		})
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	return tx.Create(&messages).Error
+}
+
+// patientRecipients maps each channel to the patient's address on it
+func patientRecipients(patient models.Patient) map[string]string {
+	recipients := map[string]string{}
+	if patient.Email != "" {
+		recipients["email"] = patient.Email
+	}
+	if patient.ContactNumber != "" {
+		recipients["sms"] = patient.ContactNumber
+	}
+	return recipients
+}
+
+// CancelAppointmentReminders withdraws reminders that have not been sent yet,
+// including those a worker has claimed but not finished sending
+func CancelAppointmentReminders(tx *gorm.DB, appointmentIDs []uint) error {
+	if len(appointmentIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.NotificationOutbox{}).
+		Where("appointment_id IN ? AND status IN ?", appointmentIDs,
+			[]string{models.NotificationStatusPending, models.NotificationStatusSending}).
+		Update("status", models.NotificationStatusCancelled).Error
+}
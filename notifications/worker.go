@@ -0,0 +1,221 @@
+package notifications
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Worker delivers due outbox messages through the configured channels and
+// retries failures with exponential backoff.
+type Worker struct {
+	Channels     map[string]Channel
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	SendTimeout  time.Duration // Limit on a single delivery attempt
+	Lease        time.Duration // How long a claimed message is reserved for this worker
+}
+
+// NewWorkerFromEnv creates a worker using ChannelsFromEnv and the
+// NOTIFICATION_POLL_SECONDS and NOTIFICATION_MAX_ATTEMPTS settings.
+func NewWorkerFromEnv() *Worker {
+	worker := &Worker{
+		Channels:     ChannelsFromEnv(),
+		PollInterval: 30 * time.Second,
+		BatchSize:    50,
+		MaxAttempts:  5,
+		BaseBackoff:  time.Minute,
+		MaxBackoff:   6 * time.Hour,
+		SendTimeout:  30 * time.Second,
+		Lease:        10 * time.Minute,
+	}
+	if seconds, err := strconv.Atoi(os.Getenv("NOTIFICATION_POLL_SECONDS")); err == nil && seconds > 0 {
+		worker.PollInterval = time.Duration(seconds) * time.Second
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("NOTIFICATION_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		worker.MaxAttempts = attempts
+	}
+	return worker
+}
+
+// Run polls the outbox until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	logger := loggers.InitializeLogger()
+	logger.Info("Notification worker started", "poll_interval", w.PollInterval.String(), "channels", len(w.Channels))
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.ProcessDue(ctx); err != nil {
+			logger.Error("Notification worker failed to process outbox", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Notification worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue delivers one batch of due messages and returns how many were
+// attempted. Messages are claimed in a short transaction (SKIP LOCKED, so
+// several instances can share the outbox), sent with no transaction open,
+// and each outcome is recorded in its own transaction. A claim is a lease:
+// if the worker dies before recording the outcome, the message becomes due
+// again once the lease runs out.
+func (w *Worker) ProcessDue(ctx context.Context) (int, error) {
+	messages, err := w.claim()
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range messages {
+		// Skip messages cancelled since the claim
+		var claimed int64
+		if err := initializers.DB.Model(&models.NotificationOutbox{}).
+			Where("id = ? AND status = ?", messages[i].ID, models.NotificationStatusSending).
+			Count(&claimed).Error; err != nil {
+			return processed, err
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		delivery := w.attempt(ctx, &messages[i])
+		if err := w.record(&messages[i], delivery); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// claim marks a batch of due messages as Sending for the length of the lease
+func (w *Worker) claim() ([]models.NotificationOutbox, error) {
+	var messages []models.NotificationOutbox
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?",
+				[]string{models.NotificationStatusPending, models.NotificationStatusSending}, now).
+			Order("next_attempt_at").Limit(w.BatchSize).Find(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, 0, len(messages))
+		for i := range messages {
+			messages[i].Status = models.NotificationStatusSending
+			messages[i].NextAttemptAt = now.Add(w.Lease)
+			ids = append(ids, messages[i].ID)
+		}
+		return tx.Model(&models.NotificationOutbox{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":          models.NotificationStatusSending,
+			"next_attempt_at": now.Add(w.Lease),
+		}).Error
+	})
+
+	return messages, err
+}
+
+// attempt sends one message and updates it for the outcome: Sent, Pending
+// with a backed-off retry time, or Failed once MaxAttempts is reached. It
+// returns the delivery log entry to record and touches no database.
+func (w *Worker) attempt(ctx context.Context, message *models.NotificationOutbox) models.NotificationDelivery {
+	logger := loggers.InitializeLogger()
+	now := time.Now()
+	message.Attempts++
+
+	var sendErr error
+	channel, ok := w.Channels[message.Channel]
+	if !ok {
+		sendErr = fmt.Errorf("channel %q is not configured", message.Channel)
+	} else {
+		sendCtx, cancel := context.WithTimeout(ctx, w.SendTimeout)
+		sendErr = channel.Send(sendCtx, Message{
+			Recipient: message.Recipient,
+			Subject:   message.Subject,
+			Body:      message.Body,
+		})
+		cancel()
+	}
+
+	delivery := models.NotificationDelivery{
+		OutboxID:    message.ID,
+		Attempt:     message.Attempts,
+		Channel:     message.Channel,
+		Status:      models.NotificationStatusSent,
+		AttemptedAt: now,
+	}
+
+	if sendErr == nil {
+		message.Status = models.NotificationStatusSent
+		message.SentAt = &now
+		message.LastError = ""
+		logger.Info("Notification sent", "outbox_id", message.ID, "channel", message.Channel)
+		return delivery
+	}
+
+	delivery.Status = models.NotificationStatusFailed
+	delivery.Error = sendErr.Error()
+	message.LastError = sendErr.Error()
+	if message.Attempts >= w.MaxAttempts {
+		message.Status = models.NotificationStatusFailed
+	} else {
+		message.Status = models.NotificationStatusPending
+		message.NextAttemptAt = now.Add(w.backoff(message.Attempts))
+	}
+	logger.Warn("Notification delivery failed", "outbox_id", message.ID, "channel", message.Channel,
+		"attempt", message.Attempts, "error", sendErr.Error())
+	return delivery
+}
+
+// record saves the outcome of one attempt. The message is only updated while
+// it is still claimed, so one cancelled during the send stays Cancelled and
+// is not retried.
+func (w *Worker) record(message *models.NotificationOutbox, delivery models.NotificationDelivery) error {
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+		result := tx.Model(message).Where("status = ?", models.NotificationStatusSending).
+			Select("status", "attempts", "next_attempt_at", "sent_at", "last_error").Updates(message)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			loggers.InitializeLogger().Info("Notification cancelled while sending", "outbox_id", message.ID, "delivery_status", delivery.Status)
+		}
+		return nil
+	})
+}
+
+// backoff doubles the wait after each failed attempt, up to MaxBackoff
+func (w *Worker) backoff(attempt int) time.Duration {
+	wait := w.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= w.MaxBackoff {
+			return w.MaxBackoff
+		}
+	}
+	return wait
+}
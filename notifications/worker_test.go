@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"apps90-hms/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testWorker(channel *FakeChannel) *Worker {
+	return &Worker{
+		Channels:    map[string]Channel{channel.Name(): channel},
+		MaxAttempts: 3,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  10 * time.Minute,
+		SendTimeout: time.Second,
+	}
+}
+
+func TestAttemptSuccess(t *testing.T) {
+	channel := &FakeChannel{ChannelName: "email"}
+	worker := testWorker(channel)
+	message := models.NotificationOutbox{
+		ID:        7,
+		Channel:   "email",
+		Recipient: "patient@example.com",
+		Subject:   "Reminder",
+		Body:      "See you tomorrow",
+		Status:    models.NotificationStatusSending,
+	}
+
+	delivery := worker.attempt(context.Background(), &message)
+
+	if message.Status != models.NotificationStatusSent || message.SentAt == nil || message.Attempts != 1 {
+		t.Fatalf("message = %+v, want sent after one attempt", message)
+	}
+	if delivery.Status != models.NotificationStatusSent || delivery.OutboxID != 7 || delivery.Attempt != 1 {
+		t.Fatalf("delivery = %+v, want sent attempt 1 of outbox 7", delivery)
+	}
+	sent := channel.Sent()
+	if len(sent) != 1 || sent[0].Recipient != "patient@example.com" || sent[0].Body != "See you tomorrow" {
+		t.Fatalf("channel sent %+v", sent)
+	}
+}
+
+func TestAttemptRetriesWithBackoff(t *testing.T) {
+	channel := &FakeChannel{ChannelName: "sms", FailWith: errors.New("gateway down")}
+	worker := testWorker(channel)
+	message := models.NotificationOutbox{Channel: "sms", Status: models.NotificationStatusSending}
+
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		before := time.Now()
+		delivery := worker.attempt(context.Background(), &message)
+
+		if message.Status != models.NotificationStatusPending {
+			t.Fatalf("attempt %d: status %s, want Pending for a retry", attempt+1, message.Status)
+		}
+		if delivery.Status != models.NotificationStatusFailed || delivery.Error != "gateway down" {
+			t.Fatalf("attempt %d: delivery = %+v", attempt+1, delivery)
+		}
+		if got := message.NextAttemptAt.Sub(before); got < wait || got > wait+time.Second {
+			t.Fatalf("attempt %d: next attempt in %s, want %s", attempt+1, got, wait)
+		}
+	}
+	if message.LastError != "gateway down" || message.SentAt != nil {
+		t.Fatalf("message = %+v", message)
+	}
+}
+
+func TestAttemptFailsPermanently(t *testing.T) {
+	channel := &FakeChannel{ChannelName: "email", FailWith: errors.New("mailbox unavailable")}
+	worker := testWorker(channel)
+	message := models.NotificationOutbox{Channel: "email", Attempts: 2, Status: models.NotificationStatusSending}
+
+	worker.attempt(context.Background(), &message)
+
+	if message.Status != models.NotificationStatusFailed || message.Attempts != 3 {
+		t.Fatalf("message = %+v, want Failed after the third attempt", message)
+	}
+}
+
+func TestAttemptUnknownChannel(t *testing.T) {
+	worker := testWorker(&FakeChannel{ChannelName: "email"})
+	message := models.NotificationOutbox{Channel: "fax", Status: models.NotificationStatusSending}
+
+	delivery := worker.attempt(context.Background(), &message)
+
+	if delivery.Status != models.NotificationStatusFailed || message.Status != models.NotificationStatusPending {
+		t.Fatalf("delivery = %+v, message = %+v", delivery, message)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	worker := testWorker(&FakeChannel{ChannelName: "email"})
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{20, 10 * time.Minute},
+	}
+	for _, test := range tests {
+		if got := worker.backoff(test.attempt); got != test.want {
+			t.Errorf("backoff(%d) = %s, want %s", test.attempt, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package pdfgen renders simple text documents (a title followed by headed
+// sections of wrapped text) as PDF using only the standard library. It covers
+// printable reports such as discharge summaries; it is not a layout engine.
+package pdfgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page geometry in points (A4)
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	margin       = 50
+	bodySize     = 10
+	headingSize  = 12
+	titleSize    = 16
+	lineHeight   = 14
+	charsPerLine = 95
+)
+
+// Section is a heading followed by body text. Newlines in Body start new paragraphs.
+type Section struct {
+	Heading string
+	Body    string
+}
+
+// Document is a titled list of sections
+type Document struct {
+	Title    string
+	Sections []Section
+}
+
+type textLine struct {
+	text string
+	size int
+	bold bool
+}
+
+// Render produces the PDF bytes for the document
+func (d Document) Render() []byte {
+	lines := []textLine{{text: d.Title, size: titleSize, bold: true}, {}}
+	for _, section := range d.Sections {
+		lines = append(lines, textLine{text: section.Heading, size: headingSize, bold: true})
+		body := strings.TrimSpace(section.Body)
+		if body == "" {
+			body = "-"
+		}
+		for _, paragraph := range strings.Split(body, "\n") {
+			for _, wrapped := range wrap(paragraph, charsPerLine) {
+				lines = append(lines, textLine{text: wrapped, size: bodySize})
+			}
+		}
+		lines = append(lines, textLine{})
+	}
+
+	// Split lines into pages
+	perPage := (pageHeight - 2*margin) / lineHeight
+	var pages [][]textLine
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 pages, 3 regular font, 4 bold font, then a page and
+	// content stream object per page
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+
+		var content bytes.Buffer
+		content.WriteString("BT\n")
+		content.WriteString(fmt.Sprintf("%d %d Td\n", margin, pageHeight-margin))
+		for j, line := range page {
+			if j > 0 {
+				content.WriteString(fmt.Sprintf("0 -%d Td\n", lineHeight))
+			}
+			if line.text == "" {
+				continue
+			}
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			content.WriteString(fmt.Sprintf("/%s %d Tf (%s) Tj\n", font, line.size, escape(line.text)))
+		}
+		content.WriteString(fmt.Sprintf("ET\nBT /F1 8 Tf %d %d Td (Page %d of %d) Tj ET\n", margin, margin/2, i+1, len(pages)))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		out.WriteString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object))
+	}
+
+	xref := out.Len()
+	out.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objects)+1))
+	for _, offset := range offsets {
+		out.WriteString(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+	out.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref))
+
+	return out.Bytes()
+}
+
+// wrap breaks text into lines of at most width characters on word boundaries
+func wrap(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for len([]rune(word)) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// escape prepares text for a PDF string literal in WinAnsi encoding.
+// Characters outside Latin-1 are replaced with '?'.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			b.WriteString(fmt.Sprintf("\\%03o", r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,176 @@
+package pharmacy
+
+import (
+	"apps90-hms/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BatchDetails describes a batch as printed on the pack
+type BatchDetails struct {
+	BatchNumber   string
+	ExpiryDate    time.Time
+	MRP           float64
+	PurchasePrice float64
+}
+
+// Allocation is the part of a requested quantity taken from one batch. A nil
+// BatchID takes stock that is not tracked by batch.
+type Allocation struct {
+	BatchID     *uint     `json:"batch_id"`
+	BatchNumber string    `json:"batch_number,omitempty"`
+	ExpiryDate  time.Time `json:"expiry_date,omitempty"`
+	Quantity    int       `json:"quantity"`
+}
+
+// FindOrCreateBatch returns the batch with the given number for the medicine
+// at the entity, creating it empty if it is new. A known batch number must
+// carry the same expiry date; its prices are refreshed from details.
+func FindOrCreateBatch(tx *gorm.DB, entityID, medicineID uint, details BatchDetails) (models.MedicineBatch, error) {
+	var batch models.MedicineBatch
+	if err := tx.Where("entity_id = ? AND medicine_id = ? AND batch_number = ?", entityID, medicineID, details.BatchNumber).
+		Find(&batch).Error; err != nil {
+		return batch, err
+	}
+
+	if batch.ID == 0 {
+		batch = models.MedicineBatch{
+			EntityID:      entityID,
+			MedicineID:    medicineID,
+			BatchNumber:   details.BatchNumber,
+			ExpiryDate:    details.ExpiryDate,
+			MRP:           details.MRP,
+			PurchasePrice: details.PurchasePrice,
+		}
+		return batch, tx.Create(&batch).Error
+	}
+
+	if batch.ExpiryDate.Format("2006-01-02") != details.ExpiryDate.Format("2006-01-02") {
+		return batch, fmt.Errorf("%w: batch %s is recorded with expiry %s", ErrBatchMismatch, batch.BatchNumber, batch.ExpiryDate.Format("2006-01-02"))
+	}
+	return batch, tx.Model(&batch).Updates(map[string]interface{}{
+		"mrp":            details.MRP,
+		"purchase_price": details.PurchasePrice,
+	}).Error
+}
+
+// PickFEFO allocates quantity units of a medicine at an entity first-expiry,
+// first-out across unexpired batches, then from stock not tracked by batch.
+// The stock level and batches stay locked until the transaction ends.
+func PickFEFO(tx *gorm.DB, entityID, medicineID uint, quantity int, day time.Time) ([]Allocation, error) {
+	level, err := lockLevel(tx, entityID, medicineID)
+	if err != nil {
+		return nil, err
+	}
+
+	var batches []models.MedicineBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("entity_id = ? AND medicine_id = ? AND quantity > 0", entityID, medicineID).
+		Order("expiry_date, id").Find(&batches).Error; err != nil {
+		return nil, err
+	}
+
+	var allocations []Allocation
+	remaining := quantity
+	batched, expired := 0, 0
+	for _, batch := range batches {
+		batched += batch.Quantity
+		if batch.Expired(day) {
+			expired += batch.Quantity
+			continue
+		}
+		if remaining == 0 {
+			continue
+		}
+		take := min(batch.Quantity, remaining)
+		id := batch.ID
+		allocations = append(allocations, Allocation{BatchID: &id, BatchNumber: batch.BatchNumber, ExpiryDate: batch.ExpiryDate, Quantity: take})
+		remaining -= take
+	}
+
+	if unbatched := level.Quantity - batched; remaining > 0 && unbatched > 0 {
+		take := min(unbatched, remaining)
+		allocations = append(allocations, Allocation{Quantity: take})
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		return nil, fmt.Errorf("%w: %d usable, %d requested (%d more in expired batches)",
+			ErrInsufficientStock, quantity-remaining, quantity, expired)
+	}
+	return allocations, nil
+}
+
+// Dispense takes quantity units out of stock FEFO, posting one Dispense
+// movement per batch used
+func Dispense(tx *gorm.DB, entityID, medicineID uint, quantity int, performedByID uint, referenceType string, referenceID *uint) ([]models.StockMovement, error) {
+	allocations, err := PickFEFO(tx, entityID, medicineID, quantity, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var movements []models.StockMovement
+	for _, allocation := range allocations {
+		movement := models.StockMovement{
+			EntityID:      entityID,
+			MedicineID:    medicineID,
+			BatchID:       allocation.BatchID,
+			Type:          models.StockMovementDispense,
+			Quantity:      -allocation.Quantity,
+			ReferenceType: referenceType,
+			ReferenceID:   referenceID,
+			PerformedByID: performedByID,
+		}
+		if err := Post(tx, &movement); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, nil
+}
+
+// WriteOffExpired pulls every expired batch with stock left at an entity,
+// posting an ExpiryWriteOff movement for each
+func WriteOffExpired(tx *gorm.DB, entityID uint, day time.Time, performedByID uint) ([]models.StockMovement, error) {
+	var batches []models.MedicineBatch
+	if err := tx.Where("entity_id = ? AND quantity > 0 AND expiry_date < ?", entityID, day.Format("2006-01-02")).
+		Order("medicine_id, expiry_date").Find(&batches).Error; err != nil {
+		return nil, err
+	}
+
+	var movements []models.StockMovement
+	for _, batch := range batches {
+		id := batch.ID
+		movement := models.StockMovement{
+			EntityID:      entityID,
+			MedicineID:    batch.MedicineID,
+			BatchID:       &id,
+			Type:          models.StockMovementExpiryWriteOff,
+			Quantity:      -batch.Quantity,
+			ReasonCode:    models.StockReasonExpired,
+			PerformedByID: performedByID,
+		}
+		if err := Post(tx, &movement); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	return movements, nil
+}
+
+// Usable returns the stock of a medicine at an entity that can be dispensed
+// on day, i.e. everything except expired batches
+func Usable(db *gorm.DB, entityID, medicineID uint, day time.Time) (int, error) {
+	available, err := Available(db, entityID, medicineID)
+	if err != nil {
+		return 0, err
+	}
+	var expired int
+	err = db.Model(&models.MedicineBatch{}).
+		Where("entity_id = ? AND medicine_id = ? AND expiry_date < ?", entityID, medicineID, day.Format("2006-01-02")).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&expired).Error
+	return available - expired, err
+}
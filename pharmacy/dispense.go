@@ -0,0 +1,139 @@
+package pharmacy
+
+import (
+	"apps90-hms/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPrescriptionDispensed = errors.New("prescription is already fully dispensed")
+	ErrItemNotOnPrescription = errors.New("item is not on this prescription")
+	ErrOverDispense          = errors.New("quantity exceeds what remains to be dispensed")
+	ErrMedicineRequired      = errors.New("medicine must be chosen for a free-text item")
+	ErrSubstitutionReason    = errors.New("substitutions need a reason")
+)
+
+// DispenseLine is what the pharmacist hands over for one prescription item.
+// MedicineID defaults to the prescribed medicine; a different one is a
+// substitution.
+type DispenseLine struct {
+	PrescriptionItemID uint
+	MedicineID         uint
+	Quantity           int
+	SubstitutionReason string
+}
+
+// DispensePrescription hands over the given lines from an entity's stock,
+// FEFO per medicine, and updates what the prescription has outstanding. The
+// prescription is locked, so two pharmacists cannot dispense it at once.
+func DispensePrescription(tx *gorm.DB, prescriptionID, entityID, pharmacistID uint, lines []DispenseLine, notes string) (models.Dispense, error) {
+	dispense := models.Dispense{
+		PrescriptionID: prescriptionID,
+		EntityID:       entityID,
+		PharmacistID:   pharmacistID,
+		DispensedAt:    time.Now(),
+		Notes:          notes,
+	}
+
+	var prescription models.Prescription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("PrescriptionItems").
+		First(&prescription, prescriptionID).Error; err != nil {
+		return dispense, err
+	}
+	if prescription.DispenseStatus == models.PrescriptionDispensed {
+		return dispense, ErrPrescriptionDispensed
+	}
+
+	items := map[uint]*models.PrescriptionItem{}
+	for i := range prescription.PrescriptionItems {
+		items[prescription.PrescriptionItems[i].ID] = &prescription.PrescriptionItems[i]
+	}
+
+	if err := tx.Create(&dispense).Error; err != nil {
+		return dispense, err
+	}
+
+	for _, line := range lines {
+		item, ok := items[line.PrescriptionItemID]
+		if !ok {
+			return dispense, fmt.Errorf("%w: %d", ErrItemNotOnPrescription, line.PrescriptionItemID)
+		}
+		if line.Quantity <= 0 {
+			return dispense, ErrInvalidQuantity
+		}
+		if item.Quantity > 0 && item.DispensedQuantity+line.Quantity > item.Quantity {
+			return dispense, fmt.Errorf("%w: item %d has %d of %d left", ErrOverDispense, item.ID, item.Quantity-item.DispensedQuantity, item.Quantity)
+		}
+
+		dispenseItem := models.DispenseItem{
+			DispenseID:         dispense.ID,
+			PrescriptionItemID: item.ID,
+			MedicineID:         line.MedicineID,
+			Quantity:           line.Quantity,
+		}
+		switch {
+		case line.MedicineID == 0 && item.MedicineID == nil:
+			return dispense, fmt.Errorf("%w: item %d", ErrMedicineRequired, item.ID)
+		case line.MedicineID == 0:
+			dispenseItem.MedicineID = *item.MedicineID
+		case item.MedicineID != nil && line.MedicineID != *item.MedicineID:
+			if line.SubstitutionReason == "" {
+				return dispense, fmt.Errorf("%w: item %d", ErrSubstitutionReason, item.ID)
+			}
+			dispenseItem.SubstitutedForID = item.MedicineID
+			dispenseItem.SubstitutionReason = line.SubstitutionReason
+		}
+
+		if _, err := Dispense(tx, entityID, dispenseItem.MedicineID, line.Quantity, pharmacistID, "Dispense", &dispense.ID); err != nil {
+			return dispense, fmt.Errorf("item %d: %w", item.ID, err)
+		}
+		if err := tx.Create(&dispenseItem).Error; err != nil {
+			return dispense, err
+		}
+
+		item.DispensedQuantity += line.Quantity
+		if err := tx.Model(item).Update("dispensed_quantity", item.DispensedQuantity).Error; err != nil {
+			return dispense, err
+		}
+		dispense.Items = append(dispense.Items, dispenseItem)
+	}
+
+	status := dispenseStatus(prescription.PrescriptionItems)
+	if err := tx.Model(&prescription).Update("dispense_status", status).Error; err != nil {
+		return dispense, err
+	}
+	return dispense, nil
+}
+
+// dispenseStatus derives a prescription's status from its items. Items
+// without a quantity count as done once anything was handed over for them.
+func dispenseStatus(items []models.PrescriptionItem) string {
+	started, done := false, true
+	for _, item := range items {
+		if item.DispensedQuantity > 0 {
+			started = true
+		}
+		if item.DispensedQuantity == 0 || item.DispensedQuantity < item.Quantity {
+			done = false
+		}
+	}
+	switch {
+	case started && done:
+		return models.PrescriptionDispensed
+	case started:
+		return models.PrescriptionPartiallyDispensed
+	}
+	return models.PrescriptionNotDispensed
+}
+
+// IsDispenseError reports whether err is a rejection of the dispense, including
+// stock rejections, rather than a database failure
+func IsDispenseError(err error) bool {
+	return IsStockError(err) || errors.Is(err, ErrPrescriptionDispensed) || errors.Is(err, ErrItemNotOnPrescription) ||
+		errors.Is(err, ErrOverDispense) || errors.Is(err, ErrMedicineRequired) || errors.Is(err, ErrSubstitutionReason)
+}
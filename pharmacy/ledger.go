@@ -0,0 +1,184 @@
+package pharmacy
+
+import (
+	"apps90-hms/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock = errors.New("not enough stock")
+	ErrInvalidQuantity   = errors.New("quantity does not match the movement direction")
+	ErrInvalidReason     = errors.New("reason code is not valid for this movement type")
+	ErrUnknownMovement   = errors.New("unknown stock movement type")
+	ErrBatchExpired      = errors.New("batch is expired")
+	ErrBatchMismatch     = errors.New("batch does not belong to this medicine and entity")
+)
+
+// Direction of each movement type: 1 adds stock, -1 removes it and 0 may do
+// either
+var directions = map[string]int{
+	models.StockMovementReceipt:        1,
+	models.StockMovementReturn:         1,
+	models.StockMovementTransferIn:     1,
+	models.StockMovementDispense:       -1,
+	models.StockMovementExpiryWriteOff: -1,
+	models.StockMovementTransferOut:    -1,
+	models.StockMovementAdjustment:     0,
+}
+
+// Reason codes accepted per movement type. Types not listed take no reason.
+var reasons = map[string][]string{
+	models.StockMovementReturn:         {models.StockReasonPatientReturn, models.StockReasonOther},
+	models.StockMovementAdjustment:     {models.StockReasonStockCount, models.StockReasonDamaged, models.StockReasonLost, models.StockReasonSupplierReturn, models.StockReasonOpening, models.StockReasonOther},
+	models.StockMovementExpiryWriteOff: {models.StockReasonExpired},
+}
+
+// Direction reports whether a movement type adds (1) or removes (-1) stock,
+// or 0 for adjustments, which may go either way
+func Direction(movementType string) (int, error) {
+	direction, ok := directions[movementType]
+	if !ok {
+		return 0, ErrUnknownMovement
+	}
+	return direction, nil
+}
+
+// Post appends a movement to the ledger and updates the stock level of the
+// medicine at the entity in the same transaction. Quantity must be signed
+// according to the movement type; stock may never go negative. The level
+// row is locked, so concurrent postings for one medicine are serialised.
+// A movement with a BatchID also moves the batch's quantity, and stock from
+// expired batches can only be written off or adjusted. Without a BatchID only
+// stock not tracked by batch can be taken out.
+func Post(tx *gorm.DB, movement *models.StockMovement) error {
+	direction, err := Direction(movement.Type)
+	if err != nil {
+		return err
+	}
+	if movement.Quantity == 0 || direction*movement.Quantity < 0 {
+		return ErrInvalidQuantity
+	}
+	if err := checkReason(movement); err != nil {
+		return err
+	}
+
+	level, err := lockLevel(tx, movement.EntityID, movement.MedicineID)
+	if err != nil {
+		return err
+	}
+	balance := level.Quantity + movement.Quantity
+	if balance < 0 {
+		return fmt.Errorf("%w: %d in stock, %d requested", ErrInsufficientStock, level.Quantity, -movement.Quantity)
+	}
+
+	if movement.BatchID != nil {
+		if err := moveBatch(tx, movement); err != nil {
+			return err
+		}
+	} else if movement.Quantity < 0 {
+		unbatched, err := unbatchedStock(tx, level)
+		if err != nil {
+			return err
+		}
+		if unbatched+movement.Quantity < 0 {
+			return fmt.Errorf("%w: %d not tracked by batch, %d requested; name a batch", ErrInsufficientStock, unbatched, -movement.Quantity)
+		}
+	}
+
+	if err := tx.Model(&level).Update("quantity", balance).Error; err != nil {
+		return err
+	}
+
+	movement.BalanceAfter = balance
+	if movement.OccurredAt.IsZero() {
+		movement.OccurredAt = time.Now()
+	}
+	return tx.Create(movement).Error
+}
+
+// Available returns the current stock of a medicine at an entity
+func Available(db *gorm.DB, entityID, medicineID uint) (int, error) {
+	var level models.StockLevel
+	err := db.Where("entity_id = ? AND medicine_id = ?", entityID, medicineID).Find(&level).Error
+	return level.Quantity, err
+}
+
+// IsStockError reports whether err is a rejection of the posting rather than
+// a database failure
+func IsStockError(err error) bool {
+	return errors.Is(err, ErrInsufficientStock) || errors.Is(err, ErrInvalidQuantity) ||
+		errors.Is(err, ErrInvalidReason) || errors.Is(err, ErrUnknownMovement) ||
+		errors.Is(err, ErrBatchExpired) || errors.Is(err, ErrBatchMismatch)
+}
+
+func checkReason(movement *models.StockMovement) error {
+	allowed, ok := reasons[movement.Type]
+	if !ok {
+		if movement.ReasonCode != "" {
+			return ErrInvalidReason
+		}
+		return nil
+	}
+	if movement.ReasonCode == "" && len(allowed) == 1 {
+		movement.ReasonCode = allowed[0]
+	}
+	for _, reason := range allowed {
+		if movement.ReasonCode == reason {
+			return nil
+		}
+	}
+	return ErrInvalidReason
+}
+
+// lockLevel returns the stock level row locked for update, creating it at
+// zero on first use
+func lockLevel(tx *gorm.DB, entityID, medicineID uint) (models.StockLevel, error) {
+	level := models.StockLevel{EntityID: entityID, MedicineID: medicineID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&level).Error; err != nil {
+		return level, err
+	}
+	level = models.StockLevel{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("entity_id = ? AND medicine_id = ?", entityID, medicineID).
+		First(&level).Error
+	return level, err
+}
+
+// moveBatch applies a movement to its batch, which is locked for update
+func moveBatch(tx *gorm.DB, movement *models.StockMovement) error {
+	var batch models.MedicineBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, *movement.BatchID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBatchMismatch
+		}
+		return err
+	}
+	if batch.EntityID != movement.EntityID || batch.MedicineID != movement.MedicineID {
+		return ErrBatchMismatch
+	}
+	if movement.Quantity < 0 && batch.Expired(time.Now()) &&
+		movement.Type != models.StockMovementExpiryWriteOff && movement.Type != models.StockMovementAdjustment {
+		return fmt.Errorf("%w: batch %s expired on %s", ErrBatchExpired, batch.BatchNumber, batch.ExpiryDate.Format("2006-01-02"))
+	}
+
+	quantity := batch.Quantity + movement.Quantity
+	if quantity < 0 {
+		return fmt.Errorf("%w: %d left in batch %s, %d requested", ErrInsufficientStock, batch.Quantity, batch.BatchNumber, -movement.Quantity)
+	}
+	return tx.Model(&batch).Update("quantity", quantity).Error
+}
+
+// unbatchedStock is the part of a stock level not held in any batch, such as
+// balances carried over from before batches were tracked
+func unbatchedStock(tx *gorm.DB, level models.StockLevel) (int, error) {
+	var batched int
+	err := tx.Model(&models.MedicineBatch{}).
+		Where("entity_id = ? AND medicine_id = ?", level.EntityID, level.MedicineID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&batched).Error
+	return level.Quantity - batched, err
+}
@@ -0,0 +1,206 @@
+package pharmacy
+
+import (
+	"apps90-hms/models"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPurchaseOrderState = errors.New("purchase order is not in a state that allows this")
+	ErrSelfApproval       = errors.New("purchase orders must be approved by someone other than who raised them")
+	ErrItemNotOnOrder     = errors.New("item is not on this purchase order")
+	ErrDuplicateMedicine  = errors.New("medicine appears more than once")
+	ErrExpiredOnReceipt   = errors.New("expired batches cannot be received")
+)
+
+// OrderLine is one medicine to order
+type OrderLine struct {
+	MedicineID uint
+	Quantity   int
+	UnitPrice  float64
+}
+
+// ReceiptLine is what arrived for one purchase order item. Quantity is
+// accepted into stock, RejectedQuantity is refused at the door.
+// Batch.PurchasePrice is the invoiced price, defaulting to the ordered price.
+type ReceiptLine struct {
+	PurchaseOrderItemID uint
+	Batch               BatchDetails
+	Quantity            int
+	RejectedQuantity    int
+	RejectionReason     string
+}
+
+// CreatePurchaseOrder saves order as a draft with one item per line
+func CreatePurchaseOrder(tx *gorm.DB, order *models.PurchaseOrder, lines []OrderLine) error {
+	seen := map[uint]bool{}
+	order.Status = models.PurchaseOrderDraft
+	order.TotalAmount = 0
+	order.Items = nil
+	for _, line := range lines {
+		if seen[line.MedicineID] {
+			return fmt.Errorf("%w: medicine %d", ErrDuplicateMedicine, line.MedicineID)
+		}
+		seen[line.MedicineID] = true
+		if line.Quantity <= 0 || line.UnitPrice < 0 {
+			return ErrInvalidQuantity
+		}
+		order.Items = append(order.Items, models.PurchaseOrderItem{
+			MedicineID: line.MedicineID,
+			Quantity:   line.Quantity,
+			UnitPrice:  line.UnitPrice,
+		})
+		order.TotalAmount += float64(line.Quantity) * line.UnitPrice
+	}
+	order.TotalAmount = math.Round(order.TotalAmount*100) / 100
+	return tx.Create(order).Error
+}
+
+// ApprovePurchaseOrder approves a draft order
+func ApprovePurchaseOrder(tx *gorm.DB, orderID, approverID uint) (models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+		return order, err
+	}
+	if order.Status != models.PurchaseOrderDraft {
+		return order, fmt.Errorf("%w: it is %s", ErrPurchaseOrderState, order.Status)
+	}
+	if order.OrderedByID == approverID {
+		return order, ErrSelfApproval
+	}
+
+	now := time.Now()
+	order.Status = models.PurchaseOrderApproved
+	order.ApprovedByID = &approverID
+	order.ApprovedAt = &now
+	return order, tx.Model(&order).Updates(map[string]interface{}{
+		"status":         order.Status,
+		"approved_by_id": approverID,
+		"approved_at":    now,
+	}).Error
+}
+
+// CancelPurchaseOrder cancels an order nothing has been received against
+func CancelPurchaseOrder(tx *gorm.DB, orderID uint) error {
+	result := tx.Model(&models.PurchaseOrder{}).
+		Where("id = ? AND status IN ?", orderID, []string{models.PurchaseOrderDraft, models.PurchaseOrderApproved}).
+		Update("status", models.PurchaseOrderCancelled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: only draft or approved orders without receipts can be cancelled", ErrPurchaseOrderState)
+	}
+	return nil
+}
+
+// ReceiveGoods records a delivery against an approved purchase order, posting
+// the accepted quantities to stock as receipts into their batches
+func ReceiveGoods(tx *gorm.DB, receipt *models.GoodsReceipt, lines []ReceiptLine) error {
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").
+		First(&order, receipt.PurchaseOrderID).Error; err != nil {
+		return err
+	}
+	if order.Status != models.PurchaseOrderApproved && order.Status != models.PurchaseOrderPartiallyReceived {
+		return fmt.Errorf("%w: goods can only be received against approved orders, it is %s", ErrPurchaseOrderState, order.Status)
+	}
+
+	items := map[uint]*models.PurchaseOrderItem{}
+	for i := range order.Items {
+		items[order.Items[i].ID] = &order.Items[i]
+	}
+
+	receipt.EntityID = order.EntityID
+	receipt.SupplierID = order.SupplierID
+	if receipt.ReceivedAt.IsZero() {
+		receipt.ReceivedAt = time.Now()
+	}
+	receipt.Items = nil
+	if err := tx.Create(receipt).Error; err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		item, ok := items[line.PurchaseOrderItemID]
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrItemNotOnOrder, line.PurchaseOrderItemID)
+		}
+		if line.Quantity < 0 || line.RejectedQuantity < 0 || line.Quantity+line.RejectedQuantity == 0 {
+			return ErrInvalidQuantity
+		}
+		// Expired stock may still be recorded as rejected
+		if line.Quantity > 0 && (models.MedicineBatch{ExpiryDate: line.Batch.ExpiryDate}).Expired(receipt.ReceivedAt) {
+			return fmt.Errorf("%w: batch %s", ErrExpiredOnReceipt, line.Batch.BatchNumber)
+		}
+		if line.Batch.PurchasePrice == 0 {
+			line.Batch.PurchasePrice = item.UnitPrice
+		}
+
+		receiptItem := models.GoodsReceiptItem{
+			GoodsReceiptID:      receipt.ID,
+			PurchaseOrderItemID: item.ID,
+			MedicineID:          item.MedicineID,
+			BatchNumber:         line.Batch.BatchNumber,
+			ExpiryDate:          line.Batch.ExpiryDate,
+			Quantity:            line.Quantity,
+			RejectedQuantity:    line.RejectedQuantity,
+			RejectionReason:     line.RejectionReason,
+			UnitPrice:           line.Batch.PurchasePrice,
+			MRP:                 line.Batch.MRP,
+		}
+
+		if line.Quantity > 0 {
+			batch, err := FindOrCreateBatch(tx, order.EntityID, item.MedicineID, line.Batch)
+			if err != nil {
+				return err
+			}
+			receiptItem.BatchID = &batch.ID
+
+			movement := models.StockMovement{
+				EntityID:      order.EntityID,
+				MedicineID:    item.MedicineID,
+				BatchID:       &batch.ID,
+				Type:          models.StockMovementReceipt,
+				Quantity:      line.Quantity,
+				ReferenceType: "GoodsReceipt",
+				ReferenceID:   &receipt.ID,
+				PerformedByID: receipt.ReceivedByID,
+			}
+			if err := Post(tx, &movement); err != nil {
+				return err
+			}
+
+			item.ReceivedQuantity += line.Quantity
+			if err := tx.Model(item).Update("received_quantity", item.ReceivedQuantity).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&receiptItem).Error; err != nil {
+			return err
+		}
+		receipt.Items = append(receipt.Items, receiptItem)
+	}
+
+	status := models.PurchaseOrderReceived
+	for _, item := range order.Items {
+		if item.ReceivedQuantity < item.Quantity {
+			status = models.PurchaseOrderPartiallyReceived
+		}
+	}
+	return tx.Model(&order).Update("status", status).Error
+}
+
+// IsProcurementError reports whether err is a rejection of a purchase order
+// or goods receipt rather than a database failure
+func IsProcurementError(err error) bool {
+	return IsStockError(err) || errors.Is(err, ErrPurchaseOrderState) || errors.Is(err, ErrSelfApproval) ||
+		errors.Is(err, ErrItemNotOnOrder) || errors.Is(err, ErrDuplicateMedicine) || errors.Is(err, ErrExpiredOnReceipt)
+}
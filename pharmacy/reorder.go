@@ -0,0 +1,337 @@
+package pharmacy
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/notifications"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Advisory lock namespace for the reorder check, used with the two-key form
+// and the entity ID so instances never evaluate one entity at the same time
+const reorderLockSpace = 2
+
+// ReorderSettings tune how stock is compared with consumption
+type ReorderSettings struct {
+	ConsumptionDays int  // Window for the average daily consumption
+	LeadTimeDays    int  // Days a supplier needs to deliver; stock must cover them
+	CoverDays       int  // Days of consumption an order is sized for when the rule has no quantity
+	AutoDraft       bool // Draft purchase orders for suggestions with a known supplier
+}
+
+// ReorderSettingsFromEnv reads PHARMACY_CONSUMPTION_DAYS (30),
+// PHARMACY_LEAD_TIME_DAYS (7), PHARMACY_REORDER_COVER_DAYS (30) and
+// PHARMACY_AUTO_DRAFT_PO (false)
+func ReorderSettingsFromEnv() ReorderSettings {
+	settings := ReorderSettings{ConsumptionDays: 30, LeadTimeDays: 7, CoverDays: 30}
+	if days, err := strconv.Atoi(os.Getenv("PHARMACY_CONSUMPTION_DAYS")); err == nil && days > 0 {
+		settings.ConsumptionDays = days
+	}
+	if days, err := strconv.Atoi(os.Getenv("PHARMACY_LEAD_TIME_DAYS")); err == nil && days >= 0 {
+		settings.LeadTimeDays = days
+	}
+	if days, err := strconv.Atoi(os.Getenv("PHARMACY_REORDER_COVER_DAYS")); err == nil && days > 0 {
+		settings.CoverDays = days
+	}
+	settings.AutoDraft = strings.EqualFold(os.Getenv("PHARMACY_AUTO_DRAFT_PO"), "true")
+	return settings
+}
+
+// ReorderSuggestion is a medicine whose usable stock plus what is already on
+// order or being transferred in has fallen to its reorder threshold
+type ReorderSuggestion struct {
+	EntityID          uint    `json:"entity_id"`
+	MedicineID        uint    `json:"medicine_id"`
+	MedicineName      string  `json:"medicine_name"`
+	Usable            int     `json:"usable"` // Stock excluding expired batches
+	OnOrder           int     `json:"on_order"`
+	ReorderLevel      int     `json:"reorder_level"`
+	Threshold         int     `json:"threshold"` // Reorder level, raised to cover the lead time at current consumption
+	AverageDailyUse   float64 `json:"average_daily_use"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+	SupplierID        *uint   `json:"supplier_id,omitempty"`
+	UnitPrice         float64 `json:"unit_price"`
+}
+
+// ReorderResult summarises one reorder check of an entity
+type ReorderResult struct {
+	Suggestions   []ReorderSuggestion `json:"suggestions"`
+	Alerts        int                 `json:"alerts"` // New feed alerts; persisting ones are refreshed
+	DraftedOrders []uint              `json:"drafted_orders"`
+}
+
+// SetReorderRule sets the reorder level, quantity and preferred supplier of a
+// medicine at an entity, creating its stock level at zero if needed
+func SetReorderRule(tx *gorm.DB, entityID, medicineID uint, level, quantity int, supplierID *uint) (models.StockLevel, error) {
+	if level < 0 || quantity < 0 {
+		return models.StockLevel{}, ErrInvalidQuantity
+	}
+	stock, err := lockLevel(tx, entityID, medicineID)
+	if err != nil {
+		return stock, err
+	}
+	stock.ReorderLevel = level
+	stock.ReorderQuantity = quantity
+	stock.PreferredSupplierID = supplierID
+	err = tx.Model(&stock).Select("reorder_level", "reorder_quantity", "preferred_supplier_id").Updates(&stock).Error
+	return stock, err
+}
+
+// Suggestions evaluates every reorder rule of an entity against usable stock,
+// open purchase orders and the average consumption
+func Suggestions(db *gorm.DB, entityID uint, settings ReorderSettings, now time.Time) ([]ReorderSuggestion, error) {
+	var levels []models.StockLevel
+	if err := db.Preload("Medicine").Where("entity_id = ? AND reorder_level > 0", entityID).
+		Order("medicine_id").Find(&levels).Error; err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, nil
+	}
+
+	consumed, err := sumByMedicine(db.Model(&models.StockMovement{}).
+		Select("medicine_id, -SUM(quantity) AS total").
+		Where("entity_id = ? AND type = ? AND occurred_at >= ?", entityID, models.StockMovementDispense,
+			now.AddDate(0, 0, -settings.ConsumptionDays)))
+	if err != nil {
+		return nil, err
+	}
+	expired, err := sumByMedicine(db.Model(&models.MedicineBatch{}).
+		Select("medicine_id, SUM(quantity) AS total").
+		Where("entity_id = ? AND quantity > 0 AND expiry_date < ?", entityID, now.Format("2006-01-02")))
+	if err != nil {
+		return nil, err
+	}
+	onOrder, err := sumByMedicine(db.Table("purchase_order_item").
+		Select("purchase_order_item.medicine_id, SUM(GREATEST(purchase_order_item.quantity - purchase_order_item.received_quantity, 0)) AS total").
+		Joins("JOIN purchase_order ON purchase_order.id = purchase_order_item.purchase_order_id").
+		Where("purchase_order.entity_id = ? AND purchase_order.status IN ?", entityID,
+			[]string{models.PurchaseOrderDraft, models.PurchaseOrderApproved, models.PurchaseOrderPartiallyReceived}))
+	if err != nil {
+		return nil, err
+	}
+	// Transfers are counted against the receiving entity's own medicine
+	incomingLines := db.Table("stock_transfer_item").
+		Select("stock_transfer_item.destination_medicine_id AS medicine_id, CASE WHEN stock_transfer.status = ? THEN stock_transfer_item.dispatched_quantity ELSE stock_transfer_item.requested_quantity END AS quantity",
+			models.StockTransferDispatched).
+		Joins("JOIN stock_transfer ON stock_transfer.id = stock_transfer_item.stock_transfer_id").
+		Where("stock_transfer.to_entity_id = ? AND stock_transfer.status IN ?", entityID,
+			[]string{models.StockTransferRequested, models.StockTransferApproved, models.StockTransferDispatched})
+	incoming, err := sumByMedicine(db.Table("(?) AS incoming", incomingLines).Select("medicine_id, SUM(quantity) AS total"))
+	if err != nil {
+		return nil, err
+	}
+	for medicineID, quantity := range incoming {
+		onOrder[medicineID] += quantity
+	}
+
+	var suggestions []ReorderSuggestion
+	for _, level := range levels {
+		average := float64(consumed[level.MedicineID]) / float64(settings.ConsumptionDays)
+		threshold := max(level.ReorderLevel, int(math.Ceil(average*float64(settings.LeadTimeDays))))
+		usable := level.Quantity - expired[level.MedicineID]
+		if usable+onOrder[level.MedicineID] > threshold {
+			continue
+		}
+
+		quantity := level.ReorderQuantity
+		if quantity == 0 {
+			quantity = int(math.Ceil(average * float64(settings.CoverDays)))
+		}
+		quantity = max(quantity, threshold+1-usable-onOrder[level.MedicineID])
+
+		suggestion := ReorderSuggestion{
+			EntityID:          entityID,
+			MedicineID:        level.MedicineID,
+			MedicineName:      level.Medicine.Name,
+			Usable:            usable,
+			OnOrder:           onOrder[level.MedicineID],
+			ReorderLevel:      level.ReorderLevel,
+			Threshold:         threshold,
+			AverageDailyUse:   math.Round(average*100) / 100,
+			SuggestedQuantity: quantity,
+			SupplierID:        level.PreferredSupplierID,
+		}
+		if err := lastPurchase(db, entityID, &suggestion); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
+// CheckReorders posts low-stock alerts to the entity's feed and, when enabled,
+// drafts one purchase order per supplier for medicines not already on order.
+// It does nothing if another instance is checking the entity.
+func CheckReorders(db *gorm.DB, entityID uint, settings ReorderSettings, now time.Time) (ReorderResult, error) {
+	result := ReorderResult{DraftedOrders: []uint{}}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?, ?)", reorderLockSpace, entityID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		suggestions, err := Suggestions(tx, entityID, settings, now)
+		if err != nil {
+			return err
+		}
+		result.Suggestions = suggestions
+
+		drafts := map[uint][]OrderLine{}
+		var supplierOrder []uint
+		for _, suggestion := range suggestions {
+			added, err := notifications.PostInApp(tx, lowStockAlert(suggestion))
+			if err != nil {
+				return err
+			}
+			if added {
+				result.Alerts++
+			}
+
+			if settings.AutoDraft && suggestion.OnOrder == 0 && suggestion.SupplierID != nil {
+				supplierID := *suggestion.SupplierID
+				if _, ok := drafts[supplierID]; !ok {
+					supplierOrder = append(supplierOrder, supplierID)
+				}
+				drafts[supplierID] = append(drafts[supplierID], OrderLine{
+					MedicineID: suggestion.MedicineID,
+					Quantity:   suggestion.SuggestedQuantity,
+					UnitPrice:  suggestion.UnitPrice,
+				})
+			}
+		}
+
+		for _, supplierID := range supplierOrder {
+			order := models.PurchaseOrder{
+				EntityID:   entityID,
+				SupplierID: supplierID,
+				Notes:      "Drafted by the reorder check on " + now.Format("2006-01-02"),
+			}
+			if err := CreatePurchaseOrder(tx, &order, drafts[supplierID]); err != nil {
+				return err
+			}
+			result.DraftedOrders = append(result.DraftedOrders, order.ID)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// RunReorderChecks checks every entity with reorder rules each interval until
+// ctx is cancelled
+func RunReorderChecks(ctx context.Context, interval time.Duration, settings ReorderSettings) {
+	logger := loggers.InitializeLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var entityIDs []uint
+		if err := initializers.DB.Model(&models.StockLevel{}).Where("reorder_level > 0").
+			Distinct("entity_id").Pluck("entity_id", &entityIDs).Error; err != nil {
+			logger.Error("Failed to list entities for reorder check", "error", err.Error())
+			continue
+		}
+		for _, entityID := range entityIDs {
+			result, err := CheckReorders(initializers.DB, entityID, settings, time.Now())
+			if err != nil {
+				logger.Error("Reorder check failed", "entity_id", entityID, "error", err.Error())
+				continue
+			}
+			if len(result.Suggestions) > 0 {
+				logger.Info("Reorder check found low stock", "entity_id", entityID, "medicines", len(result.Suggestions),
+					"alerts", result.Alerts, "drafted_orders", len(result.DraftedOrders))
+			}
+		}
+	}
+}
+
+func lowStockAlert(suggestion ReorderSuggestion) models.InAppNotification {
+	severity := models.SeverityWarning
+	title := "Low stock: " + suggestion.MedicineName
+	if suggestion.Usable <= 0 {
+		severity = models.SeverityCritical
+		title = "Out of stock: " + suggestion.MedicineName
+	}
+	body := fmt.Sprintf("%d usable, %d on order, reorder threshold %d (average use %.2f a day). Suggested order: %d.",
+		suggestion.Usable, suggestion.OnOrder, suggestion.Threshold, suggestion.AverageDailyUse, suggestion.SuggestedQuantity)
+	if suggestion.SupplierID == nil {
+		body += " No supplier is known for this medicine."
+	}
+	medicineID := suggestion.MedicineID
+	return models.InAppNotification{
+		EntityID:      suggestion.EntityID,
+		Category:      "LowStock",
+		Severity:      severity,
+		Title:         title,
+		Body:          body,
+		ReferenceType: "Medicine",
+		ReferenceID:   &medicineID,
+		DedupKey:      fmt.Sprintf("low-stock:%d", suggestion.MedicineID),
+	}
+}
+
+// lastPurchase fills in the supplier, unless one is preferred, and unit price
+// of the entity's latest purchase of the medicine, falling back to the price
+// of its newest batch
+func lastPurchase(db *gorm.DB, entityID uint, suggestion *ReorderSuggestion) error {
+	var last struct {
+		SupplierID uint
+		UnitPrice  float64
+	}
+	if err := db.Table("purchase_order_item").
+		Select("purchase_order.supplier_id, purchase_order_item.unit_price").
+		Joins("JOIN purchase_order ON purchase_order.id = purchase_order_item.purchase_order_id").
+		Where("purchase_order.entity_id = ? AND purchase_order_item.medicine_id = ? AND purchase_order.status <> ?",
+			entityID, suggestion.MedicineID, models.PurchaseOrderCancelled).
+		Order("purchase_order.created_at DESC").Limit(1).Scan(&last).Error; err != nil {
+		return err
+	}
+	if suggestion.SupplierID == nil && last.SupplierID != 0 {
+		suggestion.SupplierID = &last.SupplierID
+	}
+	suggestion.UnitPrice = last.UnitPrice
+	if last.SupplierID == 0 {
+		var batch models.MedicineBatch
+		if err := db.Where("entity_id = ? AND medicine_id = ?", entityID, suggestion.MedicineID).
+			Order("created_at DESC").Limit(1).Find(&batch).Error; err != nil {
+			return err
+		}
+		suggestion.UnitPrice = batch.PurchasePrice
+	}
+	return nil
+}
+
+// sumByMedicine runs a query selecting medicine_id and total
+func sumByMedicine(query *gorm.DB) (map[uint]int, error) {
+	var rows []struct {
+		MedicineID uint
+		Total      int
+	}
+	if err := query.Group("medicine_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	totals := map[uint]int{}
+	for _, row := range rows {
+		totals[row.MedicineID] = row.Total
+	}
+	return totals, nil
+}
@@ -0,0 +1,380 @@
+package pharmacy
+
+import (
+	"apps90-hms/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransferState        = errors.New("stock transfer is not in a state that allows this")
+	ErrTransferSameEntity   = errors.New("stock can only be transferred between different entities")
+	ErrTransferSelfApproval = errors.New("transfers must be approved by someone other than who requested them")
+	ErrNotOnTransfer        = errors.New("line is not on this transfer")
+	ErrShortExceedsSent     = errors.New("short quantity exceeds what was dispatched")
+	ErrNotInSenderCatalog   = errors.New("medicine is not in the sending entity's catalog")
+	ErrNoCounterpart        = errors.New("receiving entity has no medicine of the same name, strength and form")
+)
+
+// TransferLine is one medicine requested from another entity
+type TransferLine struct {
+	MedicineID uint
+	Quantity   int
+}
+
+// ShortLine records units of a dispatched batch line that did not arrive in
+// usable condition. Reason is Lost or Damaged.
+type ShortLine struct {
+	StockTransferBatchID uint
+	Quantity             int
+	Reason               string
+}
+
+// RequestTransfer saves transfer as a request with one item per line. Lines
+// name medicines of the sending entity; each is matched to the receiving
+// entity's medicine of the same name, strength and dosage form.
+func RequestTransfer(tx *gorm.DB, transfer *models.StockTransfer, lines []TransferLine) error {
+	if transfer.FromEntityID == transfer.ToEntityID {
+		return ErrTransferSameEntity
+	}
+	seen := map[uint]bool{}
+	transfer.Status = models.StockTransferRequested
+	transfer.Items = nil
+	for _, line := range lines {
+		if seen[line.MedicineID] {
+			return fmt.Errorf("%w: medicine %d", ErrDuplicateMedicine, line.MedicineID)
+		}
+		seen[line.MedicineID] = true
+		if line.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+		destinationID, err := counterpart(tx, line.MedicineID, transfer.FromEntityID, transfer.ToEntityID)
+		if err != nil {
+			return err
+		}
+		transfer.Items = append(transfer.Items, models.StockTransferItem{
+			MedicineID:            line.MedicineID,
+			DestinationMedicineID: destinationID,
+			RequestedQuantity:     line.Quantity,
+		})
+	}
+	return tx.Create(transfer).Error
+}
+
+// ApproveTransfer approves a requested transfer on behalf of the sending
+// entity
+func ApproveTransfer(tx *gorm.DB, transferID, approverID uint) (models.StockTransfer, error) {
+	transfer, err := lockTransfer(tx, transferID, models.StockTransferRequested)
+	if err != nil {
+		return transfer, err
+	}
+	if transfer.RequestedByID == approverID {
+		return transfer, ErrTransferSelfApproval
+	}
+
+	now := time.Now()
+	transfer.Status = models.StockTransferApproved
+	transfer.ApprovedByID = &approverID
+	transfer.ApprovedAt = &now
+	return transfer, tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":         transfer.Status,
+		"approved_by_id": approverID,
+		"approved_at":    now,
+	}).Error
+}
+
+// RejectTransfer turns down a transfer that has not been dispatched
+func RejectTransfer(tx *gorm.DB, transferID uint, reason string) error {
+	return closeTransfer(tx, transferID, map[string]interface{}{
+		"status":           models.StockTransferRejected,
+		"rejection_reason": reason,
+	})
+}
+
+// CancelTransfer withdraws a transfer that has not been dispatched
+func CancelTransfer(tx *gorm.DB, transferID uint) error {
+	return closeTransfer(tx, transferID, map[string]interface{}{"status": models.StockTransferCancelled})
+}
+
+// DispatchTransfer takes an approved transfer out of the sending entity's
+// stock FEFO, posting a TransferOut movement per batch used. quantities
+// overrides the requested quantity per item ID and may only lower it; an item
+// dispatched at zero is left out. The stock is in transit until received.
+func DispatchTransfer(tx *gorm.DB, transferID, dispatchedByID uint, quantities map[uint]int) (models.StockTransfer, error) {
+	transfer, err := lockTransfer(tx, transferID, models.StockTransferApproved)
+	if err != nil {
+		return transfer, err
+	}
+	if err := tx.Where("stock_transfer_id = ?", transfer.ID).Order("id").Find(&transfer.Items).Error; err != nil {
+		return transfer, err
+	}
+	for itemID := range quantities {
+		if !hasItem(transfer.Items, itemID) {
+			return transfer, fmt.Errorf("%w: item %d", ErrNotOnTransfer, itemID)
+		}
+	}
+
+	now := time.Now()
+	total := 0
+	for i := range transfer.Items {
+		item := &transfer.Items[i]
+		quantity, ok := quantities[item.ID]
+		if !ok {
+			quantity = item.RequestedQuantity
+		}
+		if quantity < 0 || quantity > item.RequestedQuantity {
+			return transfer, fmt.Errorf("%w: item %d may dispatch up to %d", ErrInvalidQuantity, item.ID, item.RequestedQuantity)
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		allocations, err := PickFEFO(tx, transfer.FromEntityID, item.MedicineID, quantity, now)
+		if err != nil {
+			return transfer, err
+		}
+		for _, allocation := range allocations {
+			line := models.StockTransferBatch{
+				StockTransferItemID: item.ID,
+				SourceBatchID:       allocation.BatchID,
+				Quantity:            allocation.Quantity,
+			}
+			if allocation.BatchID != nil {
+				var batch models.MedicineBatch
+				if err := tx.First(&batch, *allocation.BatchID).Error; err != nil {
+					return transfer, err
+				}
+				line.BatchNumber = batch.BatchNumber
+				line.ExpiryDate = &batch.ExpiryDate
+				line.MRP = batch.MRP
+				line.PurchasePrice = batch.PurchasePrice
+			}
+
+			movement := models.StockMovement{
+				EntityID:      transfer.FromEntityID,
+				MedicineID:    item.MedicineID,
+				BatchID:       allocation.BatchID,
+				Type:          models.StockMovementTransferOut,
+				Quantity:      -allocation.Quantity,
+				ReferenceType: "StockTransfer",
+				ReferenceID:   &transfer.ID,
+				PerformedByID: dispatchedByID,
+				OccurredAt:    now,
+			}
+			if err := Post(tx, &movement); err != nil {
+				return transfer, err
+			}
+			if err := tx.Create(&line).Error; err != nil {
+				return transfer, err
+			}
+			item.Batches = append(item.Batches, line)
+		}
+
+		item.DispatchedQuantity = quantity
+		if err := tx.Model(item).Update("dispatched_quantity", quantity).Error; err != nil {
+			return transfer, err
+		}
+		total += quantity
+	}
+	if total == 0 {
+		return transfer, fmt.Errorf("%w: nothing to dispatch", ErrInvalidQuantity)
+	}
+
+	transfer.Status = models.StockTransferDispatched
+	transfer.DispatchedByID = &dispatchedByID
+	transfer.DispatchedAt = &now
+	return transfer, tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":           transfer.Status,
+		"dispatched_by_id": dispatchedByID,
+		"dispatched_at":    now,
+	}).Error
+}
+
+// ReceiveTransfer takes a dispatched transfer into the receiving entity's
+// stock. Every dispatched unit is posted as a TransferIn into a batch of the
+// same number and expiry; units listed in shortages are then written off as
+// lost or damaged, so the in-transit stock is fully accounted for.
+func ReceiveTransfer(tx *gorm.DB, transferID, receivedByID uint, shortages []ShortLine) (models.StockTransfer, error) {
+	transfer, err := lockTransfer(tx, transferID, models.StockTransferDispatched)
+	if err != nil {
+		return transfer, err
+	}
+	if err := tx.Preload("Batches", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("stock_transfer_id = ?", transfer.ID).Order("id").Find(&transfer.Items).Error; err != nil {
+		return transfer, err
+	}
+
+	short := map[uint]ShortLine{}
+	for _, shortage := range shortages {
+		if shortage.Reason == "" {
+			shortage.Reason = models.StockReasonLost
+		}
+		if shortage.Quantity < 0 || (shortage.Reason != models.StockReasonLost && shortage.Reason != models.StockReasonDamaged) {
+			return transfer, ErrInvalidQuantity
+		}
+		if _, ok := short[shortage.StockTransferBatchID]; ok {
+			return transfer, fmt.Errorf("%w: batch line %d listed twice", ErrInvalidQuantity, shortage.StockTransferBatchID)
+		}
+		short[shortage.StockTransferBatchID] = shortage
+	}
+
+	now := time.Now()
+	for i := range transfer.Items {
+		item := &transfer.Items[i]
+		for j := range item.Batches {
+			line := &item.Batches[j]
+			shortage := short[line.ID]
+			delete(short, line.ID)
+			if shortage.Quantity > line.Quantity {
+				return transfer, fmt.Errorf("%w: %d short of %d on batch line %d", ErrShortExceedsSent, shortage.Quantity, line.Quantity, line.ID)
+			}
+
+			if line.ExpiryDate != nil {
+				batch, err := FindOrCreateBatch(tx, transfer.ToEntityID, item.DestinationMedicineID, BatchDetails{
+					BatchNumber:   line.BatchNumber,
+					ExpiryDate:    *line.ExpiryDate,
+					MRP:           line.MRP,
+					PurchasePrice: line.PurchasePrice,
+				})
+				if err != nil {
+					return transfer, err
+				}
+				line.DestinationBatchID = &batch.ID
+			}
+
+			movement := models.StockMovement{
+				EntityID:      transfer.ToEntityID,
+				MedicineID:    item.DestinationMedicineID,
+				BatchID:       line.DestinationBatchID,
+				Type:          models.StockMovementTransferIn,
+				Quantity:      line.Quantity,
+				ReferenceType: "StockTransfer",
+				ReferenceID:   &transfer.ID,
+				PerformedByID: receivedByID,
+				OccurredAt:    now,
+			}
+			if err := Post(tx, &movement); err != nil {
+				return transfer, err
+			}
+			if shortage.Quantity > 0 {
+				writeOff := models.StockMovement{
+					EntityID:      transfer.ToEntityID,
+					MedicineID:    item.DestinationMedicineID,
+					BatchID:       line.DestinationBatchID,
+					Type:          models.StockMovementAdjustment,
+					Quantity:      -shortage.Quantity,
+					ReasonCode:    shortage.Reason,
+					Notes:         fmt.Sprintf("Short on receipt of stock transfer %d", transfer.ID),
+					ReferenceType: "StockTransfer",
+					ReferenceID:   &transfer.ID,
+					PerformedByID: receivedByID,
+					OccurredAt:    now,
+				}
+				if err := Post(tx, &writeOff); err != nil {
+					return transfer, err
+				}
+			}
+
+			line.ReceivedQuantity = line.Quantity - shortage.Quantity
+			line.ShortQuantity = shortage.Quantity
+			if err := tx.Model(line).Updates(map[string]interface{}{
+				"destination_batch_id": line.DestinationBatchID,
+				"received_quantity":    line.ReceivedQuantity,
+				"short_quantity":       line.ShortQuantity,
+			}).Error; err != nil {
+				return transfer, err
+			}
+			item.ReceivedQuantity += line.ReceivedQuantity
+		}
+		if err := tx.Model(item).Update("received_quantity", item.ReceivedQuantity).Error; err != nil {
+			return transfer, err
+		}
+	}
+	for lineID := range short {
+		return transfer, fmt.Errorf("%w: batch line %d", ErrNotOnTransfer, lineID)
+	}
+
+	transfer.Status = models.StockTransferReceived
+	transfer.ReceivedByID = &receivedByID
+	transfer.ReceivedAt = &now
+	return transfer, tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":         transfer.Status,
+		"received_by_id": receivedByID,
+		"received_at":    now,
+	}).Error
+}
+
+// IsTransferError reports whether err is a rejection of a stock transfer
+// step rather than a database failure
+func IsTransferError(err error) bool {
+	return IsStockError(err) || errors.Is(err, ErrTransferState) || errors.Is(err, ErrTransferSameEntity) ||
+		errors.Is(err, ErrTransferSelfApproval) || errors.Is(err, ErrNotOnTransfer) ||
+		errors.Is(err, ErrShortExceedsSent) || errors.Is(err, ErrDuplicateMedicine) ||
+		errors.Is(err, ErrNotInSenderCatalog) || errors.Is(err, ErrNoCounterpart)
+}
+
+// counterpart finds the receiving entity's medicine matching a medicine of
+// the sending entity by name, strength and dosage form
+func counterpart(tx *gorm.DB, medicineID, fromEntityID, toEntityID uint) (uint, error) {
+	var source models.Medicine
+	if err := tx.Where("id = ? AND entity_id = ?", medicineID, fromEntityID).Find(&source).Error; err != nil {
+		return 0, err
+	}
+	if source.ID == 0 {
+		return 0, fmt.Errorf("%w: medicine %d", ErrNotInSenderCatalog, medicineID)
+	}
+
+	var destination models.Medicine
+	if err := tx.Where("entity_id = ? AND LOWER(name) = LOWER(?) AND LOWER(strength) = LOWER(?) AND dosage_form = ?",
+		toEntityID, source.Name, source.Strength, source.DosageForm).Find(&destination).Error; err != nil {
+		return 0, err
+	}
+	if destination.ID == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoCounterpart, source.Name)
+	}
+	return destination.ID, nil
+}
+
+// lockTransfer returns the transfer locked for update, provided it is in the
+// given status
+func lockTransfer(tx *gorm.DB, transferID uint, status string) (models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, transferID).Error; err != nil {
+		return transfer, err
+	}
+	if transfer.Status != status {
+		return transfer, fmt.Errorf("%w: it is %s, expected %s", ErrTransferState, transfer.Status, status)
+	}
+	return transfer, nil
+}
+
+func closeTransfer(tx *gorm.DB, transferID uint, updates map[string]interface{}) error {
+	var transfer models.StockTransfer
+	if err := tx.First(&transfer, transferID).Error; err != nil {
+		return err
+	}
+	result := tx.Model(&models.StockTransfer{}).
+		Where("id = ? AND status IN ?", transferID, []string{models.StockTransferRequested, models.StockTransferApproved}).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: only transfers that have not been dispatched can be closed", ErrTransferState)
+	}
+	return nil
+}
+
+func hasItem(items []models.StockTransferItem, itemID uint) bool {
+	for _, item := range items {
+		if item.ID == itemID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	calendarController "apps90-hms/controllers/calendar"
+	"apps90-hms/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CalendarRoutes(r *gin.Engine) {
+	calendar := r.Group("/calendar")
+	{
+		calendar.POST("/doctor/token", middlewares.CheckAuth, calendarController.IssueCalendarToken)
+		calendar.GET("/doctor/:token", calendarController.GetDoctorFeed)
+		calendar.GET("/appointment.ics", middlewares.CheckAuth, calendarController.DownloadAppointment)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	documentController "apps90-hms/controllers/document"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DocumentRoutes(r *gin.Engine) {
+	document := r.Group("/document")
+	{
+		document.POST("/", documentController.UploadDocument)
+		document.GET("/", documentController.GetDocuments)
+		document.GET("/download", documentController.DownloadDocument)
+		document.DELETE("/", documentController.DeleteDocument)
+	}
+}
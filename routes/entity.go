@@ -18,10 +18,30 @@ func EntityRoutes(r *gin.Engine) {
 		entity.GET("/patient", entityController.GetPatientList)
 		entity.POST("/appointment", appointmentControllers.CreateAppointment)
 		entity.GET("/appointment", appointmentControllers.GetAppointments)
+		entity.PUT("/appointment", appointmentControllers.UpdateAppointment)
+		entity.POST("/appointment/recurring", appointmentControllers.CreateRecurringAppointments)
+		entity.POST("/appointment/cancel", appointmentControllers.CancelAppointment)
+		entity.POST("/waitlist", appointmentControllers.AddToWaitlist)
+		entity.GET("/waitlist", appointmentControllers.GetWaitlist)
+		entity.POST("/waitlist/remove", appointmentControllers.RemoveFromWaitlist)
+		entity.POST("/waitlist/offer/accept", appointmentControllers.AcceptWaitlistOffer)
+		entity.POST("/waitlist/offer/decline", appointmentControllers.DeclineWaitlistOffer)
 		entity.POST("/visit", appointmentControllers.CreateVisit)
+		entity.GET("/visit", appointmentControllers.GetVisit)
+		entity.PATCH("/visit", appointmentControllers.UpdateVisit)
+		entity.POST("/visit/close", appointmentControllers.CloseVisit)
+		entity.POST("/visit/admit", appointmentControllers.AdmitPatient)
+		entity.POST("/visit/transfer", appointmentControllers.TransferVisit)
+		entity.POST("/visit/discharge", appointmentControllers.DischargeVisit)
+		entity.GET("/visit/adt", appointmentControllers.GetAdtHistory)
 		entity.GET("/medicine", entityController.GetMedicines)
 		entity.POST("/medicine", entityController.AddMedicine)
+		entity.PATCH("/medicine", entityController.UpdateMedicine)
+		entity.GET("/medicine/search", entityController.SearchMedicines)
+		entity.POST("/medicine/import", entityController.ImportMedicines)
+		entity.GET("/medicine/import", entityController.GetImportJobs)
 		entity.POST("/category", entityController.AddMedicineCategory)
+		entity.PATCH("/category", entityController.UpdateMedicineCategory)
 
 	}
 }
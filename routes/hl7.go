@@ -0,0 +1,16 @@
+package routes
+
+import (
+	hl7Controller "apps90-hms/controllers/hl7"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Hl7Routes(r *gin.Engine) {
+	hl7 := r.Group("/hl7")
+	{
+		hl7.GET("/messages", hl7Controller.GetHl7Messages)
+		hl7.POST("/replay", hl7Controller.ReplayHl7Message)
+		hl7.POST("/order/send", hl7Controller.SendLabOrder)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	labController "apps90-hms/controllers/lab"
+	"apps90-hms/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LabRoutes(r *gin.Engine) {
+	lab := r.Group("/lab")
+	{
+		lab.POST("/test", labController.AddLabTest)
+		lab.GET("/test", labController.GetLabTests)
+		lab.POST("/order", labController.CreateLabOrder)
+		lab.GET("/order", labController.GetLabOrders)
+		lab.POST("/order/collect", labController.CollectSamples)
+		lab.PUT("/order/status", labController.UpdateLabOrderStatus)
+		lab.GET("/sample", labController.GetSampleByBarcode)
+		lab.POST("/analyte", labController.AddLabAnalyte)
+		lab.POST("/result", labController.EnterLabResults)
+		lab.GET("/result", labController.GetLabResults)
+		lab.POST("/result/verify", middlewares.CheckAuth, labController.VerifyLabResults)
+		lab.GET("/result/cumulative", labController.GetCumulativeResults)
+	}
+}
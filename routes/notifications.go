@@ -0,0 +1,16 @@
+package routes
+
+import (
+	notificationController "apps90-hms/controllers/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NotificationRoutes(r *gin.Engine) {
+	notification := r.Group("/notification")
+	{
+		notification.GET("/", notificationController.GetNotifications)
+		notification.GET("/feed", notificationController.GetNotificationFeed)
+		notification.POST("/feed/read", notificationController.MarkNotificationsRead)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	patientController "apps90-hms/controllers/patient"
+	"apps90-hms/middlewares"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +15,15 @@ func PatientRoutes(r *gin.Engine) {
 		patient.POST("/prescription", patientController.CreatePrescription)
 		patient.GET("/prescription", patientController.GetPrescriptionDetails)
 		patient.PUT("/prescription", patientController.EditPrescription)
+		patient.POST("/discharge-summary", patientController.GenerateDischargeSummary)
+		patient.PUT("/discharge-summary", patientController.UpdateDischargeSummary)
+		patient.POST("/discharge-summary/sign", middlewares.CheckAuth, patientController.SignDischargeSummary)
+		patient.GET("/discharge-summary", patientController.GetDischargeSummary)
+		patient.POST("/notes", patientController.CreateClinicalNote)
+		patient.PUT("/notes", patientController.UpdateClinicalNote)
+		patient.POST("/notes/sign", patientController.SignClinicalNote)
+		patient.POST("/notes/addendum", patientController.AddAddendum)
+		patient.GET("/notes", patientController.GetClinicalNotes)
 
 	}
 }
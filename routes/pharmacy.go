@@ -0,0 +1,44 @@
+package routes
+
+import (
+	pharmacyController "apps90-hms/controllers/pharmacy"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PharmacyRoutes(r *gin.Engine) {
+	pharmacy := r.Group("/pharmacy")
+	{
+		pharmacy.POST("/stock/movement", pharmacyController.RecordStockMovement)
+		pharmacy.GET("/stock", pharmacyController.GetStockLevels)
+		pharmacy.GET("/stock/card", pharmacyController.GetStockCard)
+		pharmacy.PUT("/stock/reorder", pharmacyController.SetReorderRule)
+		pharmacy.GET("/reorder/suggestions", pharmacyController.GetReorderSuggestions)
+		pharmacy.POST("/reorder/run", pharmacyController.RunReorderCheck)
+		pharmacy.GET("/batch", pharmacyController.GetBatches)
+		pharmacy.GET("/batch/expiring", pharmacyController.GetNearExpiryReport)
+		pharmacy.POST("/batch/write-off-expired", pharmacyController.WriteOffExpiredBatches)
+		pharmacy.GET("/batch/pick", pharmacyController.PreviewFEFO)
+		pharmacy.GET("/prescription", pharmacyController.GetDispensablePrescription)
+		pharmacy.POST("/dispense", pharmacyController.DispensePrescription)
+		pharmacy.GET("/dispense", pharmacyController.GetDispenses)
+		pharmacy.POST("/supplier", pharmacyController.AddSupplier)
+		pharmacy.PUT("/supplier", pharmacyController.UpdateSupplier)
+		pharmacy.GET("/supplier", pharmacyController.GetSuppliers)
+		pharmacy.POST("/purchase-order", pharmacyController.CreatePurchaseOrder)
+		pharmacy.GET("/purchase-order", pharmacyController.GetPurchaseOrders)
+		pharmacy.POST("/purchase-order/approve", pharmacyController.ApprovePurchaseOrder)
+		pharmacy.POST("/purchase-order/cancel", pharmacyController.CancelPurchaseOrder)
+		pharmacy.GET("/purchase-order/discrepancy", pharmacyController.GetPurchaseOrderDiscrepancies)
+		pharmacy.POST("/grn", pharmacyController.ReceiveGoods)
+		pharmacy.GET("/grn", pharmacyController.GetGoodsReceipts)
+		pharmacy.POST("/transfer", pharmacyController.RequestStockTransfer)
+		pharmacy.GET("/transfer", pharmacyController.GetStockTransfers)
+		pharmacy.POST("/transfer/approve", pharmacyController.ApproveStockTransfer)
+		pharmacy.POST("/transfer/reject", pharmacyController.RejectStockTransfer)
+		pharmacy.POST("/transfer/cancel", pharmacyController.CancelStockTransfer)
+		pharmacy.POST("/transfer/dispatch", pharmacyController.DispatchStockTransfer)
+		pharmacy.POST("/transfer/receive", pharmacyController.ReceiveStockTransfer)
+		pharmacy.GET("/transfer/in-transit", pharmacyController.GetInTransitStock)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	queueController "apps90-hms/controllers/queue"
+
+	"github.com/gin-gonic/gin"
+)
+
+func QueueRoutes(r *gin.Engine) {
+	queue := r.Group("/queue")
+	{
+		queue.GET("/", queueController.GetQueue)
+		queue.GET("/stream", queueController.StreamQueue)
+		queue.POST("/checkin", queueController.CheckIn)
+		queue.POST("/next", queueController.CallNext)
+		queue.POST("/skip", queueController.SkipToken)
+		queue.POST("/recall", queueController.RecallToken)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	radiologyController "apps90-hms/controllers/radiology"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RadiologyRoutes(r *gin.Engine) {
+	radiology := r.Group("/radiology")
+	{
+		radiology.POST("/order", radiologyController.CreateRadiologyOrder)
+		radiology.GET("/order", radiologyController.GetRadiologyOrders)
+		radiology.POST("/order/schedule", radiologyController.ScheduleRadiologyOrder)
+		radiology.POST("/order/perform", radiologyController.MarkRadiologyPerformed)
+		radiology.POST("/order/cancel", radiologyController.CancelRadiologyOrder)
+		radiology.POST("/report", radiologyController.SaveRadiologyReport)
+		radiology.POST("/attachment", radiologyController.UploadRadiologyAttachment)
+		radiology.GET("/attachment", radiologyController.DownloadRadiologyAttachment)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	referralController "apps90-hms/controllers/referral"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReferralRoutes(r *gin.Engine) {
+	referral := r.Group("/referral")
+	{
+		referral.POST("/", referralController.CreateReferral)
+		referral.GET("/inbox", referralController.GetReferralInbox)
+		referral.POST("/accept", referralController.AcceptReferral)
+		referral.POST("/decline", referralController.DeclineReferral)
+		referral.GET("/visit", referralController.GetReferredVisit)
+	}
+}
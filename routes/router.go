@@ -18,9 +18,9 @@ func InitRoutes() *gin.Engine {
 	FrontendUrl := os.Getenv("FRONTEND_URL")
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{FrontendUrl}, // Allow frontend domain
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "ETag"},
 		AllowCredentials: true, // If using cookies or auth headers
 		MaxAge:           12 * time.Hour,
 	}))
@@ -29,6 +29,16 @@ func InitRoutes() *gin.Engine {
 	AuthRoutes(router)
 	EntityRoutes(router)
 	PatientRoutes(router)
+	QueueRoutes(router)
+	NotificationRoutes(router)
+	CalendarRoutes(router)
+	WardRoutes(router)
+	ReferralRoutes(router)
+	LabRoutes(router)
+	Hl7Routes(router)
+	RadiologyRoutes(router)
+	DocumentRoutes(router)
+	PharmacyRoutes(router)
 
 	return router
 }
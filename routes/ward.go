@@ -0,0 +1,18 @@
+package routes
+
+import (
+	wardController "apps90-hms/controllers/ward"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WardRoutes(r *gin.Engine) {
+	ward := r.Group("/ward")
+	{
+		ward.POST("/", wardController.AddWard)
+		ward.POST("/room", wardController.AddRoom)
+		ward.POST("/bed", wardController.AddBed)
+		ward.PUT("/bed/status", wardController.UpdateBedStatus)
+		ward.GET("/occupancy", wardController.GetOccupancyBoard)
+	}
+}
@@ -0,0 +1,32 @@
+package scheduling
+
+import (
+	"apps90-hms/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FindConflict returns a scheduled appointment for the same doctor or patient
+// that overlaps [start, start+duration), ignoring the excludeIDs.
+func FindConflict(db *gorm.DB, doctorID, patientID uint, start time.Time, durationMinutes int, excludeIDs ...uint) (*models.Appointment, error) {
+	end := start.Add(time.Duration(durationMinutes) * time.Minute)
+
+	query := db.
+		Where("(employee_id = ? OR patient_id = ?) AND status <> ?",
+			doctorID, patientID, models.AppointmentStatusCancelled).
+		Where("appointment_time < ? AND appointment_time + (duration_minutes * interval '1 minute') > ?", end, start)
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+
+	var existing models.Appointment
+	result := query.Limit(1).Find(&existing)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &existing, nil
+}
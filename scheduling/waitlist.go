@@ -0,0 +1,160 @@
+package scheduling
+
+import (
+	"apps90-hms/initializers"
+	"apps90-hms/loggers"
+	"apps90-hms/models"
+	"apps90-hms/notifications"
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Used when WAITLIST_OFFER_MINUTES is not set
+const defaultOfferMinutes = 120
+
+// offerWindow is how long a waitlisted patient has to accept an offered slot
+func offerWindow() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("WAITLIST_OFFER_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultOfferMinutes * time.Minute
+}
+
+// OfferFreedSlot offers a slot freed by a cancelled appointment to the most
+// urgent waitlisted patient for the doctor whose preferred dates cover it.
+// Patients who were already offered this slot, or who are busy at that time,
+// are passed over, and nothing is offered once the doctor is booked again.
+// It returns nil when the slot is in the past or nobody fits.
+func OfferFreedSlot(tx *gorm.DB, doctorID uint, slot time.Time, durationMinutes int, sourceAppointmentID uint) (*models.WaitlistOffer, error) {
+	now := time.Now()
+	if !slot.After(now) {
+		return nil, nil
+	}
+	slotDay := slot.Format("2006-01-02")
+
+	var entries []models.WaitlistEntry
+	if err := tx.Preload("Patient").Preload("Doctor").Preload("Entity").
+		Where("doctor_id = ? AND status = ? AND preferred_from <= ? AND preferred_to >= ?",
+			doctorID, models.WaitlistStatusWaiting, slotDay, slotDay).
+		Where("id NOT IN (?)", tx.Model(&models.WaitlistOffer{}).
+			Select("waitlist_entry_id").Where("source_appointment_id = ?", sourceAppointmentID)).
+		Order("priority, created_at").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		conflict, err := FindConflict(tx, doctorID, entry.PatientID, slot, durationMinutes)
+		if err != nil {
+			return nil, err
+		}
+		if conflict != nil {
+			continue
+		}
+
+		expiresAt := now.Add(offerWindow())
+		if expiresAt.After(slot) {
+			expiresAt = slot
+		}
+
+		offer := models.WaitlistOffer{
+			WaitlistEntryID:     entry.ID,
+			SlotTime:            slot,
+			DurationMinutes:     durationMinutes,
+			ExpiresAt:           expiresAt,
+			Status:              models.OfferStatusPending,
+			SourceAppointmentID: sourceAppointmentID,
+		}
+		if err := tx.Create(&offer).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Model(&entry).Update("status", models.WaitlistStatusOffered).Error; err != nil {
+			return nil, err
+		}
+
+		body := "Dear " + entry.Patient.FirstName + ", a slot with Dr. " + entry.Doctor.FirstName + " " + entry.Doctor.LastName +
+			" at " + entry.Entity.Name + " is available on " + slot.Format("Mon, 02 Jan 2006 at 15:04") +
+			". Please contact us before " + expiresAt.Format("02 Jan 15:04") + " to accept it."
+		if err := notifications.EnqueuePatientMessage(tx, entry.Patient, entry.EntityID, "Appointment slot available", body); err != nil {
+			return nil, err
+		}
+
+		loggers.InitializeLogger().Info("Waitlist slot offered", "offer_id", offer.ID, "waitlist_entry_id", entry.ID, "slot_time", slot)
+		return &offer, nil
+	}
+
+	return nil, nil
+}
+
+// ReleaseOffer returns the entry of a declined or expired offer to the
+// waitlist and passes the slot on to the next patient in line.
+func ReleaseOffer(tx *gorm.DB, offer models.WaitlistOffer, status string) error {
+	if err := tx.Model(&offer).Update("status", status).Error; err != nil {
+		return err
+	}
+
+	var entry models.WaitlistEntry
+	if err := tx.First(&entry, offer.WaitlistEntryID).Error; err != nil {
+		return err
+	}
+	if entry.Status == models.WaitlistStatusOffered {
+		if err := tx.Model(&entry).Update("status", models.WaitlistStatusWaiting).Error; err != nil {
+			return err
+		}
+	}
+
+	_, err := OfferFreedSlot(tx, entry.DoctorID, offer.SlotTime, offer.DurationMinutes, offer.SourceAppointmentID)
+	return err
+}
+
+// ExpireOffers releases every pending offer past its expiry time
+func ExpireOffers() (int, error) {
+	expired := 0
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var offers []models.WaitlistOffer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", models.OfferStatusPending, time.Now()).
+			Find(&offers).Error; err != nil {
+			return err
+		}
+
+		for _, offer := range offers {
+			if err := ReleaseOffer(tx, offer, models.OfferStatusExpired); err != nil {
+				return err
+			}
+			expired++
+		}
+		return nil
+	})
+
+	return expired, err
+}
+
+// RunOfferExpiry expires waitlist offers every interval until ctx is cancelled
+func RunOfferExpiry(ctx context.Context, interval time.Duration) {
+	logger := loggers.InitializeLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := ExpireOffers()
+		if err != nil {
+			logger.Error("Failed to expire waitlist offers", "error", err.Error())
+			continue
+		}
+		if expired > 0 {
+			logger.Info("Waitlist offers expired", "count", expired)
+		}
+	}
+}
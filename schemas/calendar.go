@@ -0,0 +1,5 @@
+package schemas
+
+type CalendarTokenInput struct {
+	DoctorID uint `json:"doctor_id" binding:"required"`
+}
@@ -0,0 +1,56 @@
+package schemas
+
+import "time"
+
+// ClinicalNoteInput creates a draft note. SOAP notes use the four SOAP
+// sections; progress and nursing notes use Body.
+type ClinicalNoteInput struct {
+	VisitID    uint       `json:"visit_id" binding:"required"`
+	AuthorID   uint       `json:"author_id" binding:"required"`
+	NoteType   string     `json:"note_type" binding:"required,oneof=SOAP Progress Nursing"`
+	NoteTime   *time.Time `json:"note_time,omitempty"` // Defaults to now
+	Subjective string     `json:"subjective"`
+	Objective  string     `json:"objective"`
+	Assessment string     `json:"assessment"`
+	Plan       string     `json:"plan"`
+	Body       string     `json:"body"`
+}
+
+// UpdateClinicalNoteInput edits a draft note; omitted fields are left unchanged
+type UpdateClinicalNoteInput struct {
+	NoteID     uint    `json:"note_id" binding:"required"`
+	AuthorID   uint    `json:"author_id" binding:"required"`
+	Subjective *string `json:"subjective"`
+	Objective  *string `json:"objective"`
+	Assessment *string `json:"assessment"`
+	Plan       *string `json:"plan"`
+	Body       *string `json:"body"`
+}
+
+type SignClinicalNoteInput struct {
+	NoteID   uint `json:"note_id" binding:"required"`
+	AuthorID uint `json:"author_id" binding:"required"`
+}
+
+type AddendumInput struct {
+	NoteID   uint   `json:"note_id" binding:"required"`
+	AuthorID uint   `json:"author_id" binding:"required"`
+	Body     string `json:"body" binding:"required"`
+}
+
+type ClinicalNoteResponse struct {
+	ID         uint                   `json:"id"`
+	VisitID    uint                   `json:"visit_id"`
+	NoteType   string                 `json:"note_type"`
+	NoteTime   time.Time              `json:"note_time"`
+	AuthorID   uint                   `json:"author_id"`
+	AuthorName string                 `json:"author_name"`
+	Subjective string                 `json:"subjective,omitempty"`
+	Objective  string                 `json:"objective,omitempty"`
+	Assessment string                 `json:"assessment,omitempty"`
+	Plan       string                 `json:"plan,omitempty"`
+	Body       string                 `json:"body,omitempty"`
+	Status     string                 `json:"status"`
+	SignedAt   *time.Time             `json:"signed_at,omitempty"`
+	Addenda    []ClinicalNoteResponse `json:"addenda"`
+}
@@ -0,0 +1,10 @@
+package schemas
+
+// DocumentUploadInput is the multipart form accompanying an uploaded file
+type DocumentUploadInput struct {
+	PatientID    uint   `form:"patient_id" binding:"required"`
+	VisitID      *uint  `form:"visit_id"`
+	Category     string `form:"category" binding:"required,oneof=Referral Report Consent Identity Other"`
+	Title        string `form:"title" binding:"max=255"`
+	UploadedByID uint   `form:"uploaded_by_id" binding:"required"`
+}
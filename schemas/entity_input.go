@@ -46,6 +46,50 @@ type AppointmentInput struct {
 	EntityID        uint      `json:"entity_id"`
 }
 
+// RecurringAppointmentInput books a series of appointments from a recurrence rule.
+// Either Rule (RRULE-style, e.g. "FREQ=WEEKLY;INTERVAL=1;COUNT=10") or the
+// structured Frequency/Interval/Count/Until fields may be supplied.
+type RecurringAppointmentInput struct {
+	AppointmentTime time.Time  `json:"appointment_time" binding:"required"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Reason          string     `json:"reason"`
+	Notes           string     `json:"notes"`
+	PatientID       uint       `json:"patient_id" binding:"required"`
+	DoctorID        uint       `json:"doctor_id" binding:"required"`
+	EntityID        uint       `json:"entity_id" binding:"required"`
+	Rule            string     `json:"rule"`
+	Frequency       string     `json:"frequency"` // DAILY or WEEKLY
+	Interval        int        `json:"interval"`
+	Count           int        `json:"count"`
+	Until           *time.Time `json:"until"`
+}
+
+// SkippedOccurrence reports an occurrence of a series that could not be booked
+type SkippedOccurrence struct {
+	AppointmentTime time.Time `json:"appointment_time"`
+	Reason          string    `json:"reason"`
+}
+
+// UpdateAppointmentInput edits an appointment. Scope is "occurrence" (default),
+// "following" for this and all later occurrences of its series, or "series"
+// for the entire series.
+type UpdateAppointmentInput struct {
+	AppointmentID   uint       `json:"appointment_id" binding:"required"`
+	Scope           string     `json:"scope" binding:"omitempty,oneof=occurrence following series"`
+	AppointmentTime *time.Time `json:"appointment_time"`
+	DurationMinutes *int       `json:"duration_minutes"`
+	Reason          *string    `json:"reason"`
+	Notes           *string    `json:"notes"`
+	DoctorID        *uint      `json:"doctor_id"`
+}
+
+// CancelAppointmentInput cancels an appointment, the rest of its series or
+// all of it
+type CancelAppointmentInput struct {
+	AppointmentID uint   `json:"appointment_id" binding:"required"`
+	Scope         string `json:"scope" binding:"omitempty,oneof=occurrence following series"` // "occurrence" (default), "following" or "series"
+}
+
 type VisitInput struct {
 	PatientID     uint       `json:"patient_id"`
 	DoctorID      uint       `json:"doctor_id"`
@@ -57,7 +101,57 @@ type VisitInput struct {
 	Diagnosis     string     `json:"diagnosis"`
 	TreatmentPlan string     `json:"treatment_plan"`
 	Notes         string     `json:"notes"`
-	VisitType     string     `json:"visit_type"` // IP or OP
+	VisitType     string     `json:"visit_type"`        // IP or OP
+	BedID         *uint      `json:"bed_id,omitempty"`  // IP only: allocate this bed
+	WardID        *uint      `json:"ward_id,omitempty"` // IP only: allocate any free bed in this ward
+}
+
+// UpdateVisitInput edits a visit; omitted fields are left unchanged.
+// Admission and discharge dates only apply to IP visits, and an open IP visit
+// is discharged through the discharge operation rather than by setting a date.
+type UpdateVisitInput struct {
+	VisitID       uint       `json:"visit_id" binding:"required"`
+	Diagnosis     *string    `json:"diagnosis"`
+	TreatmentPlan *string    `json:"treatment_plan"`
+	Notes         *string    `json:"notes"`
+	RoomNumber    *string    `json:"room_number"`
+	AdmissionDate *time.Time `json:"admission_date"`
+	DischargeDate *time.Time `json:"discharge_date"`
+}
+
+type CloseVisitInput struct {
+	VisitID uint `json:"visit_id" binding:"required"`
+}
+
+// AdmitInput opens an IP visit. Either BedID or WardID may be given to
+// allocate a bed at admission.
+type AdmitInput struct {
+	PatientID     uint       `json:"patient_id" binding:"required"`
+	DoctorID      uint       `json:"doctor_id" binding:"required"`
+	AppointmentID *uint      `json:"appointment_id,omitempty"`
+	AdmissionDate *time.Time `json:"admission_date,omitempty"` // Defaults to now
+	BedID         *uint      `json:"bed_id,omitempty"`
+	WardID        *uint      `json:"ward_id,omitempty"`
+	Diagnosis     string     `json:"diagnosis"`
+	TreatmentPlan string     `json:"treatment_plan"`
+	Notes         string     `json:"notes"`
+}
+
+// TransferVisitInput moves an admitted patient. At least one of BedID, WardID
+// or DoctorID is required.
+type TransferVisitInput struct {
+	VisitID      uint       `json:"visit_id" binding:"required"`
+	BedID        *uint      `json:"bed_id,omitempty"`
+	WardID       *uint      `json:"ward_id,omitempty"`
+	DoctorID     *uint      `json:"doctor_id,omitempty"`
+	TransferTime *time.Time `json:"transfer_time,omitempty"` // Defaults to now
+	Notes        string     `json:"notes"`
+}
+
+type DischargeVisitInput struct {
+	VisitID       uint       `json:"visit_id" binding:"required"`
+	DischargeDate *time.Time `json:"discharge_date,omitempty"` // Defaults to now
+	Notes         string     `json:"notes"`
 }
 
 type PrescriptionResponse struct {
@@ -66,6 +160,11 @@ type PrescriptionResponse struct {
 
 type VisitResponse struct {
 	ID            uint                   `json:"id"`
+	PatientID     uint                   `json:"patient_id,omitempty"`
+	DoctorID      uint                   `json:"doctor_id,omitempty"`
+	AppointmentID *uint                  `json:"appointment_id,omitempty"`
+	BedID         *uint                  `json:"bed_id,omitempty"`
+	ClosedAt      *time.Time             `json:"closed_at,omitempty"`
 	VisitDate     time.Time              `json:"visit_date"`
 	AdmissionDate *time.Time             `json:"admission_date,omitempty"`
 	DischargeDate *time.Time             `json:"discharge_date,omitempty"`
@@ -85,8 +184,16 @@ type MedicineCategoryResponse struct {
 }
 
 type MedicineResponse struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID           uint    `json:"id"`
+	Name         string  `json:"name"`
+	GenericName  string  `json:"generic_name,omitempty"`
+	Strength     string  `json:"strength,omitempty"`
+	DosageForm   string  `json:"dosage_form,omitempty"`
+	Manufacturer string  `json:"manufacturer,omitempty"`
+	PackSize     int     `json:"pack_size,omitempty"`
+	MRP          float64 `json:"mrp"`
+	SellingPrice float64 `json:"selling_price"`
+	Stock        *int    `json:"stock,omitempty"` // Usable units at the entity, when listed for one
 }
 
 type MedicineCategoryRequest struct {
@@ -95,8 +202,78 @@ type MedicineCategoryRequest struct {
 	EntityID    uint   `json:"entity_id" binding:"required"`
 }
 
+// MedicineRequest adds a medicine to the catalog. SellingPrice may not exceed
+// MRP when both are given.
 type MedicineRequest struct {
-	Name       string `json:"name" binding:"required"`
-	CategoryID uint   `json:"category_id" binding:"required"`
-	EntityID   uint   `json:"entity_id" binding:"required"`
+	Name         string  `json:"name" binding:"required,max=100"`
+	CategoryID   uint    `json:"category_id" binding:"required"`
+	EntityID     uint    `json:"entity_id" binding:"required"`
+	GenericName  string  `json:"generic_name" binding:"max=150"`
+	Strength     string  `json:"strength" binding:"max=50"`
+	DosageForm   string  `json:"dosage_form" binding:"omitempty,oneof=Tablet Capsule Syrup Suspension Injection Infusion Ointment Cream Drops Inhaler Powder Other"`
+	Manufacturer string  `json:"manufacturer" binding:"max=150"`
+	Description  string  `json:"description"`
+	HSNCode      string  `json:"hsn_code" binding:"omitempty,numeric,min=4,max=8"`
+	TaxRate      float64 `json:"tax_rate" binding:"min=0,max=100"`
+	PackSize     int     `json:"pack_size" binding:"min=0"`
+	MRP          float64 `json:"mrp" binding:"min=0"`
+	SellingPrice float64 `json:"selling_price" binding:"min=0"`
+}
+
+// UpdateMedicineInput edits a catalog medicine; omitted fields are left
+// unchanged
+type UpdateMedicineInput struct {
+	MedicineID   uint     `json:"medicine_id" binding:"required"`
+	Name         *string  `json:"name" binding:"omitempty,min=1,max=100"`
+	CategoryID   *uint    `json:"category_id"`
+	GenericName  *string  `json:"generic_name" binding:"omitempty,max=150"`
+	Strength     *string  `json:"strength" binding:"omitempty,max=50"`
+	DosageForm   *string  `json:"dosage_form" binding:"omitempty,oneof=Tablet Capsule Syrup Suspension Injection Infusion Ointment Cream Drops Inhaler Powder Other"`
+	Manufacturer *string  `json:"manufacturer" binding:"omitempty,max=150"`
+	Description  *string  `json:"description"`
+	HSNCode      *string  `json:"hsn_code" binding:"omitempty,numeric,min=4,max=8"`
+	TaxRate      *float64 `json:"tax_rate" binding:"omitempty,min=0,max=100"`
+	PackSize     *int     `json:"pack_size" binding:"omitempty,min=0"`
+	MRP          *float64 `json:"mrp" binding:"omitempty,min=0"`
+	SellingPrice *float64 `json:"selling_price" binding:"omitempty,min=0"`
+	IsActive     *bool    `json:"is_active"`
+}
+
+// UpdateMedicineCategoryInput edits a medicine category; omitted fields are
+// left unchanged
+type UpdateMedicineCategoryInput struct {
+	CategoryID  uint    `json:"category_id" binding:"required"`
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// WaitlistInput parks a patient on a doctor's waitlist. Dates are YYYY-MM-DD
+// and Priority runs from 1 (most urgent) to 5.
+type WaitlistInput struct {
+	PatientID     uint   `json:"patient_id" binding:"required"`
+	DoctorID      uint   `json:"doctor_id" binding:"required"`
+	EntityID      uint   `json:"entity_id" binding:"required"`
+	PreferredFrom string `json:"preferred_from" binding:"required"`
+	PreferredTo   string `json:"preferred_to" binding:"required"`
+	Priority      int    `json:"priority" binding:"omitempty,min=1,max=5"`
+	Reason        string `json:"reason"`
+}
+
+type WaitlistEntryActionInput struct {
+	WaitlistEntryID uint `json:"waitlist_entry_id" binding:"required"`
+}
+
+type WaitlistOfferActionInput struct {
+	OfferID uint `json:"offer_id" binding:"required"`
+}
+
+// MedicineImportInput is the multipart form accompanying a CSV or XLSX
+// catalog. Mapping is an optional JSON object of field to column header, for
+// columns not recognised by name, e.g. {"name": "Brand", "mrp": "M.R.P."}.
+type MedicineImportInput struct {
+	EntityID    uint   `form:"entity_id" binding:"required"`
+	StartedByID uint   `form:"started_by_id" binding:"required"`
+	DryRun      bool   `form:"dry_run"`
+	Mapping     string `form:"mapping"`
 }
@@ -0,0 +1,9 @@
+package schemas
+
+type Hl7ReplayInput struct {
+	MessageID uint `json:"message_id" binding:"required"`
+}
+
+type Hl7SendOrderInput struct {
+	OrderID uint `json:"order_id" binding:"required"`
+}
@@ -0,0 +1,95 @@
+package schemas
+
+import "time"
+
+// LabTestInput adds a test to an entity's catalog. Listing component_ids
+// makes it a panel of those tests.
+type LabTestInput struct {
+	EntityID        uint    `json:"entity_id" binding:"required"`
+	Code            string  `json:"code" binding:"required,max=30"`
+	Name            string  `json:"name" binding:"required"`
+	SpecimenType    string  `json:"specimen_type" binding:"required"`
+	Price           float64 `json:"price" binding:"min=0"`
+	TurnaroundHours int     `json:"turnaround_hours" binding:"min=0"`
+	ComponentIDs    []uint  `json:"component_ids"`
+}
+
+type LabOrderInput struct {
+	VisitID       uint   `json:"visit_id" binding:"required"`
+	DoctorID      uint   `json:"doctor_id" binding:"required"`
+	TestIDs       []uint `json:"test_ids" binding:"required,min=1"`
+	Priority      string `json:"priority" binding:"omitempty,oneof=Routine Stat"`
+	ClinicalNotes string `json:"clinical_notes"`
+}
+
+type CollectSamplesInput struct {
+	OrderID       uint `json:"order_id" binding:"required"`
+	CollectedByID uint `json:"collected_by_id" binding:"required"`
+}
+
+// LabOrderStatusInput moves an order along. Collection and result
+// verification have their own endpoints, so Collected and Resulted cannot be
+// set here.
+type LabOrderStatusInput struct {
+	OrderID uint   `json:"order_id" binding:"required"`
+	Status  string `json:"status" binding:"required,oneof=InProgress Cancelled"`
+}
+
+type ReferenceRangeInput struct {
+	Sex          string   `json:"sex" binding:"omitempty,oneof=Male Female Any"`
+	AgeMinDays   int      `json:"age_min_days" binding:"min=0"`
+	AgeMaxDays   *int     `json:"age_max_days"`
+	Low          *float64 `json:"low"`
+	High         *float64 `json:"high"`
+	CriticalLow  *float64 `json:"critical_low"`
+	CriticalHigh *float64 `json:"critical_high"`
+}
+
+type LabAnalyteInput struct {
+	LabTestID uint                  `json:"lab_test_id" binding:"required"`
+	Code      string                `json:"code" binding:"required,max=30"`
+	Name      string                `json:"name" binding:"required"`
+	Unit      string                `json:"unit"`
+	SortOrder int                   `json:"sort_order"`
+	Ranges    []ReferenceRangeInput `json:"ranges" binding:"dive"`
+}
+
+type LabResultValueInput struct {
+	AnalyteID uint   `json:"analyte_id" binding:"required"`
+	Value     string `json:"value" binding:"required"`
+}
+
+// LabResultEntryInput records analyte values for an order. Values that are
+// entered again replace earlier ones until they are verified.
+type LabResultEntryInput struct {
+	OrderID     uint                  `json:"order_id" binding:"required"`
+	EnteredByID uint                  `json:"entered_by_id" binding:"required"`
+	Results     []LabResultValueInput `json:"results" binding:"required,min=1,dive"`
+}
+
+// VerifyLabResultsInput verifies the given results of an order, or all of
+// its entered results when result_ids is empty
+type VerifyLabResultsInput struct {
+	OrderID   uint   `json:"order_id" binding:"required"`
+	ResultIDs []uint `json:"result_ids"`
+}
+
+type CumulativeResultPoint struct {
+	ResultID   uint      `json:"result_id"`
+	LabOrderID uint      `json:"lab_order_id"`
+	VisitID    uint      `json:"visit_id"`
+	ResultedAt time.Time `json:"resulted_at"`
+	Value      string    `json:"value"`
+	Flag       string    `json:"flag"`
+	RangeLow   *float64  `json:"range_low"`
+	RangeHigh  *float64  `json:"range_high"`
+}
+
+// CumulativeResultResponse is the history of one analyte for a patient
+type CumulativeResultResponse struct {
+	AnalyteID uint                    `json:"analyte_id"`
+	Code      string                  `json:"code"`
+	Name      string                  `json:"name"`
+	Unit      string                  `json:"unit"`
+	Results   []CumulativeResultPoint `json:"results"`
+}
@@ -0,0 +1,7 @@
+package schemas
+
+// MarkNotificationsReadInput marks in-app alerts as read by an employee
+type MarkNotificationsReadInput struct {
+	NotificationIDs []uint `json:"notification_ids" binding:"required,min=1"`
+	EmployeeID      uint   `json:"employee_id" binding:"required"`
+}
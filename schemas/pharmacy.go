@@ -0,0 +1,304 @@
+package schemas
+
+import "time"
+
+// StockMovementInput records a manual ledger entry. Quantity is the number of
+// units moved; only adjustments take a sign, negative to reduce stock.
+// Receipts describe the batch received in Batch; other movements may name an
+// existing batch by BatchID.
+type StockMovementInput struct {
+	EntityID      uint        `json:"entity_id" binding:"required"`
+	MedicineID    uint        `json:"medicine_id" binding:"required"`
+	Type          string      `json:"type" binding:"required,oneof=Receipt Return Adjustment ExpiryWriteOff"`
+	Quantity      int         `json:"quantity" binding:"required"`
+	BatchID       *uint       `json:"batch_id"`
+	Batch         *BatchInput `json:"batch"`
+	ReasonCode    string      `json:"reason_code"`
+	Notes         string      `json:"notes"`
+	PerformedByID uint        `json:"performed_by_id" binding:"required"`
+}
+
+// BatchInput describes a batch as printed on the pack. ExpiryDate is
+// YYYY-MM-DD.
+type BatchInput struct {
+	BatchNumber   string  `json:"batch_number" binding:"required,max=50"`
+	ExpiryDate    string  `json:"expiry_date" binding:"required"`
+	MRP           float64 `json:"mrp" binding:"min=0"`
+	PurchasePrice float64 `json:"purchase_price" binding:"min=0"`
+}
+
+// WriteOffExpiredInput pulls all expired batches at an entity
+type WriteOffExpiredInput struct {
+	EntityID      uint `json:"entity_id" binding:"required"`
+	PerformedByID uint `json:"performed_by_id" binding:"required"`
+}
+
+// NearExpiryRow is one batch of the near-expiry report
+type NearExpiryRow struct {
+	BatchID       uint      `json:"batch_id"`
+	MedicineID    uint      `json:"medicine_id"`
+	MedicineName  string    `json:"medicine_name"`
+	BatchNumber   string    `json:"batch_number"`
+	ExpiryDate    time.Time `json:"expiry_date"`
+	DaysLeft      int       `json:"days_left"` // Negative once expired
+	Expired       bool      `json:"expired"`
+	Quantity      int       `json:"quantity"`
+	PurchaseValue float64   `json:"purchase_value"`
+}
+
+// StockCardResponse is the ledger of one medicine at one entity over a period
+type StockCardResponse struct {
+	EntityID       uint                   `json:"entity_id"`
+	MedicineID     uint                   `json:"medicine_id"`
+	MedicineName   string                 `json:"medicine_name"`
+	From           *time.Time             `json:"from,omitempty"`
+	To             *time.Time             `json:"to,omitempty"`
+	OpeningBalance int                    `json:"opening_balance"`
+	TotalIn        int                    `json:"total_in"`
+	TotalOut       int                    `json:"total_out"`
+	ClosingBalance int                    `json:"closing_balance"`
+	Movements      []StockCardMovementRow `json:"movements"`
+}
+
+type StockCardMovementRow struct {
+	ID            uint      `json:"id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	Type          string    `json:"type"`
+	ReasonCode    string    `json:"reason_code,omitempty"`
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   *uint     `json:"reference_id,omitempty"`
+	BatchID       *uint     `json:"batch_id,omitempty"`
+	BatchNumber   string    `json:"batch_number,omitempty"`
+	In            int       `json:"in"`
+	Out           int       `json:"out"`
+	Balance       int       `json:"balance"`
+	Notes         string    `json:"notes,omitempty"`
+	PerformedByID uint      `json:"performed_by_id"`
+}
+
+// DispenseInput hands over medicines against a prescription. Items may cover
+// part of the prescription; MedicineID is only needed for free-text items or
+// to substitute the prescribed medicine.
+type DispenseInput struct {
+	PrescriptionID uint                `json:"prescription_id" binding:"required"`
+	EntityID       uint                `json:"entity_id" binding:"required"`
+	PharmacistID   uint                `json:"pharmacist_id" binding:"required"`
+	Notes          string              `json:"notes"`
+	Items          []DispenseItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+type DispenseItemInput struct {
+	PrescriptionItemID uint   `json:"prescription_item_id" binding:"required"`
+	MedicineID         uint   `json:"medicine_id"`
+	Quantity           int    `json:"quantity" binding:"required,min=1"`
+	SubstitutionReason string `json:"substitution_reason"`
+}
+
+// DispensableItem is a prescription item as the pharmacist sees it, with what
+// is left to hand over and the usable stock at the pharmacy
+type DispensableItem struct {
+	PrescriptionItemID uint   `json:"prescription_item_id"`
+	Details            string `json:"details"`
+	MedicineID         *uint  `json:"medicine_id,omitempty"`
+	MedicineName       string `json:"medicine_name,omitempty"`
+	Quantity           int    `json:"quantity"`
+	DispensedQuantity  int    `json:"dispensed_quantity"`
+	Remaining          int    `json:"remaining"`
+	Available          int    `json:"available"`
+}
+
+type DispensablePrescription struct {
+	PrescriptionID uint              `json:"prescription_id"`
+	PatientID      uint              `json:"patient_id"`
+	PatientName    string            `json:"patient_name"`
+	DoctorName     string            `json:"doctor_name"`
+	DateIssued     time.Time         `json:"date_issued"`
+	DispenseStatus string            `json:"dispense_status"`
+	Items          []DispensableItem `json:"items"`
+}
+
+type SupplierInput struct {
+	EntityID         uint   `json:"entity_id" binding:"required"`
+	Name             string `json:"name" binding:"required,max=150"`
+	ContactPerson    string `json:"contact_person"`
+	Phone            string `json:"phone" binding:"max=20"`
+	Email            string `json:"email" binding:"omitempty,email"`
+	Address          string `json:"address"`
+	TaxNumber        string `json:"tax_number" binding:"max=30"`
+	DrugLicense      string `json:"drug_license" binding:"max=50"`
+	PaymentTermsDays int    `json:"payment_terms_days" binding:"min=0"`
+}
+
+// UpdateSupplierInput edits a supplier; omitted fields are left unchanged
+type UpdateSupplierInput struct {
+	SupplierID       uint    `json:"supplier_id" binding:"required"`
+	Name             *string `json:"name" binding:"omitempty,max=150"`
+	ContactPerson    *string `json:"contact_person"`
+	Phone            *string `json:"phone" binding:"omitempty,max=20"`
+	Email            *string `json:"email" binding:"omitempty,email"`
+	Address          *string `json:"address"`
+	TaxNumber        *string `json:"tax_number" binding:"omitempty,max=30"`
+	DrugLicense      *string `json:"drug_license" binding:"omitempty,max=50"`
+	PaymentTermsDays *int    `json:"payment_terms_days" binding:"omitempty,min=0"`
+	IsActive         *bool   `json:"is_active"`
+}
+
+// PurchaseOrderInput raises a draft purchase order. ExpectedAt is YYYY-MM-DD.
+type PurchaseOrderInput struct {
+	EntityID    uint                     `json:"entity_id" binding:"required"`
+	SupplierID  uint                     `json:"supplier_id" binding:"required"`
+	OrderedByID uint                     `json:"ordered_by_id" binding:"required"`
+	ExpectedAt  string                   `json:"expected_at"`
+	Notes       string                   `json:"notes"`
+	Items       []PurchaseOrderItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+type PurchaseOrderItemInput struct {
+	MedicineID uint    `json:"medicine_id" binding:"required"`
+	Quantity   int     `json:"quantity" binding:"required,min=1"`
+	UnitPrice  float64 `json:"unit_price" binding:"min=0"`
+}
+
+type ApprovePurchaseOrderInput struct {
+	PurchaseOrderID uint `json:"purchase_order_id" binding:"required"`
+	ApprovedByID    uint `json:"approved_by_id" binding:"required"`
+}
+
+type PurchaseOrderActionInput struct {
+	PurchaseOrderID uint `json:"purchase_order_id" binding:"required"`
+}
+
+// GoodsReceiptInput records a delivery (GRN) against a purchase order
+type GoodsReceiptInput struct {
+	PurchaseOrderID   uint                    `json:"purchase_order_id" binding:"required"`
+	ReceivedByID      uint                    `json:"received_by_id" binding:"required"`
+	SupplierInvoiceNo string                  `json:"supplier_invoice_no" binding:"max=50"`
+	Notes             string                  `json:"notes"`
+	Items             []GoodsReceiptItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+// GoodsReceiptItemInput is one batch delivered for a purchase order item.
+// UnitPrice is the invoiced price, defaulting to the ordered price.
+type GoodsReceiptItemInput struct {
+	PurchaseOrderItemID uint    `json:"purchase_order_item_id" binding:"required"`
+	BatchNumber         string  `json:"batch_number" binding:"required,max=50"`
+	ExpiryDate          string  `json:"expiry_date" binding:"required"`
+	Quantity            int     `json:"quantity" binding:"min=0"`
+	RejectedQuantity    int     `json:"rejected_quantity" binding:"min=0"`
+	RejectionReason     string  `json:"rejection_reason"`
+	UnitPrice           float64 `json:"unit_price" binding:"min=0"`
+	MRP                 float64 `json:"mrp" binding:"min=0"`
+}
+
+// PurchaseOrderDiscrepancy compares what was ordered with what was received
+type PurchaseOrderDiscrepancy struct {
+	PurchaseOrderID uint                 `json:"purchase_order_id"`
+	SupplierID      uint                 `json:"supplier_id"`
+	SupplierName    string               `json:"supplier_name"`
+	Status          string               `json:"status"`
+	OrderedAmount   float64              `json:"ordered_amount"`
+	ReceivedAmount  float64              `json:"received_amount"` // Accepted quantities at invoiced prices
+	Items           []DiscrepancyItemRow `json:"items"`
+}
+
+type DiscrepancyItemRow struct {
+	PurchaseOrderItemID uint     `json:"purchase_order_item_id"`
+	MedicineID          uint     `json:"medicine_id"`
+	MedicineName        string   `json:"medicine_name"`
+	OrderedQuantity     int      `json:"ordered_quantity"`
+	ReceivedQuantity    int      `json:"received_quantity"`
+	RejectedQuantity    int      `json:"rejected_quantity"`
+	ShortQuantity       int      `json:"short_quantity"`  // Ordered but not accepted
+	ExcessQuantity      int      `json:"excess_quantity"` // Accepted beyond the order
+	OrderedUnitPrice    float64  `json:"ordered_unit_price"`
+	InvoicedUnitPrice   float64  `json:"invoiced_unit_price"` // Average over accepted quantities
+	PriceVariance       float64  `json:"price_variance"`      // (invoiced - ordered) x accepted quantity
+	Issues              []string `json:"issues"`
+}
+
+// ReorderRuleInput sets when and how much of a medicine an entity reorders.
+// A reorder level of 0 turns the rule off; a reorder quantity of 0 sizes
+// orders from recent consumption.
+type ReorderRuleInput struct {
+	EntityID            uint  `json:"entity_id" binding:"required"`
+	MedicineID          uint  `json:"medicine_id" binding:"required"`
+	ReorderLevel        int   `json:"reorder_level" binding:"min=0"`
+	ReorderQuantity     int   `json:"reorder_quantity" binding:"min=0"`
+	PreferredSupplierID *uint `json:"preferred_supplier_id"`
+}
+
+type ReorderRunInput struct {
+	EntityID uint `json:"entity_id" binding:"required"`
+}
+
+// StockTransferInput requests medicines from another entity. The receiving
+// entity raises it; the sending entity approves and dispatches it.
+type StockTransferInput struct {
+	FromEntityID  uint                     `json:"from_entity_id" binding:"required"`
+	ToEntityID    uint                     `json:"to_entity_id" binding:"required,nefield=FromEntityID"`
+	RequestedByID uint                     `json:"requested_by_id" binding:"required"`
+	Notes         string                   `json:"notes"`
+	Items         []StockTransferItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+type StockTransferItemInput struct {
+	MedicineID uint `json:"medicine_id" binding:"required"`
+	Quantity   int  `json:"quantity" binding:"required,min=1"`
+}
+
+type ApproveStockTransferInput struct {
+	StockTransferID uint `json:"stock_transfer_id" binding:"required"`
+	ApprovedByID    uint `json:"approved_by_id" binding:"required"`
+}
+
+type RejectStockTransferInput struct {
+	StockTransferID uint   `json:"stock_transfer_id" binding:"required"`
+	Reason          string `json:"reason" binding:"required"`
+}
+
+type StockTransferActionInput struct {
+	StockTransferID uint `json:"stock_transfer_id" binding:"required"`
+}
+
+// DispatchStockTransferInput sends an approved transfer. Items may lower the
+// quantity sent per transfer item; items not listed are sent in full.
+type DispatchStockTransferInput struct {
+	StockTransferID uint                        `json:"stock_transfer_id" binding:"required"`
+	DispatchedByID  uint                        `json:"dispatched_by_id" binding:"required"`
+	Items           []DispatchTransferItemInput `json:"items" binding:"dive"`
+}
+
+type DispatchTransferItemInput struct {
+	StockTransferItemID uint `json:"stock_transfer_item_id" binding:"required"`
+	Quantity            int  `json:"quantity" binding:"min=0"`
+}
+
+// ReceiveStockTransferInput records the arrival of a dispatched transfer.
+// Batch lines not listed in Shortages arrived in full.
+type ReceiveStockTransferInput struct {
+	StockTransferID uint                    `json:"stock_transfer_id" binding:"required"`
+	ReceivedByID    uint                    `json:"received_by_id" binding:"required"`
+	Shortages       []TransferShortageInput `json:"shortages" binding:"dive"`
+}
+
+type TransferShortageInput struct {
+	StockTransferBatchID uint   `json:"stock_transfer_batch_id" binding:"required"`
+	Quantity             int    `json:"quantity" binding:"required,min=1"`
+	Reason               string `json:"reason" binding:"omitempty,oneof=Lost Damaged"` // Defaults to Lost
+}
+
+// InTransitRow is one dispatched batch line not yet received, seen from the
+// entity that sent it (Out) or is to receive it (In)
+type InTransitRow struct {
+	StockTransferID uint       `json:"stock_transfer_id"`
+	Direction       string     `json:"direction"`
+	FromEntityID    uint       `json:"from_entity_id"`
+	ToEntityID      uint       `json:"to_entity_id"`
+	MedicineID      uint       `json:"medicine_id"`
+	MedicineName    string     `json:"medicine_name"`
+	BatchNumber     string     `json:"batch_number,omitempty"`
+	ExpiryDate      *time.Time `json:"expiry_date,omitempty"`
+	Quantity        int        `json:"quantity"`
+	Value           float64    `json:"value"` // At purchase price
+	DispatchedAt    *time.Time `json:"dispatched_at"`
+}
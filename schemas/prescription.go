@@ -3,28 +3,69 @@ package schemas
 import "time"
 
 type CreatePrescriptionInput struct {
-	VisitID             uint     `json:"visit_id" binding:"required"`
-	VisitType           string   `json:"visit_type" binding:"required"` // "IP" or "OP"
-	PatientID           uint     `json:"patient_id" binding:"required"`
-	DoctorID            uint     `json:"doctor_id" binding:"required"`
-	Notes               string   `json:"notes"`
-	PrescriptionDetails []string `json:"prescription_details" binding:"required"`
+	VisitID             uint                    `json:"visit_id" binding:"required"`
+	VisitType           string                  `json:"visit_type" binding:"required"` // "IP" or "OP"
+	PatientID           uint                    `json:"patient_id" binding:"required"`
+	DoctorID            uint                    `json:"doctor_id" binding:"required"`
+	Notes               string                  `json:"notes"`
+	PrescriptionDetails []string                `json:"prescription_details" binding:"required_without=Items"`
+	Items               []PrescriptionItemInput `json:"items" binding:"dive"`
+}
+
+// PrescriptionItemInput is a prescription line naming a catalog medicine and
+// the quantity the pharmacy should hand over
+type PrescriptionItemInput struct {
+	MedicineID uint   `json:"medicine_id" binding:"required"`
+	Quantity   int    `json:"quantity" binding:"min=0"`
+	Details    string `json:"details"` // Dose, frequency and duration as written
+}
+
+type PrescriptionItemResponse struct {
+	ID                uint   `json:"id"`
+	Details           string `json:"details"`
+	MedicineID        *uint  `json:"medicine_id,omitempty"`
+	MedicineName      string `json:"medicine_name,omitempty"`
+	Quantity          int    `json:"quantity"`
+	DispensedQuantity int    `json:"dispensed_quantity"`
 }
 
 type PrescriptionDetailsResponse struct {
-	ID                uint      `json:"id"`
-	DoctorName        string    `json:"doctor_name"`
-	DateIssued        time.Time `json:"date_issued"`
-	Notes             string    `json:"notes"`
-	PrescriptionItems []string  `json:"prescription_items"`
+	ID                uint                       `json:"id"`
+	DoctorName        string                     `json:"doctor_name"`
+	DateIssued        time.Time                  `json:"date_issued"`
+	Notes             string                     `json:"notes"`
+	PrescriptionItems []string                   `json:"prescription_items"`
+	DispenseStatus    string                     `json:"dispense_status"`
+	Items             []PrescriptionItemResponse `json:"items"`
 }
 
 type EditPrescriptionRequest struct {
-	PrescriptionID    uint     `json:"prescription_id" binding:"required"`
-	PrescriptionItems []string `json:"prescription_items" binding:"required"`
+	PrescriptionID    uint                    `json:"prescription_id" binding:"required"`
+	PrescriptionItems []string                `json:"prescription_items" binding:"required_without=Items"`
+	Items             []PrescriptionItemInput `json:"items" binding:"dive"`
 }
 
 type EditPrescriptionItem struct {
 	ID                  uint   `json:"id" binding:"required"` // Prescription item ID
 	PrescriptionDetails string `json:"prescription_details"`  // New prescription details
 }
+
+type GenerateDischargeSummaryInput struct {
+	VisitID uint `json:"visit_id" binding:"required"`
+}
+
+// UpdateDischargeSummaryInput edits a draft summary; omitted fields are left unchanged
+type UpdateDischargeSummaryInput struct {
+	SummaryID            uint    `json:"summary_id" binding:"required"`
+	Diagnosis            *string `json:"diagnosis"`
+	HospitalCourse       *string `json:"hospital_course"`
+	TreatmentPlan        *string `json:"treatment_plan"`
+	Procedures           *string `json:"procedures"`
+	Vitals               *string `json:"vitals"`
+	Medications          *string `json:"medications"`
+	FollowUpInstructions *string `json:"follow_up_instructions"`
+}
+
+type SignDischargeSummaryInput struct {
+	SummaryID uint `json:"summary_id" binding:"required"`
+}
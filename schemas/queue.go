@@ -0,0 +1,44 @@
+package schemas
+
+import "time"
+
+// CheckInInput issues a queue token for a patient, either against an
+// appointment or as a walk-in when AppointmentID is omitted.
+type CheckInInput struct {
+	DoctorID      uint  `json:"doctor_id" binding:"required"`
+	EntityID      uint  `json:"entity_id" binding:"required"`
+	PatientID     uint  `json:"patient_id" binding:"required"`
+	AppointmentID *uint `json:"appointment_id,omitempty"`
+}
+
+type CallNextInput struct {
+	DoctorID uint `json:"doctor_id" binding:"required"`
+}
+
+type QueueTokenActionInput struct {
+	TokenID uint `json:"token_id" binding:"required"`
+}
+
+type QueueTokenResponse struct {
+	ID                   uint       `json:"id"`
+	TokenNumber          int        `json:"token_number"`
+	PatientID            uint       `json:"patient_id"`
+	PatientName          string     `json:"patient_name"`
+	AppointmentID        *uint      `json:"appointment_id"`
+	Status               string     `json:"status"`
+	CheckedInAt          time.Time  `json:"checked_in_at"`
+	CalledAt             *time.Time `json:"called_at,omitempty"`
+	EstimatedWaitMinutes int        `json:"estimated_wait_minutes"`
+}
+
+// QueueSnapshot is the state of one doctor's queue for a day, as sent to
+// waiting-room displays and doctor consoles.
+type QueueSnapshot struct {
+	DoctorID          uint                 `json:"doctor_id"`
+	QueueDate         string               `json:"queue_date"`
+	Current           *QueueTokenResponse  `json:"current"`
+	Waiting           []QueueTokenResponse `json:"waiting"`
+	Skipped           []QueueTokenResponse `json:"skipped"`
+	CompletedCount    int                  `json:"completed_count"`
+	AvgConsultMinutes int                  `json:"avg_consult_minutes"`
+}
@@ -0,0 +1,33 @@
+package schemas
+
+import "time"
+
+type RadiologyOrderInput struct {
+	VisitID            uint   `json:"visit_id" binding:"required"`
+	DoctorID           uint   `json:"doctor_id" binding:"required"`
+	Modality           string `json:"modality" binding:"required,oneof=CR DX CT MR US MG NM PT XA RF"`
+	BodyPart           string `json:"body_part" binding:"required"`
+	Laterality         string `json:"laterality" binding:"omitempty,oneof=Left Right Bilateral"`
+	Priority           string `json:"priority" binding:"omitempty,oneof=Routine Urgent Stat"`
+	ClinicalIndication string `json:"clinical_indication" binding:"required"`
+}
+
+type ScheduleRadiologyInput struct {
+	OrderID     uint      `json:"order_id" binding:"required"`
+	ScheduledAt time.Time `json:"scheduled_at" binding:"required"`
+	Room        string    `json:"room" binding:"required"`
+}
+
+type RadiologyOrderActionInput struct {
+	OrderID uint `json:"order_id" binding:"required"`
+}
+
+// RadiologyReportInput saves the report of a performed study. With finalize
+// set the report is locked and the order becomes Reported.
+type RadiologyReportInput struct {
+	OrderID       uint   `json:"order_id" binding:"required"`
+	RadiologistID uint   `json:"radiologist_id" binding:"required"`
+	Findings      string `json:"findings" binding:"required"`
+	Impression    string `json:"impression" binding:"required"`
+	Finalize      bool   `json:"finalize"`
+}
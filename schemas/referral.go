@@ -0,0 +1,72 @@
+package schemas
+
+import "time"
+
+// ReferralInput refers a visit to a doctor or, without to_doctor_id, to a
+// department of the target entity
+type ReferralInput struct {
+	VisitID        uint   `json:"visit_id" binding:"required"`
+	FromDoctorID   uint   `json:"from_doctor_id" binding:"required"`
+	ToDoctorID     *uint  `json:"to_doctor_id"`
+	Department     string `json:"department"`
+	TargetEntityID uint   `json:"target_entity_id"` // Defaults to the receiving doctor's entity
+	Reason         string `json:"reason" binding:"required"`
+	Urgency        string `json:"urgency" binding:"omitempty,oneof=Routine Urgent Emergency"`
+}
+
+// AcceptReferralInput books the referred patient with the accepting doctor
+type AcceptReferralInput struct {
+	ReferralID      uint      `json:"referral_id" binding:"required"`
+	DoctorID        uint      `json:"doctor_id" binding:"required"`
+	AppointmentTime time.Time `json:"appointment_time" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"omitempty,min=5,max=480"`
+	Notes           string    `json:"notes"`
+}
+
+type ReferralActionInput struct {
+	ReferralID uint   `json:"referral_id" binding:"required"`
+	DoctorID   uint   `json:"doctor_id" binding:"required"`
+	Notes      string `json:"notes"`
+}
+
+// ReferralInboxItem is a referral as listed in the receiving doctor's inbox.
+// It identifies the patient and summarises the referred visit; the full
+// visit is fetched separately through the referral.
+type ReferralInboxItem struct {
+	ID               uint      `json:"id"`
+	Status           string    `json:"status"`
+	Urgency          string    `json:"urgency"`
+	Reason           string    `json:"reason"`
+	Department       string    `json:"department"`
+	CreatedAt        time.Time `json:"created_at"`
+	PatientID        uint      `json:"patient_id"`
+	PatientName      string    `json:"patient_name"`
+	FromDoctorName   string    `json:"from_doctor_name"`
+	ToDoctorID       *uint     `json:"to_doctor_id"`
+	TargetEntityName string    `json:"target_entity_name"`
+	AppointmentID    *uint     `json:"appointment_id"`
+	VisitID          uint      `json:"visit_id"`
+	VisitDate        time.Time `json:"visit_date"`
+	VisitType        string    `json:"visit_type"`
+	Diagnosis        string    `json:"diagnosis"`
+}
+
+// ReferredVisitResponse is the part of a patient's record shared with the
+// receiving doctor: the referred visit and nothing else
+type ReferredVisitResponse struct {
+	ReferralID    uint                   `json:"referral_id"`
+	PatientName   string                 `json:"patient_name"`
+	Gender        string                 `json:"gender"`
+	DateOfBirth   time.Time              `json:"date_of_birth"`
+	Reason        string                 `json:"reason"`
+	Urgency       string                 `json:"urgency"`
+	Visit         VisitResponse          `json:"visit"`
+	Prescriptions []ReferredPrescription `json:"prescriptions"`
+}
+
+type ReferredPrescription struct {
+	ID         uint      `json:"id"`
+	DateIssued time.Time `json:"date_issued"`
+	Items      []string  `json:"items"`
+	Notes      string    `json:"notes"`
+}
@@ -0,0 +1,53 @@
+package schemas
+
+type WardInput struct {
+	EntityID          uint   `json:"entity_id" binding:"required"`
+	Name              string `json:"name" binding:"required"`
+	WardType          string `json:"ward_type"`
+	GenderRestriction string `json:"gender_restriction" binding:"omitempty,oneof=Male Female Any"`
+}
+
+type RoomInput struct {
+	WardID     uint    `json:"ward_id" binding:"required"`
+	RoomNumber string  `json:"room_number" binding:"required"`
+	RoomType   string  `json:"room_type"`
+	DailyRate  float64 `json:"daily_rate" binding:"min=0"`
+}
+
+type BedInput struct {
+	RoomID    uint   `json:"room_id" binding:"required"`
+	BedNumber string `json:"bed_number" binding:"required"`
+}
+
+// BedStatusInput changes a bed's housekeeping status. Occupied is set only by
+// allocation, so it cannot be chosen here.
+type BedStatusInput struct {
+	BedID  uint   `json:"bed_id" binding:"required"`
+	Status string `json:"status" binding:"required,oneof=Free Cleaning Blocked"`
+}
+
+type BedOccupancyResponse struct {
+	ID          uint   `json:"id"`
+	BedNumber   string `json:"bed_number"`
+	Status      string `json:"status"`
+	VisitID     *uint  `json:"visit_id"`
+	PatientID   uint   `json:"patient_id,omitempty"`
+	PatientName string `json:"patient_name,omitempty"`
+}
+
+type RoomOccupancyResponse struct {
+	ID         uint                   `json:"id"`
+	RoomNumber string                 `json:"room_number"`
+	RoomType   string                 `json:"room_type"`
+	DailyRate  float64                `json:"daily_rate"`
+	Beds       []BedOccupancyResponse `json:"beds"`
+}
+
+type WardOccupancyResponse struct {
+	ID                uint                    `json:"id"`
+	Name              string                  `json:"name"`
+	WardType          string                  `json:"ward_type"`
+	GenderRestriction string                  `json:"gender_restriction"`
+	Summary           map[string]int          `json:"summary"` // Bed count per status
+	Rooms             []RoomOccupancyResponse `json:"rooms"`
+}
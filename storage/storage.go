@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Backend stores uploaded files under opaque keys such as
+// "documents/12/3f9c...". Keys use forward slashes whatever the backend.
+type Backend interface {
+	Name() string
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// FromEnv returns the backend selected by STORAGE_BACKEND, "local" (the
+// default, rooted at STORAGE_LOCAL_DIR or ./uploads) or "s3" (configured by
+// the STORAGE_S3_* settings).
+func FromEnv() (Backend, error) {
+	switch strings.ToLower(os.Getenv("STORAGE_BACKEND")) {
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_DIR")
+		if root == "" {
+			root = "uploads"
+		}
+		return &Local{Root: root}, nil
+
+	case "s3":
+		s3 := &S3{
+			Endpoint:  os.Getenv("STORAGE_S3_ENDPOINT"),
+			Region:    os.Getenv("STORAGE_S3_REGION"),
+			Bucket:    os.Getenv("STORAGE_S3_BUCKET"),
+			AccessKey: os.Getenv("STORAGE_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("STORAGE_S3_SECRET_KEY"),
+		}
+		if s3.Endpoint == "" || s3.Bucket == "" || s3.AccessKey == "" || s3.SecretKey == "" {
+			return nil, errors.New("STORAGE_S3_ENDPOINT, STORAGE_S3_BUCKET, STORAGE_S3_ACCESS_KEY and STORAGE_S3_SECRET_KEY are required")
+		}
+		if s3.Region == "" {
+			s3.Region = "us-east-1"
+		}
+		return s3, nil
+	}
+	return nil, fmt.Errorf("unknown STORAGE_BACKEND %q", os.Getenv("STORAGE_BACKEND"))
+}
+
+// Open reads a file recorded as stored in the named backend. It fails when the
+// configured backend is a different one, e.g. after a switch from local disk
+// to S3 without migrating old files.
+func Open(ctx context.Context, backendName, key string) (io.ReadCloser, error) {
+	backend, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if backend.Name() != backendName {
+		return nil, fmt.Errorf("file is stored in the %s backend but %s is configured", backendName, backend.Name())
+	}
+	return backend.Open(ctx, key)
+}
+
+// Key joins path elements into a storage key
+func Key(parts ...string) string {
+	return strings.Join(parts, "/")
+}
+
+// Local keeps files on the local filesystem below Root
+type Local struct {
+	Root string
+}
+
+func (l *Local) Name() string {
+	return "local"
+}
+
+func (l *Local) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial upload
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a key below Root, refusing keys that would escape it
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(l.Root, clean), nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPath(t *testing.T) {
+	root := filepath.Join("srv", "uploads")
+	tests := []struct {
+		key     string
+		want    string
+		invalid bool
+	}{
+		{key: "documents/12/abc", want: filepath.Join(root, "documents", "12", "abc")},
+		{key: "documents/./12//abc", want: filepath.Join(root, "documents", "12", "abc")},
+		{key: "documents/../radiology/1", want: filepath.Join(root, "radiology", "1")},
+		{key: "..foo/bar", want: filepath.Join(root, "..foo", "bar")},
+		{key: "", invalid: true},
+		{key: ".", invalid: true},
+		{key: "..", invalid: true},
+		{key: "../etc/passwd", invalid: true},
+		{key: "documents/../../etc/passwd", invalid: true},
+		{key: "a/b/../../../outside", invalid: true},
+		{key: "/etc/passwd", invalid: true},
+	}
+
+	local := &Local{Root: root}
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			got, err := local.path(test.key)
+			if test.invalid {
+				if err == nil {
+					t.Fatalf("path(%q) = %q, want an error", test.key, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q) error = %v", test.key, err)
+			}
+			if got != test.want {
+				t.Errorf("path(%q) = %q, want %q", test.key, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	local := &Local{Root: filepath.Join(dir, "uploads")}
+	ctx := context.Background()
+	key := Key("documents", "3", "f00d")
+	content := []byte("hello")
+
+	if err := local.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	reader, err := local.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	read, _ := io.ReadAll(reader)
+	reader.Close()
+	if !bytes.Equal(read, content) {
+		t.Errorf("Open() read %q, want %q", read, content)
+	}
+
+	if err := local.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := local.Open(ctx, key); err != ErrNotFound {
+		t.Errorf("Open() after Delete error = %v, want ErrNotFound", err)
+	}
+
+	err = local.Put(ctx, "../escaped", strings.NewReader("x"), 1, "")
+	if err == nil {
+		t.Fatal("Put() outside Root succeeded")
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "escaped")); !os.IsNotExist(statErr) {
+		t.Errorf("file was written outside Root: %v", statErr)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"apps90-hms/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage:
+  storagetool stub <listen-addr> <dir>   run a minimal S3-compatible server storing objects in dir
+  storagetool check                      write, read back and delete a test object through the
+                                         backend configured by STORAGE_BACKEND and STORAGE_*`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch {
+	case os.Args[1] == "stub" && len(os.Args) == 4:
+		err = stub(os.Args[2], os.Args[3])
+	case os.Args[1] == "check":
+		err = check()
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// stub stands in for MinIO when testing the S3 backend. It serves path-style
+// PUT, GET, HEAD and DELETE of /<bucket>/<key> from files below dir and
+// rejects requests that are not SigV4 signed. Signatures themselves are not
+// verified.
+func stub(addr, dir string) error {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Printf("%s %s\n", r.Method, r.URL.Path)
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=") || r.Header.Get("X-Amz-Date") == "" {
+			http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+			return
+		}
+		key := strings.TrimPrefix(filepath.Clean("/"+r.URL.Path), "/")
+		if !strings.Contains(key, "/") {
+			http.Error(w, "<Error><Code>InvalidRequest</Code></Error>", http.StatusBadRequest)
+			return
+		}
+		path := filepath.Join(dir, filepath.FromSlash(key))
+
+		switch r.Method {
+		case http.MethodPut:
+			if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			file, err := os.Create(path)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			defer file.Close()
+			if _, err := io.Copy(file, r.Body); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		case http.MethodGet, http.MethodHead:
+			file, err := os.Open(path)
+			if err != nil {
+				http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+				return
+			}
+			defer file.Close()
+			info, _ := file.Stat()
+			http.ServeContent(w, r, "", info.ModTime(), file)
+		case http.MethodDelete:
+			os.Remove(path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	fmt.Println("S3 stub listening on", addr, "storing in", dir)
+	return http.ListenAndServe(addr, handler)
+}
+
+func check() error {
+	godotenv.Load()
+
+	backend, err := storage.FromEnv()
+	if err != nil {
+		return err
+	}
+
+	raw := make([]byte, 8)
+	rand.Read(raw)
+	key := storage.Key("storagetool", hex.EncodeToString(raw))
+	content := []byte("storagetool check " + key)
+	ctx := context.Background()
+
+	if err := backend.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		return fmt.Errorf("put: %w", err)
+	}
+	reader, err := backend.Open(ctx, key)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	read, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if !bytes.Equal(read, content) {
+		return errors.New("read back different content")
+	}
+	if err := backend.Delete(ctx, key); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	if _, err := backend.Open(ctx, key); err != storage.ErrNotFound {
+		return fmt.Errorf("object still readable after delete: %v", err)
+	}
+
+	fmt.Printf("%s backend OK: wrote, read and deleted %s\n", backend.Name(), key)
+	return nil
+}